@@ -0,0 +1,67 @@
+// cmd/blob-gc/main.go
+//
+// blob-gc efface les fichiers stockés qui ne sont plus référencés par une piste,
+// une ressource partagée, un document ou une image :
+//
+//	go run ./cmd/blob-gc -dry-run
+//
+// La même collecte tourne périodiquement dans le serveur (BLOB_GC_INTERVAL).
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"github.com/okinrev/veza-web-app/internal/blob"
+	"github.com/okinrev/veza-web-app/internal/config"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/storage"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg := config.New()
+
+	dryRun := flag.Bool("dry-run", false, "lister les fichiers à effacer sans rien modifier")
+	grace := flag.Duration("grace", cfg.Jobs.BlobGCGrace, "épargner les fichiers et références plus récents")
+	asJSON := flag.Bool("json", false, "afficher le rapport complet en JSON")
+	flag.Parse()
+
+	db, err := database.NewConnection(cfg.Database.URL)
+	if err != nil {
+		log.Fatal("Database connection failed:", err)
+	}
+	defer db.Close()
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Storage configuration failed:", err)
+	}
+
+	report, err := blob.NewStore(db, store).Collect(*dryRun, *grace)
+	if err != nil {
+		log.Fatal("Collection failed:", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, item := range report.Items {
+			log.Printf("%-10s %s (%d octets)", item.Kind, item.Key, item.Size)
+		}
+		log.Print(report.Summary())
+	}
+	if report.Failures > 0 {
+		os.Exit(1)
+	}
+}
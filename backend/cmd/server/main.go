@@ -15,6 +15,7 @@ import (
 	"github.com/okinrev/veza-web-app/internal/api"
 	"github.com/okinrev/veza-web-app/internal/config"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/storage"
 	"github.com/okinrev/veza-web-app/internal/websocket"
 )
 
@@ -55,6 +56,12 @@ func main() {
 		log.Printf("Migration warning: %v", err)
 	}
 
+	// Stockage des fichiers (disque local ou service compatible S3)
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Storage configuration failed:", err)
+	}
+
 	// Obtenir le chemin absolu du projet
 	projectRoot := getProjectRoot()
 	frontendPath := filepath.Join(projectRoot, "talas-frontend", "dist")
@@ -96,7 +103,7 @@ func main() {
 	})
 
 	// Configurer les routes API
-	api.SetupRoutes(router, db, cfg)
+	api.SetupRoutes(router, db, cfg, store, chatManager)
 
 	// Démarrer le serveur
 	port := cfg.Server.Port
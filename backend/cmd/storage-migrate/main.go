@@ -0,0 +1,85 @@
+// cmd/storage-migrate/main.go
+//
+// storage-migrate copie les fichiers stockés d'un pilote de stockage à l'autre,
+// par exemple du disque local vers un service compatible S3 :
+//
+//	go run ./cmd/storage-migrate -from local -to s3
+//
+// Les deux pilotes sont configurés par les variables d'environnement habituelles
+// (STORAGE_LOCAL_ROOT, S3_*). Les fichiers déjà présents à l'arrivée avec la même
+// taille sont ignorés : la commande peut être relancée après une interruption.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"github.com/okinrev/veza-web-app/internal/config"
+	"github.com/okinrev/veza-web-app/internal/storage"
+)
+
+func main() {
+	from := flag.String("from", "local", "pilote source (local, s3)")
+	to := flag.String("to", "s3", "pilote de destination (local, s3)")
+	prefix := flag.String("prefix", "", "ne copier que les clés commençant par ce préfixe (ex. audio/)")
+	dryRun := flag.Bool("dry-run", false, "lister les fichiers à copier sans les copier")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	if *from == *to {
+		log.Fatal("Source and destination drivers must differ")
+	}
+
+	cfg := config.New()
+	src, err := storage.NewBackend(*from, cfg.Storage)
+	if err != nil {
+		log.Fatal("Source storage:", err)
+	}
+	dst, err := storage.NewBackend(*to, cfg.Storage)
+	if err != nil {
+		log.Fatal("Destination storage:", err)
+	}
+
+	var copied, skipped, failed int
+	var bytes int64
+	err = src.List(*prefix, func(info storage.ObjectInfo) error {
+		existing, err := dst.Stat(info.Key)
+		if err == nil && existing.Size == info.Size {
+			skipped++
+			return nil
+		}
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("⚠️ %s: %v", info.Key, err)
+			failed++
+			return nil
+		}
+
+		if *dryRun {
+			log.Printf("%s (%d octets)", info.Key, info.Size)
+		} else if err := storage.Copy(dst, src, info); err != nil {
+			log.Printf("⚠️ %s: %v", info.Key, err)
+			failed++
+			return nil
+		}
+		copied++
+		bytes += info.Size
+		return nil
+	})
+	if err != nil {
+		log.Fatal("Listing failed:", err)
+	}
+
+	verb := "copiés"
+	if *dryRun {
+		verb = "à copier"
+	}
+	log.Printf("%d fichier(s) %s (%d octets), %d déjà présent(s), %d échec(s)", copied, verb, bytes, skipped, failed)
+	if failed > 0 {
+		log.Fatal("Migration incomplete")
+	}
+}
@@ -0,0 +1,45 @@
+package activity
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListActivities liste le fil d'activité (global ou d'un utilisateur)
+func (h *Handler) ListActivities(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	userID, _ := strconv.Atoi(c.Query("user_id"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	activities, total, err := h.service.ListActivities(userID, page, limit)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to retrieve activities", http.StatusInternalServerError)
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+
+	response.PaginatedJSON(c.Writer, activities, meta, "Activities retrieved successfully")
+}
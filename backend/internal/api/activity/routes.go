@@ -0,0 +1,12 @@
+package activity
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	activities := router.Group("/activities")
+
+	// Routes publiques
+	activities.GET("", handler.ListActivities)
+}
@@ -0,0 +1,86 @@
+package activity
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+)
+
+// Types d'activité
+const (
+	TypeTrackPublished    = "track_published"
+	TypeResourcePublished = "resource_published"
+)
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// Record enregistre une activité dans la transaction fournie
+func Record(tx *sql.Tx, userID int, activityType, objectType string, objectID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO activities (user_id, type, object_type, object_id)
+		VALUES ($1, $2, $3, $4)
+	`, userID, activityType, objectType, objectID)
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// ListActivities retourne les activités récentes, éventuellement filtrées par utilisateur
+func (s *Service) ListActivities(userID, page, limit int) ([]models.ActivityWithUser, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	whereClause := ""
+	args := []interface{}{}
+	if userID > 0 {
+		whereClause = " WHERE a.user_id = $1"
+		args = append(args, userID)
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM activities a"+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count activities: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id, a.user_id, a.type, a.object_type, a.object_id, a.created_at, u.username
+		FROM activities a
+		JOIN users u ON u.id = a.user_id
+		%s
+		ORDER BY a.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve activities: %w", err)
+	}
+	defer rows.Close()
+
+	activities := []models.ActivityWithUser{}
+	for rows.Next() {
+		var a models.ActivityWithUser
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Type, &a.ObjectType, &a.ObjectID, &a.CreatedAt, &a.Username); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		activities = append(activities, a)
+	}
+
+	return activities, total, rows.Err()
+}
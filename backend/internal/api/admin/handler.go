@@ -1,11 +1,15 @@
 package admin
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/utils"
 	"github.com/okinrev/veza-web-app/internal/utils/response"
 )
 
@@ -52,11 +56,18 @@ func (h *Handler) GetUsers(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
 	search := c.Query("search")
 	role := c.Query("role")
 
 	users, total, err := h.service.GetUsers(page, limit, search, role)
 	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to get users: %v", err))
 		response.ErrorJSON(c.Writer, "Failed to get users", http.StatusInternalServerError)
 		return
 	}
@@ -71,6 +82,58 @@ func (h *Handler) GetUsers(c *gin.Context) {
 	response.PaginatedJSON(c.Writer, users, meta, "Users retrieved successfully")
 }
 
+// GetUserStorage retourne l'espace de stockage utilisé par un utilisateur et son quota
+func (h *Handler) GetUserStorage(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.service.GetUserStorage(targetID)
+	if err != nil {
+		writeStorageError(c, err, "Failed to get storage usage")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, usage, "Storage usage retrieved successfully")
+}
+
+// SetUserStorageQuota remplace le quota du rôle par un quota propre à l'utilisateur
+func (h *Handler) SetUserStorageQuota(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// quota en octets : 0 pour illimité, null pour revenir au quota du rôle
+	var req struct {
+		Quota *int64 `json:"quota" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.service.SetUserStorageQuota(targetID, req.Quota)
+	if err != nil {
+		writeStorageError(c, err, "Failed to update storage quota")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, usage, "Storage quota updated successfully")
+}
+
+func writeStorageError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, quota.ErrUserNotFound) {
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+	response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+}
+
 func (h *Handler) GetAnalytics(c *gin.Context) {
 	userID, exists := common.GetUserIDFromContext(c)
 	if !exists {
@@ -40,6 +40,12 @@ func (rg *RouteGroup) registerAdminRoutes(router *gin.RouterGroup) {
 	// GET /api/v1/admin/users - Liste des utilisateurs
 	router.GET("/users", rg.handler.GetUsers)
 	
+	// GET /api/v1/admin/users/:id/storage - Espace de stockage d'un utilisateur
+	router.GET("/users/:id/storage", rg.handler.GetUserStorage)
+	
+	// PUT /api/v1/admin/users/:id/storage-quota - Quota propre à un utilisateur
+	router.PUT("/users/:id/storage-quota", rg.handler.SetUserStorageQuota)
+	
 	// GET /api/v1/admin/analytics - Données analytiques
 	router.GET("/analytics", rg.handler.GetAnalytics)
 	
@@ -1,16 +1,21 @@
 package admin
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/okinrev/veza-web-app/internal/database"
 	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
 )
 
 type Service struct {
-	db *database.DB
+	db     *database.DB
+	quotas *quota.Manager
 }
 
-func NewService(db *database.DB) *Service {
-	return &Service{db: db}
+func NewService(db *database.DB, quotas *quota.Manager) *Service {
+	return &Service{db: db, quotas: quotas}
 }
 
 func (s *Service) IsAdmin(userID int) bool {
@@ -34,8 +39,70 @@ func (s *Service) GetDashboardStats() (*models.DashboardStats, error) {
 }
 
 func (s *Service) GetUsers(page, limit int, search, role string) ([]models.UserAnalytics, int, error) {
-	// TODO: Implement based on doc_admin_handler.md
-	return []models.UserAnalytics{}, 0, nil
+	conditions := []string{}
+	args := []interface{}{}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		conditions = append(conditions, fmt.Sprintf("(u.username ILIKE $%d OR u.email ILIKE $%d)", len(args), len(args)))
+	}
+	if role != "" {
+		args = append(args, role)
+		conditions = append(conditions, fmt.Sprintf("u.role = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users u "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT u.id, u.username, u.email, u.role,
+		       (SELECT COUNT(*) FROM tracks t WHERE t.uploader_id = u.id),
+		       (SELECT COUNT(*) FROM shared_ressources r WHERE r.uploader_id = u.id),
+		       (SELECT COUNT(*) FROM listings l WHERE l.user_id = u.id),
+		       (SELECT COUNT(*) FROM messages m WHERE m.from_user = u.id),
+		       (SELECT COUNT(*) FROM products p WHERE p.user_id = u.id),
+		       u.created_at, u.last_login_at, u.is_active,
+		       %s
+		FROM users u
+		%s
+		ORDER BY u.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, quota.UsageExpr("u.id"), where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserAnalytics{}
+	for rows.Next() {
+		var u models.UserAnalytics
+		err := rows.Scan(
+			&u.UserID, &u.Username, &u.Email, &u.Role,
+			&u.TracksCount, &u.ResourcesCount, &u.ListingsCount, &u.MessagesCount, &u.ProductsCount,
+			&u.RegistrationDate, &u.LastActivity, &u.IsActive, &u.StorageUsed,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+// GetUserStorage retourne l'espace de stockage utilisé par un utilisateur et son quota
+func (s *Service) GetUserStorage(userID int) (*models.StorageUsage, error) {
+	return s.quotas.Usage(userID)
+}
+
+// SetUserStorageQuota définit le quota propre à un utilisateur ; nil rétablit celui de son rôle
+func (s *Service) SetUserStorageQuota(userID int, quotaBytes *int64) (*models.StorageUsage, error) {
+	return s.quotas.SetQuota(userID, quotaBytes)
 }
 
 func (s *Service) GetAnalytics() (*models.ContentAnalytics, error) {
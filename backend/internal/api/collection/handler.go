@@ -0,0 +1,477 @@
+package collection
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrCollectionNotFound), errors.Is(err, ErrItemNotFound), errors.Is(err, ErrNoCover),
+		errors.Is(err, ErrNothingToDownload):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotOwner):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrItemAlreadyAdded):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalidItemType), errors.Is(err, ErrCollectionFull), errors.Is(err, ErrInvalidOrder),
+		errors.Is(err, ErrSelfFollow), errors.Is(err, ErrNothingToUpdate), errors.Is(err, ErrEmptyName):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// pagination lit et normalise les paramètres page et limit
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+func paginationMeta(page, limit, total int) *response.Meta {
+	return &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+// ListCollections liste les collections visibles, filtrables par user_id
+func (h *Handler) ListCollections(c *gin.Context) {
+	page, limit := pagination(c)
+	ownerID, _ := strconv.Atoi(c.Query("user_id"))
+	userID, _ := common.GetUserIDFromContext(c)
+
+	collections, total, err := h.service.ListCollections(userID, ownerID, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve collections")
+		return
+	}
+
+	response.PaginatedJSON(c.Writer, collections, paginationMeta(page, limit, total), "Collections retrieved successfully")
+}
+
+// ListFollowedCollections liste les collections suivies par l'utilisateur connecté
+func (h *Handler) ListFollowedCollections(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	page, limit := pagination(c)
+	collections, total, err := h.service.ListFollowed(userID, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve followed collections")
+		return
+	}
+
+	response.PaginatedJSON(c.Writer, collections, paginationMeta(page, limit, total), "Followed collections retrieved successfully")
+}
+
+// GetCollection retourne une collection et ses éléments
+func (h *Handler) GetCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	collection, err := h.service.GetCollection(collectionID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, collection, "Collection retrieved successfully")
+}
+
+func (h *Handler) CreateCollection(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.service.CreateCollection(userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to create collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, collection, "Collection created successfully")
+}
+
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.service.UpdateCollection(collectionID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to update collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, collection, "Collection updated successfully")
+}
+
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	cover, err := h.service.DeleteCollection(collectionID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to delete collection")
+		return
+	}
+	h.removeCover(cover)
+
+	response.SuccessJSON(c.Writer, nil, "Collection deleted successfully")
+}
+
+// coverKey retourne la clé de stockage d'une image de couverture
+func coverKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceCollections, filepath.Base(filename))
+}
+
+// removeCover efface un fichier de couverture remplacé ou orphelin
+func (h *Handler) removeCover(filename string) {
+	if filename == "" {
+		return
+	}
+	if key, err := coverKey(filename); err == nil {
+		h.service.store.Remove(key)
+	}
+}
+
+// UploadCover remplace l'image de couverture d'une collection (JPEG, PNG ou WebP)
+func (h *Handler) UploadCover(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("cover")
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Cover image is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > coverMaxSize {
+		response.ErrorJSON(c.Writer, "Cover image exceeds 5MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Le type est déterminé par le contenu et non par l'extension déclarée
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	ext, ok := coverTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		response.ErrorJSON(c.Writer, "Cover must be a JPEG, PNG or WebP image", http.StatusUnsupportedMediaType)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeError(c, err, "Failed to read cover image")
+		return
+	}
+
+	filename := fmt.Sprintf("%d_%d%s", collectionID, time.Now().UnixNano(), ext)
+	key, err := coverKey(filename)
+	if err != nil {
+		writeError(c, err, "Failed to store cover image")
+		return
+	}
+	if _, err := h.service.store.Put(key, io.LimitReader(file, coverMaxSize)); err != nil {
+		writeError(c, err, "Failed to store cover image")
+		return
+	}
+
+	previous, err := h.service.SetCover(collectionID, userID, filename)
+	if err != nil {
+		h.service.store.Remove(key)
+		writeError(c, err, "Failed to update collection cover")
+		return
+	}
+	h.removeCover(previous)
+
+	response.SuccessJSON(c.Writer, gin.H{"cover_url": fmt.Sprintf(coverURLFmt, collectionID)}, "Cover updated successfully")
+}
+
+// GetCover sert l'image de couverture d'une collection
+func (h *Handler) GetCover(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	filename, err := h.service.GetCover(collectionID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve cover")
+		return
+	}
+
+	key, err := coverKey(filename)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "File not found", http.StatusNotFound)
+		return
+	}
+	if err := h.service.store.Serve(c.Writer, c.Request, key, storage.ServeOptions{Inline: true}); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			response.ErrorJSON(c.Writer, "File not found", http.StatusNotFound)
+			return
+		}
+		writeError(c, err, "Failed to retrieve cover")
+	}
+}
+
+func (h *Handler) AddItem(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req AddItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.AddItem(collectionID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to add item to collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, item, "Item added to collection")
+}
+
+func (h *Handler) RemoveItem(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.RemoveItem(collectionID, userID, itemID); err != nil {
+		writeError(c, err, "Failed to remove item from collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Item removed from collection")
+}
+
+// ReorderItems applique l'ordre donné par la liste complète des identifiants d'éléments
+func (h *Handler) ReorderItems(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ReorderItems(collectionID, userID, req.ItemIDs); err != nil {
+		writeError(c, err, "Failed to reorder collection")
+		return
+	}
+
+	collection, err := h.service.GetCollection(collectionID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, collection, "Collection reordered successfully")
+}
+
+func (h *Handler) FollowCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Follow(collectionID, userID); err != nil {
+		writeError(c, err, "Failed to follow collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Collection followed")
+}
+
+func (h *Handler) UnfollowCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Unfollow(collectionID, userID); err != nil {
+		writeError(c, err, "Failed to unfollow collection")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Collection unfollowed")
+}
+
+// DownloadCollection génère à la volée une archive zip des fichiers de la collection.
+// Les fichiers sont écrits sans recompression, directement dans la réponse.
+func (h *Handler) DownloadCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	name, entries, err := h.service.DownloadEntries(collectionID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to download collection")
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	for _, entry := range entries {
+		if err := h.addToArchive(zw, entry); err != nil {
+			// Les en-têtes sont déjà envoyés : on journalise et on passe au fichier suivant
+			utils.LogError(fmt.Sprintf("Failed to add %s to collection %d archive: %v", entry.key, collectionID, err))
+		}
+	}
+	if err := zw.Close(); err != nil {
+		utils.LogError(fmt.Sprintf("Failed to finalize collection %d archive: %v", collectionID, err))
+	}
+}
+
+func (h *Handler) addToArchive(zw *zip.Writer, entry downloadEntry) error {
+	obj, err := h.service.store.Open(entry.key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     entry.name,
+		Method:   zip.Store,
+		Modified: obj.Info().ModTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, obj)
+	return err
+}
@@ -0,0 +1,37 @@
+package collection
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	collections := router.Group("/collections")
+	{
+		// Routes publiques (l'authentification optionnelle donne accès aux collections privées du propriétaire)
+		public := collections.Group("")
+		public.Use(middleware.OptionalJWTAuthMiddleware(jwtSecret))
+		{
+			public.GET("", handler.ListCollections)
+			public.GET("/:id", handler.GetCollection)
+			public.GET("/:id/cover", handler.GetCover)
+			public.GET("/:id/download", handler.DownloadCollection)
+		}
+
+		// Routes protégées
+		protected := collections.Group("")
+		protected.Use(middleware.JWTAuthMiddleware(jwtSecret))
+		{
+			protected.GET("/followed", handler.ListFollowedCollections)
+			protected.POST("", handler.CreateCollection)
+			protected.PUT("/:id", handler.UpdateCollection)
+			protected.DELETE("/:id", handler.DeleteCollection)
+			protected.PUT("/:id/cover", handler.UploadCover)
+			protected.POST("/:id/items", handler.AddItem)
+			protected.DELETE("/:id/items/:item_id", handler.RemoveItem)
+			protected.PUT("/:id/items", handler.ReorderItems)
+			protected.POST("/:id/follow", handler.FollowCollection)
+			protected.DELETE("/:id/follow", handler.UnfollowCollection)
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package collection
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
+	"github.com/okinrev/veza-web-app/internal/blob"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/storage"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrNotOwner           = errors.New("you are not the owner of this collection")
+	ErrInvalidItemType    = errors.New("item type must be track or shared_resource")
+	ErrItemNotFound       = errors.New("item not found")
+	ErrItemAlreadyAdded   = errors.New("item is already in this collection")
+	ErrCollectionFull     = errors.New("collection has reached the maximum number of items")
+	ErrInvalidOrder       = errors.New("item_ids must list every item of the collection exactly once")
+	ErrSelfFollow         = errors.New("you cannot follow your own collection")
+	ErrNothingToDownload  = errors.New("collection has no downloadable items")
+	ErrNoCover            = errors.New("collection has no cover image")
+	ErrNothingToUpdate    = errors.New("no fields to update")
+	ErrEmptyName          = errors.New("name cannot be empty")
+)
+
+// itemTables associe un type d'élément à sa table
+var itemTables = map[string]string{
+	ItemTrack:          "tracks",
+	ItemSharedResource: "shared_ressources",
+}
+
+// selectCollection lit une collection avec ses compteurs ; $1 est l'utilisateur courant
+const selectCollection = `
+	SELECT c.id, c.user_id, c.name, c.description, c.cover_image, c.is_public,
+	       c.created_at, c.updated_at, u.username,
+	       (SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id),
+	       (SELECT COUNT(*) FROM collection_followers f WHERE f.collection_id = c.id),
+	       EXISTS (SELECT 1 FROM collection_followers f WHERE f.collection_id = c.id AND f.user_id = $1)
+	FROM collections c
+	JOIN users u ON u.id = c.user_id
+`
+
+// visibleCollection restreint aux collections publiques ou appartenant à l'utilisateur courant
+const visibleCollection = "(c.is_public = true OR c.user_id = $1)"
+
+type Service struct {
+	db    *database.DB
+	store *blob.Store
+}
+
+func NewService(db *database.DB, store *blob.Store) *Service {
+	return &Service{db: db, store: store}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCollection(row rowScanner) (*models.CollectionWithDetails, error) {
+	var c models.CollectionWithDetails
+	err := row.Scan(
+		&c.ID, &c.UserID, &c.Name, &c.Description, &c.CoverImage, &c.IsPublic,
+		&c.CreatedAt, &c.UpdatedAt, &c.Username,
+		&c.ItemCount, &c.FollowerCount, &c.IsFollowing,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if c.CoverImage.Valid {
+		c.CoverURL = fmt.Sprintf(coverURLFmt, c.ID)
+	}
+	return &c, nil
+}
+
+// CreateCollection crée une collection vide
+func (s *Service) CreateCollection(userID int, req CreateCollectionRequest) (*models.CollectionWithDetails, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+	isPublic := true
+	if req.IsPublic != nil {
+		isPublic = *req.IsPublic
+	}
+
+	var id int
+	err := s.db.QueryRow(`
+		INSERT INTO collections (user_id, name, description, is_public)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, userID, name, strings.TrimSpace(req.Description), isPublic).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	return s.GetCollection(id, userID)
+}
+
+// GetCollection retourne une collection visible et les éléments que l'utilisateur peut voir
+func (s *Service) GetCollection(collectionID, userID int) (*models.CollectionWithDetails, error) {
+	c, err := scanCollection(s.db.QueryRow(
+		selectCollection+" WHERE c.id = $2 AND "+visibleCollection,
+		userID, collectionID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	c.Items, err = s.loadItems(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadItems retourne les éléments d'une collection dans l'ordre, en masquant
+// les contenus privés d'autres utilisateurs et ceux qui ont été supprimés
+func (s *Service) loadItems(collectionID, userID int) ([]models.CollectionItem, error) {
+	rows, err := s.db.Query(`
+		SELECT ci.id, ci.item_type, ci.item_id, ci.position, ci.added_at,
+		       COALESCE(t.title, r.title), COALESCE(t.filename, r.filename)
+		FROM collection_items ci
+		LEFT JOIN tracks t ON ci.item_type = 'track' AND t.id = ci.item_id
+		LEFT JOIN shared_ressources r ON ci.item_type = 'shared_resource' AND r.id = ci.item_id
+		WHERE ci.collection_id = $1
+		  AND (COALESCE(t.is_public, r.is_public) = true OR COALESCE(t.uploader_id, r.uploader_id) = $2)
+		ORDER BY ci.position, ci.id
+	`, collectionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve collection items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.CollectionItem{}
+	for rows.Next() {
+		var item models.CollectionItem
+		if err := rows.Scan(&item.ID, &item.ItemType, &item.ItemID, &item.Position, &item.AddedAt,
+			&item.Title, &item.Filename); err != nil {
+			return nil, fmt.Errorf("failed to scan collection item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListCollections retourne les collections visibles, éventuellement filtrées par propriétaire
+func (s *Service) ListCollections(userID, ownerID, page, limit int) ([]models.CollectionWithDetails, int, error) {
+	where := " WHERE " + visibleCollection
+	args := []interface{}{userID}
+	if ownerID > 0 {
+		where += " AND c.user_id = $2"
+		args = append(args, ownerID)
+	}
+	return s.queryCollections(where, args, page, limit)
+}
+
+// ListFollowed retourne les collections suivies par l'utilisateur
+func (s *Service) ListFollowed(userID, page, limit int) ([]models.CollectionWithDetails, int, error) {
+	where := " WHERE " + visibleCollection +
+		" AND EXISTS (SELECT 1 FROM collection_followers f WHERE f.collection_id = c.id AND f.user_id = $1)"
+	return s.queryCollections(where, []interface{}{userID}, page, limit)
+}
+
+func (s *Service) queryCollections(where string, args []interface{}, page, limit int) ([]models.CollectionWithDetails, int, error) {
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM collections c"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count collections: %w", err)
+	}
+
+	query := selectCollection + where + " ORDER BY c.updated_at DESC LIMIT $" +
+		strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	rows, err := s.db.Query(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []models.CollectionWithDetails{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, *c)
+	}
+	return collections, total, rows.Err()
+}
+
+// lockOwned verrouille une collection dans la transaction et vérifie son propriétaire
+func lockOwned(tx *sql.Tx, collectionID, userID int) error {
+	var ownerID int
+	err := tx.QueryRow("SELECT user_id FROM collections WHERE id = $1 FOR UPDATE", collectionID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return ErrCollectionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get collection: %w", err)
+	}
+	if ownerID != userID {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// checkOwner vérifie le propriétaire d'une collection et retourne sa couverture actuelle
+func (s *Service) checkOwner(collectionID, userID int) (sql.NullString, error) {
+	var ownerID int
+	var cover sql.NullString
+	err := s.db.QueryRow("SELECT user_id, cover_image FROM collections WHERE id = $1", collectionID).Scan(&ownerID, &cover)
+	if err == sql.ErrNoRows {
+		return cover, ErrCollectionNotFound
+	}
+	if err != nil {
+		return cover, fmt.Errorf("failed to get collection: %w", err)
+	}
+	if ownerID != userID {
+		return cover, ErrNotOwner
+	}
+	return cover, nil
+}
+
+// UpdateCollection met à jour le nom, la description ou la visibilité d'une collection
+func (s *Service) UpdateCollection(collectionID, userID int, req UpdateCollectionRequest) (*models.CollectionWithDetails, error) {
+	if _, err := s.checkOwner(collectionID, userID); err != nil {
+		return nil, err
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrEmptyName
+		}
+		setParts = append(setParts, "name = $"+strconv.Itoa(argCount))
+		args = append(args, name)
+		argCount++
+	}
+	if req.Description != nil {
+		setParts = append(setParts, "description = $"+strconv.Itoa(argCount))
+		args = append(args, strings.TrimSpace(*req.Description))
+		argCount++
+	}
+	if req.IsPublic != nil {
+		setParts = append(setParts, "is_public = $"+strconv.Itoa(argCount))
+		args = append(args, *req.IsPublic)
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	setParts = append(setParts, "updated_at = NOW()")
+	args = append(args, collectionID)
+
+	query := "UPDATE collections SET " + strings.Join(setParts, ", ") + " WHERE id = $" + strconv.Itoa(argCount)
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	return s.GetCollection(collectionID, userID)
+}
+
+// DeleteCollection supprime une collection et retourne sa couverture à effacer
+func (s *Service) DeleteCollection(collectionID, userID int) (string, error) {
+	cover, err := s.checkOwner(collectionID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.db.Exec("DELETE FROM collections WHERE id = $1", collectionID); err != nil {
+		return "", fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return cover.String, nil
+}
+
+// SetCover enregistre la nouvelle couverture et retourne l'ancienne à effacer
+func (s *Service) SetCover(collectionID, userID int, filename string) (string, error) {
+	previous, err := s.checkOwner(collectionID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.Exec(
+		"UPDATE collections SET cover_image = $1, updated_at = NOW() WHERE id = $2",
+		filename, collectionID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to update collection cover: %w", err)
+	}
+	return previous.String, nil
+}
+
+// GetCover retourne le fichier de couverture d'une collection visible
+func (s *Service) GetCover(collectionID, userID int) (string, error) {
+	c, err := scanCollection(s.db.QueryRow(
+		selectCollection+" WHERE c.id = $2 AND "+visibleCollection,
+		userID, collectionID,
+	))
+	if err == sql.ErrNoRows {
+		return "", ErrCollectionNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get collection: %w", err)
+	}
+	if !c.CoverImage.Valid {
+		return "", ErrNoCover
+	}
+	return c.CoverImage.String, nil
+}
+
+// AddItem ajoute en fin de collection une piste ou une ressource accessible au propriétaire
+func (s *Service) AddItem(collectionID, userID int, req AddItemRequest) (*models.CollectionItem, error) {
+	table, ok := itemTables[req.Type]
+	if !ok {
+		return nil, ErrInvalidItemType
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwned(tx, collectionID, userID); err != nil {
+		return nil, err
+	}
+
+	item := models.CollectionItem{ItemType: req.Type, ItemID: req.ID}
+	err = tx.QueryRow(
+		"SELECT title, filename FROM "+table+" WHERE id = $1 AND (is_public = true OR uploader_id = $2)",
+		req.ID, userID,
+	).Scan(&item.Title, &item.Filename)
+	if err == sql.ErrNoRows {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM collection_items WHERE collection_id = $1", collectionID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count collection items: %w", err)
+	}
+	if count >= MaxItems {
+		return nil, ErrCollectionFull
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO collection_items (collection_id, item_type, item_id, position)
+		SELECT $1, $2, $3, COALESCE(MAX(position), 0) + 1 FROM collection_items WHERE collection_id = $1
+		ON CONFLICT (collection_id, item_type, item_id) DO NOTHING
+		RETURNING id, position, added_at
+	`, collectionID, req.Type, req.ID).Scan(&item.ID, &item.Position, &item.AddedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrItemAlreadyAdded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add collection item: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE collections SET updated_at = NOW() WHERE id = $1", collectionID); err != nil {
+		return nil, fmt.Errorf("failed to update collection: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit collection item: %w", err)
+	}
+	return &item, nil
+}
+
+// RemoveItem retire un élément d'une collection
+func (s *Service) RemoveItem(collectionID, userID, itemID int) error {
+	if _, err := s.checkOwner(collectionID, userID); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec("DELETE FROM collection_items WHERE id = $1 AND collection_id = $2", itemID, collectionID)
+	if err != nil {
+		return fmt.Errorf("failed to remove collection item: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// ReorderItems applique un nouvel ordre à tous les éléments d'une collection
+func (s *Service) ReorderItems(collectionID, userID int, itemIDs []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwned(tx, collectionID, userID); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id FROM collection_items WHERE collection_id = $1", collectionID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve collection items: %w", err)
+	}
+	existing := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan collection item: %w", err)
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to retrieve collection items: %w", err)
+	}
+
+	if len(itemIDs) != len(existing) {
+		return ErrInvalidOrder
+	}
+	seen := make(map[int]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if !existing[id] || seen[id] {
+			return ErrInvalidOrder
+		}
+		seen[id] = true
+	}
+
+	for i, id := range itemIDs {
+		if _, err := tx.Exec("UPDATE collection_items SET position = $1 WHERE id = $2", i+1, id); err != nil {
+			return fmt.Errorf("failed to reorder collection items: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE collections SET updated_at = NOW() WHERE id = $1", collectionID); err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit collection order: %w", err)
+	}
+	return nil
+}
+
+// Follow abonne l'utilisateur à une collection visible d'un autre utilisateur
+func (s *Service) Follow(collectionID, userID int) error {
+	c, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return err
+	}
+	if c.UserID == userID {
+		return ErrSelfFollow
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO collection_followers (collection_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, collectionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to follow collection: %w", err)
+	}
+	return nil
+}
+
+// Unfollow désabonne l'utilisateur d'une collection
+func (s *Service) Unfollow(collectionID, userID int) error {
+	_, err := s.db.Exec(
+		"DELETE FROM collection_followers WHERE collection_id = $1 AND user_id = $2",
+		collectionID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow collection: %w", err)
+	}
+	return nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^\p{L}\p{N} ._()-]+`)
+
+// archiveName construit un nom de fichier lisible et ordonné pour l'archive
+func archiveName(item models.CollectionItem, index int) string {
+	title := strings.TrimSpace(unsafeNameChars.ReplaceAllString(item.Title, "_"))
+	if title == "" {
+		title = item.ItemType
+	}
+	return fmt.Sprintf("%02d - %s%s", index, title, strings.ToLower(filepath.Ext(item.Filename)))
+}
+
+// DownloadEntries retourne le nom de l'archive et les fichiers d'une collection
+// accessibles à l'utilisateur, dans l'ordre de la collection
+func (s *Service) DownloadEntries(collectionID, userID int) (string, []downloadEntry, error) {
+	c, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entries := []downloadEntry{}
+	for i, item := range c.Items {
+		var key string
+		switch item.ItemType {
+		case ItemTrack:
+			key, err = storage.Key(storage.NamespaceAudio, filepath.Base(item.Filename))
+		case ItemSharedResource:
+			key, err = shared_resources.StorageKey(item.Filename)
+		}
+		if err != nil {
+			continue
+		}
+		entries = append(entries, downloadEntry{name: archiveName(item, i+1), key: key})
+	}
+	if len(entries) == 0 {
+		return "", nil, ErrNothingToDownload
+	}
+
+	name := strings.TrimSpace(unsafeNameChars.ReplaceAllString(c.Name, "_"))
+	if name == "" {
+		name = "collection"
+	}
+	return name + ".zip", entries, nil
+}
@@ -0,0 +1,52 @@
+package collection
+
+const (
+	// Types d'éléments d'une collection
+	ItemTrack          = "track"
+	ItemSharedResource = "shared_resource"
+
+	// MaxItems est le nombre maximal d'éléments par collection
+	MaxItems = 500
+
+	// Couvertures des collections
+	coverMaxSize = 5 << 20 // 5MB
+	coverURLFmt  = "/api/v1/collections/%d/cover"
+)
+
+// coverTypes associe les types MIME acceptés pour une couverture à leur extension
+var coverTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// CreateCollectionRequest represents a request to create a collection
+type CreateCollectionRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=2000"`
+	IsPublic    *bool  `json:"is_public"`
+}
+
+// UpdateCollectionRequest represents a request to update a collection
+type UpdateCollectionRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=2000"`
+	IsPublic    *bool   `json:"is_public,omitempty"`
+}
+
+// AddItemRequest represents a track or shared resource to add to a collection
+type AddItemRequest struct {
+	Type string `json:"type" binding:"required"`
+	ID   int    `json:"id" binding:"required"`
+}
+
+// ReorderRequest lists every item ID of a collection in the new order
+type ReorderRequest struct {
+	ItemIDs []int `json:"item_ids" binding:"required"`
+}
+
+// downloadEntry est un fichier à ajouter à l'archive d'une collection
+type downloadEntry struct {
+	name string
+	key  string
+}
@@ -0,0 +1,203 @@
+package conversation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrConversationNotFound), errors.Is(err, ErrListingNotFound), errors.Is(err, ErrOfferNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrListingNotOpen), errors.Is(err, ErrArchived):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrOwnListing), errors.Is(err, ErrEmptyMessage):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// pagination lit et normalise les paramètres page et limit
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+func paginationMeta(page, limit, total int) *response.Meta {
+	return &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+// params lit l'utilisateur authentifié et l'identifiant de la route
+func params(c *gin.Context, name string) (int, int, bool) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User not authenticated", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid "+name+" ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+// ListConversations retourne les conversations de l'utilisateur (archived=true pour les archives)
+func (h *Handler) ListConversations(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	archived := false
+	if raw := c.Query("archived"); raw != "" {
+		var err error
+		if archived, err = strconv.ParseBool(raw); err != nil {
+			response.ErrorJSON(c.Writer, "Invalid archived filter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, limit := pagination(c)
+	conversations, total, err := h.service.ListConversations(userID, archived, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve conversations")
+		return
+	}
+
+	response.PaginatedJSON(c.Writer, conversations, paginationMeta(page, limit, total), "Conversations retrieved successfully")
+}
+
+// OpenListingConversation ouvre une conversation avec le vendeur d'une annonce
+func (h *Handler) OpenListingConversation(c *gin.Context) {
+	userID, listingID, ok := params(c, "listing")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.service.OpenListingConversation(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to open conversation")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, conversation, "Conversation opened successfully")
+}
+
+// ListListingConversations retourne les conversations d'une annonce visibles par l'utilisateur
+func (h *Handler) ListListingConversations(c *gin.Context) {
+	userID, listingID, ok := params(c, "listing")
+	if !ok {
+		return
+	}
+
+	conversations, err := h.service.ListListingConversations(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve conversations")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, conversations, "Conversations retrieved successfully")
+}
+
+// OpenOfferConversation ouvre la conversation entre les deux parties d'une offre
+func (h *Handler) OpenOfferConversation(c *gin.Context) {
+	userID, offerID, ok := params(c, "offer")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.service.OpenOfferConversation(offerID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to open conversation")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, conversation, "Conversation opened successfully")
+}
+
+// GetConversation retourne une conversation de l'utilisateur
+func (h *Handler) GetConversation(c *gin.Context) {
+	userID, conversationID, ok := params(c, "conversation")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.service.GetConversation(conversationID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve conversation")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, conversation, "Conversation retrieved successfully")
+}
+
+// ListMessages retourne les messages d'une conversation, les plus récents d'abord
+func (h *Handler) ListMessages(c *gin.Context) {
+	userID, conversationID, ok := params(c, "conversation")
+	if !ok {
+		return
+	}
+
+	page, limit := pagination(c)
+	messages, total, err := h.service.ListMessages(conversationID, userID, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve messages")
+		return
+	}
+
+	response.PaginatedJSON(c.Writer, messages, paginationMeta(page, limit, total), "Messages retrieved successfully")
+}
+
+// SendMessage envoie un message dans une conversation active
+func (h *Handler) SendMessage(c *gin.Context) {
+	userID, conversationID, ok := params(c, "conversation")
+	if !ok {
+		return
+	}
+
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.service.SendMessage(conversationID, userID, req.Content)
+	if err != nil {
+		writeError(c, err, "Failed to send message")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, message, "Message sent successfully")
+}
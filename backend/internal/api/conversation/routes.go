@@ -0,0 +1,22 @@
+package conversation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	conversations := router.Group("/conversations")
+	conversations.Use(middleware.JWTAuthMiddleware(jwtSecret))
+
+	// Routes protégées : seuls les participants accèdent à une conversation
+	{
+		conversations.GET("", handler.ListConversations)
+		conversations.GET("/listings/:id", handler.ListListingConversations)
+		conversations.POST("/listings/:id", handler.OpenListingConversation)
+		conversations.POST("/offers/:id", handler.OpenOfferConversation)
+		conversations.GET("/:id", handler.GetConversation)
+		conversations.GET("/:id/messages", handler.ListMessages)
+		conversations.POST("/:id/messages", handler.SendMessage)
+	}
+}
@@ -0,0 +1,349 @@
+package conversation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrListingNotFound      = errors.New("listing not found")
+	ErrOfferNotFound        = errors.New("offer not found")
+	ErrOwnListing           = errors.New("you cannot start a conversation on your own listing")
+	ErrListingNotOpen       = errors.New("listing is no longer open")
+	ErrArchived             = errors.New("conversation is archived")
+	ErrEmptyMessage         = errors.New("message cannot be empty")
+)
+
+// selectConversation lit une conversation avec son annonce, ses participants et
+// son dernier message
+const selectConversation = `
+	SELECT c.id, c.listing_id, c.offer_id, c.buyer_id, c.seller_id, c.archived_at,
+	       c.created_at, COALESCE(c.updated_at, c.created_at),
+	       p.name, l.status, bu.username, su.username,
+	       lm.content, lm.timestamp
+	FROM conversations c
+	JOIN listings l ON l.id = c.listing_id
+	JOIN products p ON p.id = l.product_id
+	JOIN users bu ON bu.id = c.buyer_id
+	JOIN users su ON su.id = c.seller_id
+	LEFT JOIN LATERAL (
+		SELECT m.content, m.timestamp FROM messages m
+		WHERE m.conversation_id = c.id ORDER BY m.id DESC LIMIT 1
+	) lm ON true
+`
+
+// visibleConversation restreint aux conversations dont l'utilisateur $1 est un participant
+const visibleConversation = "(c.buyer_id = $1 OR c.seller_id = $1)"
+
+const selectMessage = `
+	SELECT m.id, m.conversation_id, m.from_user, u.username, u.avatar, m.to_user, m.content, m.timestamp
+	FROM messages m
+	JOIN users u ON u.id = m.from_user
+`
+
+type Service struct {
+	db  *database.DB
+	hub Hub
+}
+
+// NewService crée le service ; sans hub, les messages ne sont pas diffusés en direct
+func NewService(db *database.DB, hub Hub) *Service {
+	return &Service{db: db, hub: hub}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanConversation(row rowScanner) (*models.ConversationWithDetails, error) {
+	var c models.ConversationWithDetails
+	err := row.Scan(
+		&c.ID, &c.ListingID, &c.OfferID, &c.BuyerID, &c.SellerID, &c.ArchivedAt, &c.CreatedAt, &c.UpdatedAt,
+		&c.ListingTitle, &c.ListingStatus, &c.BuyerUsername, &c.SellerUsername, &c.LastMessage, &c.LastMessageAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanMessage(row rowScanner) (*models.ConversationMessage, error) {
+	var m models.ConversationMessage
+	err := row.Scan(&m.ID, &m.ConversationID, &m.FromUser, &m.FromUsername, &m.FromAvatar, &m.ToUser, &m.Content, &m.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ArchiveTrade archive, dans la transaction de fin d'un échange, la conversation
+// entre le vendeur de l'annonce et l'acheteur
+func ArchiveTrade(tx *sql.Tx, listingID, buyerID int) error {
+	_, err := tx.Exec(`
+		UPDATE conversations SET archived_at = NOW(), updated_at = NOW()
+		WHERE listing_id = $1 AND buyer_id = $2 AND archived_at IS NULL
+	`, listingID, buyerID)
+	if err != nil {
+		return fmt.Errorf("failed to archive conversation: %w", err)
+	}
+	return nil
+}
+
+// OpenListingConversation ouvre, ou retrouve, la conversation de l'utilisateur
+// avec le propriétaire d'une annonce ouverte
+func (s *Service) OpenListingConversation(listingID, userID int) (*models.ConversationWithDetails, error) {
+	var ownerID int
+	var status string
+	err := s.db.QueryRow("SELECT user_id, status FROM listings WHERE id = $1", listingID).Scan(&ownerID, &status)
+	if err == sql.ErrNoRows {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if ownerID == userID {
+		return nil, ErrOwnListing
+	}
+
+	var id int
+	err = s.db.QueryRow(
+		"SELECT id FROM conversations WHERE listing_id = $1 AND buyer_id = $2", listingID, userID,
+	).Scan(&id)
+	if err == nil {
+		return s.GetConversation(id, userID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	// Une nouvelle conversation ne s'ouvre que sur une annonce encore en vente
+	if status != "open" {
+		return nil, ErrListingNotOpen
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO conversations (listing_id, buyer_id, seller_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (listing_id, buyer_id) DO UPDATE SET listing_id = EXCLUDED.listing_id
+		RETURNING id
+	`, listingID, userID, ownerID).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return s.GetConversation(id, userID)
+}
+
+// OpenOfferConversation ouvre, ou retrouve, la conversation entre les deux
+// parties d'une offre et la rattache à l'offre
+func (s *Service) OpenOfferConversation(offerID, userID int) (*models.ConversationWithDetails, error) {
+	var listingID, buyerID, sellerID int
+	err := s.db.QueryRow(`
+		SELECT o.listing_id, o.from_user_id, l.user_id
+		FROM offers o JOIN listings l ON l.id = o.listing_id
+		WHERE o.id = $1
+	`, offerID).Scan(&listingID, &buyerID, &sellerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+	if userID != buyerID && userID != sellerID {
+		return nil, ErrOfferNotFound
+	}
+
+	var id int
+	err = s.db.QueryRow(`
+		INSERT INTO conversations (listing_id, offer_id, buyer_id, seller_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (listing_id, buyer_id) DO UPDATE SET offer_id = EXCLUDED.offer_id, updated_at = NOW()
+		RETURNING id
+	`, listingID, offerID, buyerID, sellerID).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return s.GetConversation(id, userID)
+}
+
+// GetConversation retourne une conversation dont l'utilisateur est un participant
+func (s *Service) GetConversation(conversationID, userID int) (*models.ConversationWithDetails, error) {
+	c, err := scanConversation(s.db.QueryRow(
+		selectConversation+" WHERE c.id = $2 AND "+visibleConversation, userID, conversationID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return c, nil
+}
+
+// ListConversations retourne les conversations de l'utilisateur, actives ou
+// archivées, les plus récemment actives d'abord
+func (s *Service) ListConversations(userID int, archived bool, page, limit int) ([]models.ConversationWithDetails, int, error) {
+	where := " WHERE " + visibleConversation + " AND c.archived_at IS NULL"
+	if archived {
+		where = " WHERE " + visibleConversation + " AND c.archived_at IS NOT NULL"
+	}
+	return s.queryConversations(where, []interface{}{userID}, page, limit)
+}
+
+// ListListingConversations retourne les conversations d'une annonce : toutes
+// pour son propriétaire, celle de l'utilisateur pour les autres
+func (s *Service) ListListingConversations(listingID, userID int) ([]models.ConversationWithDetails, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM listings WHERE id = $1)", listingID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if !exists {
+		return nil, ErrListingNotFound
+	}
+
+	conversations, _, err := s.queryConversations(
+		" WHERE c.listing_id = $2 AND "+visibleConversation, []interface{}{userID, listingID}, 1, 0,
+	)
+	return conversations, err
+}
+
+// queryConversations exécute une recherche de conversations ; limit = 0 retourne tous les résultats
+func (s *Service) queryConversations(where string, args []interface{}, page, limit int) ([]models.ConversationWithDetails, int, error) {
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM conversations c"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	query := selectConversation + where + " ORDER BY COALESCE(c.updated_at, c.created_at) DESC, c.id DESC"
+	if limit > 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, limit, (page-1)*limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []models.ConversationWithDetails{}
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, *c)
+	}
+	return conversations, total, rows.Err()
+}
+
+// ListMessages retourne les messages d'une conversation, les plus récents d'abord
+func (s *Service) ListMessages(conversationID, userID, page, limit int) ([]models.ConversationMessage, int, error) {
+	if _, err := s.GetConversation(conversationID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM messages WHERE conversation_id = $1", conversationID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	rows, err := s.db.Query(
+		selectMessage+" WHERE m.conversation_id = $1 ORDER BY m.id DESC LIMIT $2 OFFSET $3",
+		conversationID, limit, (page-1)*limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []models.ConversationMessage{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, *m)
+	}
+	return messages, total, rows.Err()
+}
+
+// SendMessage enregistre un message dans une conversation active et le diffuse
+// aux deux participants sur le websocket du chat
+func (s *Service) SendMessage(conversationID, userID int, content string) (*models.ConversationMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, ErrEmptyMessage
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var buyerID, sellerID int
+	var archived bool
+	err = tx.QueryRow(
+		"SELECT buyer_id, seller_id, archived_at IS NOT NULL FROM conversations WHERE id = $1 FOR UPDATE", conversationID,
+	).Scan(&buyerID, &sellerID, &archived)
+	if err == sql.ErrNoRows || (err == nil && userID != buyerID && userID != sellerID) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock conversation: %w", err)
+	}
+	if archived {
+		return nil, ErrArchived
+	}
+
+	recipient := sellerID
+	if userID == sellerID {
+		recipient = buyerID
+	}
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO messages (from_user, to_user, content, conversation_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		userID, recipient, content, conversationID,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record message: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE conversations SET updated_at = NOW() WHERE id = $1", conversationID); err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	message, err := scanMessage(tx.QueryRow(selectMessage+" WHERE m.id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message: %w", err)
+	}
+
+	s.deliver(message, buyerID, sellerID)
+	return message, nil
+}
+
+// deliver diffuse un message aux connexions websocket des participants ;
+// l'expéditeur le reçoit aussi sur ses autres appareils
+func (s *Service) deliver(message *models.ConversationMessage, userIDs ...int) {
+	if s.hub == nil {
+		return
+	}
+	payload, err := json.Marshal(event{Type: eventMessage, Message: message})
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to encode conversation message: %v", err))
+		return
+	}
+	for _, userID := range userIDs {
+		s.hub.SendToUser(userID, payload)
+	}
+}
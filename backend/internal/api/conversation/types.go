@@ -0,0 +1,22 @@
+package conversation
+
+import "github.com/okinrev/veza-web-app/internal/models"
+
+// eventMessage est le type des messages de conversation diffusés sur le websocket du chat
+const eventMessage = "conversation_message"
+
+// Hub diffuse un message aux connexions websocket d'un utilisateur
+type Hub interface {
+	SendToUser(userID int, message []byte)
+}
+
+// event est le message envoyé sur le websocket du chat aux participants
+type event struct {
+	Type    string                      `json:"type"`
+	Message *models.ConversationMessage `json:"message"`
+}
+
+// SendMessageRequest represents a message posted in a listing conversation
+type SendMessageRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
@@ -2,12 +2,19 @@
 package listing
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
-	"github.com/okinrev/veza-web-app/internal/utils/response"  // ADD THIS
-    "github.com/okinrev/veza-web-app/internal/common"
 
 	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
 )
 
 type Handler struct {
@@ -18,7 +25,68 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// CreateListing crée un nouveau listing
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrListingNotFound), errors.Is(err, ErrProductNotOwned), errors.Is(err, ErrImageNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotOwner):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrListingNotOpen), errors.Is(err, ErrTooManyImages), errors.Is(err, ErrProductInTrade),
+		errors.Is(err, ErrListingInTrade), errors.Is(err, ErrListingTraded), errors.Is(err, ErrNotRenewable):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrBumpTooSoon):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, media.ErrInvalidImage):
+		response.ErrorJSON(c.Writer, "Image must be a JPEG, PNG or WebP file", http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrInvalidPrice),
+		errors.Is(err, ErrNoTerms), errors.Is(err, ErrNothingToUpdate), errors.Is(err, ErrEmptyDescription),
+		errors.Is(err, media.ErrImageTooLarge), errors.Is(err, ErrInvalidSize):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// pagination lit et normalise les paramètres page et limit
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+func paginationMeta(page, limit, total int) *response.Meta {
+	return &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+// priceParam lit un prix optionnel de la requête
+func priceParam(c *gin.Context, name string) (*int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	price, err := strconv.Atoi(raw)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &price, nil
+}
+
+// CreateListing met en vente un produit de l'utilisateur
 func (h *Handler) CreateListing(c *gin.Context) {
 	userID, exists := common.GetUserIDFromContext(c)
 	if !exists {
@@ -26,83 +94,176 @@ func (h *Handler) CreateListing(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		ProductID   int    `json:"product_id" binding:"required"`
-		Description string `json:"description" binding:"required"`
-		State       string `json:"state" binding:"required"`
-		Price       *int   `json:"price"`
-		ExchangeFor string `json:"exchange_for"`
-	}
-
+	var req CreateListingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Sauvegarder en BDD
-	listing := map[string]interface{}{
-		"id":          1,
-		"user_id":     userID,
-		"product_id":  req.ProductID,
-		"description": req.Description,
-		"state":       req.State,
-		"price":       req.Price,
-		"exchange_for": req.ExchangeFor,
-		"status":      "open",
-		"created_at":  "2025-01-01T00:00:00Z",
+	listing, err := h.service.CreateListing(userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to create listing")
+		return
 	}
 
 	response.SuccessJSON(c.Writer, listing, "Listing created successfully")
 }
 
-// GetAllListings récupère tous les listings
+// GetAllListings liste les annonces, filtrées par état, statut, prix, catégorie,
+// marque ou vendeur
 func (h *Handler) GetAllListings(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, limit := pagination(c)
 
-	listings := []map[string]interface{}{
-		{
-			"id":          1,
-			"description": "Sample listing",
-			"state":       "excellent",
-			"status":      "open",
-		},
+	minPrice, err := priceParam(c, "min_price")
+	if err != nil {
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxPrice, err := priceParam(c, "max_price")
+	if err != nil {
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+		return
 	}
+	categoryID, _ := strconv.Atoi(c.Query("category_id"))
+	ownerID, _ := strconv.Atoi(c.Query("user_id"))
 
-	meta := &response.Meta{
-		Page:       page,
-		PerPage:    limit,
-		Total:      len(listings),
-		TotalPages: 1,
+	filters := ListFilters{
+		State:      c.Query("state"),
+		Status:     c.Query("status"),
+		MinPrice:   minPrice,
+		MaxPrice:   maxPrice,
+		CategoryID: categoryID,
+		Brand:      c.Query("brand"),
+		UserID:     ownerID,
+	}
+
+	listings, total, err := h.service.ListListings(filters, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve listings")
+		return
 	}
 
-	response.PaginatedJSON(c.Writer, listings, meta, "Listings retrieved successfully")
+	response.PaginatedJSON(c.Writer, listings, paginationMeta(page, limit, total), "Listings retrieved successfully")
 }
 
-// GetListingByID récupère un listing spécifique
+// GetListingByID récupère une annonce
 func (h *Handler) GetListingByID(c *gin.Context) {
-	idStr := c.Param("id")
-	listingID, err := strconv.Atoi(idStr)
+	listingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Récupérer depuis BDD
-	listing := map[string]interface{}{
-		"id":          listingID,
-		"description": "Sample listing",
-		"state":       "excellent",
-		"status":      "open",
+	listing, err := h.service.GetListing(listingID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve listing")
+		return
 	}
 
 	response.SuccessJSON(c.Writer, listing, "Listing retrieved successfully")
 }
 
-// DeleteListing supprime un listing
+// UpdateListing modifie une annonce ouverte de l'utilisateur
+func (h *Handler) UpdateListing(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	listing, err := h.service.UpdateListing(listingID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to update listing")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, listing, "Listing updated successfully")
+}
+
+// CloseListing retire une annonce de la vente
+func (h *Handler) CloseListing(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.CloseListing(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to close listing")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, listing, "Listing closed successfully")
+}
+
+// RenewListing prolonge une annonce ou remet en vente une annonce expirée
+func (h *Handler) RenewListing(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.RenewListing(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to renew listing")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, listing, "Listing renewed successfully")
+}
+
+// BumpListing remonte une annonce en tête des annonces les plus récentes
+func (h *Handler) BumpListing(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.BumpListing(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to bump listing")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, listing, "Listing bumped successfully")
+}
+
+// DeleteListing supprime une annonce de l'utilisateur
 func (h *Handler) DeleteListing(c *gin.Context) {
-	idStr := c.Param("id")
-	listingID, err := strconv.Atoi(idStr)
+	listingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
 		return
@@ -114,9 +275,125 @@ func (h *Handler) DeleteListing(c *gin.Context) {
 		return
 	}
 
-	// TODO: Vérifier propriétaire + suppression
-	_ = listingID
-	_ = userID
+	if err := h.service.DeleteListing(listingID, userID); err != nil {
+		writeError(c, err, "Failed to delete listing")
+		return
+	}
 
 	response.SuccessJSON(c.Writer, nil, "Listing deleted successfully")
-}
\ No newline at end of file
+}
+
+// listingParams lit l'identifiant de l'annonce et, si demandé, celui de la photo
+func listingParams(c *gin.Context, withImage bool) (int, int, bool) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	if !withImage {
+		return listingID, 0, true
+	}
+	imageID, err := strconv.Atoi(c.Param("image_id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid image ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return listingID, imageID, true
+}
+
+// UploadImage ajoute une photo à une annonce de l'utilisateur
+func (h *Handler) UploadImage(c *gin.Context) {
+	listingID, _, ok := listingParams(c, false)
+	if !ok {
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("image")
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Image file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > imageMaxSize {
+		response.ErrorJSON(c.Writer, "Image exceeds 10MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// L'image est nettoyée en mémoire avant tout enregistrement
+	data, err := io.ReadAll(io.LimitReader(file, imageMaxSize))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to read image", http.StatusInternalServerError)
+		return
+	}
+
+	image, err := h.service.AddImage(listingID, userID, data)
+	if err != nil {
+		writeError(c, err, "Failed to add listing image")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, image, "Image added successfully")
+}
+
+// ListImages liste les photos d'une annonce
+func (h *Handler) ListImages(c *gin.Context) {
+	listingID, _, ok := listingParams(c, false)
+	if !ok {
+		return
+	}
+
+	images, err := h.service.ListImages(listingID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve listing images")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, images, "Images retrieved successfully")
+}
+
+// GetImage envoie une photo, en taille originale ou en miniature (size=thumb, small, medium)
+func (h *Handler) GetImage(c *gin.Context) {
+	listingID, imageID, ok := listingParams(c, true)
+	if !ok {
+		return
+	}
+
+	key, contentType, err := h.service.ImageKey(listingID, imageID, c.Query("size"))
+	if err != nil {
+		writeError(c, err, "Failed to retrieve listing image")
+		return
+	}
+
+	if err := h.service.store.Serve(c.Writer, c.Request, key, storage.ServeOptions{ContentType: contentType, Inline: true}); err != nil {
+		utils.LogError(fmt.Sprintf("Failed to serve listing image: %v", err))
+		response.ErrorJSON(c.Writer, "Image file not found", http.StatusNotFound)
+	}
+}
+
+// DeleteImage retire une photo d'une annonce de l'utilisateur
+func (h *Handler) DeleteImage(c *gin.Context) {
+	listingID, imageID, ok := listingParams(c, true)
+	if !ok {
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteImage(listingID, imageID, userID); err != nil {
+		writeError(c, err, "Failed to delete listing image")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Image deleted successfully")
+}
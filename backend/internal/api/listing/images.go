@@ -0,0 +1,319 @@
+package listing
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+	ErrTooManyImages = fmt.Errorf("a listing cannot have more than %d images", MaxImages)
+	ErrInvalidSize   = errors.New("unknown image size")
+)
+
+// imageKey retourne la clé de stockage d'une photo ou d'une miniature
+func imageKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceListings, filename)
+}
+
+// removeImageFiles efface les fichiers d'une photo et de ses miniatures
+func (s *Service) removeImageFiles(filenames ...string) {
+	for _, filename := range filenames {
+		if key, err := imageKey(filename); err == nil {
+			s.store.Remove(key)
+		}
+	}
+}
+
+// withURLs complète une photo avec les URLs de l'original et de ses miniatures ;
+// sans miniature, chaque taille désigne l'original
+func withURLs(img *models.ListingImage) {
+	img.URL = fmt.Sprintf(imageURLFmt, img.ListingID, img.ID)
+	img.Sizes = make(map[string]string, len(imageSizes))
+	for i, size := range imageSizes {
+		if i < len(img.Thumbnails) {
+			img.Sizes[size.name] = img.URL + "?size=" + size.name
+		} else {
+			img.Sizes[size.name] = img.URL
+		}
+	}
+}
+
+const selectImage = `
+	SELECT id, listing_id, user_id, filename, thumbnails, content_type, width, height, size, position, created_at
+	FROM listing_images
+`
+
+func scanImage(row rowScanner) (*models.ListingImage, error) {
+	var img models.ListingImage
+	err := row.Scan(&img.ID, &img.ListingID, &img.UserID, &img.Filename, &img.Thumbnails, &img.ContentType,
+		&img.Width, &img.Height, &img.Size, &img.Position, &img.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	withURLs(&img)
+	return &img, nil
+}
+
+// ListImages retourne les photos d'une annonce dans leur ordre d'affichage
+func (s *Service) ListImages(listingID int) ([]models.ListingImage, error) {
+	if _, err := s.GetListing(listingID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(selectImage+" WHERE listing_id = $1 ORDER BY position, id", listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve listing images: %w", err)
+	}
+	defer rows.Close()
+
+	images := []models.ListingImage{}
+	for rows.Next() {
+		img, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan listing image: %w", err)
+		}
+		images = append(images, *img)
+	}
+	return images, rows.Err()
+}
+
+// AddImage valide une photo, retire ses métadonnées, génère ses miniatures et
+// l'ajoute à une annonce ouverte de l'utilisateur
+func (s *Service) AddImage(listingID, userID int, data []byte) (*models.ListingImage, error) {
+	// Contrôles préalables, refaits dans la transaction d'enregistrement
+	listing, err := s.GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.UserID != userID {
+		return nil, ErrNotOwner
+	}
+	if listing.Status != StatusOpen {
+		return nil, ErrListingNotOpen
+	}
+	if len(listing.Images) >= MaxImages {
+		return nil, ErrTooManyImages
+	}
+	if err := s.quotas.Check(userID, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	clean, err := media.CleanImageData(data)
+	if err != nil {
+		return nil, err
+	}
+	format := imageFormats[clean.Format]
+
+	// L'original et ses miniatures partagent le même préfixe
+	base := fmt.Sprintf("%d_%d", listingID, time.Now().UnixNano())
+	stored := []string{}
+	var size int64
+	put := func(filename string, content []byte) error {
+		key, err := imageKey(filename)
+		if err != nil {
+			return err
+		}
+		n, err := s.store.Put(key, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		stored = append(stored, filename)
+		size += n
+		return nil
+	}
+
+	filename := base + format.ext
+	if err := put(filename, clean.Data); err != nil {
+		return nil, err
+	}
+	// Les miniatures d'une photo WebP sont en JPEG ou en PNG
+	thumbnailFormat := media.ThumbnailFormat(clean)
+	thumbnails := []string{}
+	for _, imgSize := range imageSizes {
+		var buf bytes.Buffer
+		if err := media.EncodeImage(&buf, media.Thumbnail(clean.Image, imgSize.maxSide), thumbnailFormat); err != nil {
+			s.removeImageFiles(stored...)
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		thumbnail := base + "_" + imgSize.name + imageFormats[thumbnailFormat].ext
+		if err := put(thumbnail, buf.Bytes()); err != nil {
+			s.removeImageFiles(stored...)
+			return nil, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	id, err := s.recordImage(listingID, userID, filename, thumbnails, format.contentType, clean, size)
+	if err != nil {
+		s.removeImageFiles(stored...)
+		return nil, err
+	}
+
+	img, err := scanImage(s.db.QueryRow(selectImage+" WHERE id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing image: %w", err)
+	}
+	return img, nil
+}
+
+// recordImage enregistre une photo après avoir vérifié, annonce verrouillée,
+// le propriétaire, le nombre de photos et le quota de stockage
+func (s *Service) recordImage(listingID, userID int, filename string, thumbnails []string, contentType string, clean *media.CleanImage, size int64) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if status != StatusOpen {
+		return 0, ErrListingNotOpen
+	}
+
+	var count, position int
+	err = tx.QueryRow(
+		"SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM listing_images WHERE listing_id = $1", listingID,
+	).Scan(&count, &position)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count listing images: %w", err)
+	}
+	if count >= MaxImages {
+		return 0, ErrTooManyImages
+	}
+	if err := s.quotas.Reserve(tx, userID, size); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO listing_images (listing_id, user_id, filename, thumbnails, content_type, width, height, size, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, listingID, userID, filename, pq.Array(thumbnails), contentType, clean.Width, clean.Height, size, position).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record listing image: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE listings SET updated_at = NOW() WHERE id = $1", listingID); err != nil {
+		return 0, fmt.Errorf("failed to update listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit listing image: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteImage supprime une photo d'une annonce de l'utilisateur et ses fichiers
+func (s *Service) DeleteImage(listingID, imageID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOwned(tx, listingID, userID); err != nil {
+		return err
+	}
+
+	var filename string
+	var thumbnails pq.StringArray
+	err = tx.QueryRow(
+		"DELETE FROM listing_images WHERE id = $1 AND listing_id = $2 RETURNING filename, thumbnails",
+		imageID, listingID,
+	).Scan(&filename, &thumbnails)
+	if err == sql.ErrNoRows {
+		return ErrImageNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete listing image: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE listings SET updated_at = NOW() WHERE id = $1", listingID); err != nil {
+		return fmt.Errorf("failed to update listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit listing image deletion: %w", err)
+	}
+	s.removeImageFiles(append([]string{filename}, thumbnails...)...)
+	return nil
+}
+
+// ImageKey retourne la clé de stockage d'une photo dans la taille demandée
+// (vide : original) et son type MIME
+func (s *Service) ImageKey(listingID, imageID int, size string) (string, string, error) {
+	img, err := scanImage(s.db.QueryRow(selectImage+" WHERE id = $1 AND listing_id = $2", imageID, listingID))
+	if err == sql.ErrNoRows {
+		return "", "", ErrImageNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get listing image: %w", err)
+	}
+
+	filename, contentType := img.Filename, img.ContentType
+	if size != "" {
+		found := false
+		for i, imgSize := range imageSizes {
+			if imgSize.name == size {
+				found = true
+				if i < len(img.Thumbnails) {
+					filename = img.Thumbnails[i]
+					contentType = thumbnailContentType(filename, img.ContentType)
+				}
+			}
+		}
+		if !found {
+			return "", "", ErrInvalidSize
+		}
+	}
+
+	key, err := imageKey(filename)
+	if err != nil {
+		return "", "", err
+	}
+	return key, contentType, nil
+}
+
+// thumbnailContentType retourne le type MIME d'une miniature d'après son
+// extension, qui diffère de celle de l'original pour une photo WebP
+func thumbnailContentType(filename, original string) string {
+	for _, format := range imageFormats {
+		if strings.HasSuffix(filename, format.ext) {
+			return format.contentType
+		}
+	}
+	return original
+}
+
+// imageFilenames retourne les fichiers des photos d'une annonce
+func imageFilenames(tx *sql.Tx, listingID int) ([]string, error) {
+	rows, err := tx.Query("SELECT filename, thumbnails FROM listing_images WHERE listing_id = $1", listingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list listing images: %w", err)
+	}
+	defer rows.Close()
+
+	filenames := []string{}
+	for rows.Next() {
+		var filename string
+		var thumbnails pq.StringArray
+		if err := rows.Scan(&filename, &thumbnails); err != nil {
+			return nil, fmt.Errorf("failed to scan listing image: %w", err)
+		}
+		filenames = append(append(filenames, filename), thumbnails...)
+	}
+	return filenames, rows.Err()
+}
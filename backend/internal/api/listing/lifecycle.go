@@ -0,0 +1,238 @@
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/api/notification"
+	"github.com/okinrev/veza-web-app/internal/api/offer"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrNotRenewable = errors.New("only open or expired listings can be renewed")
+	ErrBumpTooSoon  = errors.New("listing was bumped too recently")
+)
+
+// RenewListing prolonge une annonce ouverte ou remet en vente une annonce
+// expirée pour une nouvelle durée de vie
+func (s *Service) RenewListing(listingID, userID int) (*models.ListingWithDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case StatusOpen:
+	case StatusExpired:
+		// Le produit a pu changer de propriétaire ou être engagé ailleurs depuis l'expiration
+		var productID int
+		var owned bool
+		err := tx.QueryRow(`
+			SELECT l.product_id, EXISTS (SELECT 1 FROM products p WHERE p.id = l.product_id AND p.user_id = l.user_id)
+			FROM listings l WHERE l.id = $1
+		`, listingID).Scan(&productID, &owned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check listing product: %w", err)
+		}
+		if !owned {
+			return nil, ErrProductNotOwned
+		}
+		inTrade, err := offer.ProductInTrade(tx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if inTrade {
+			return nil, ErrProductInTrade
+		}
+	default:
+		return nil, ErrNotRenewable
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE listings
+		SET status = $1, expires_at = NOW() + make_interval(secs => $2), reminded_at = NULL, updated_at = NOW()
+		WHERE id = $3
+	`, StatusOpen, s.cfg.ListingTTL.Seconds(), listingID); err != nil {
+		return nil, fmt.Errorf("failed to renew listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing renewal: %w", err)
+	}
+	return s.GetListing(listingID)
+}
+
+// BumpListing remonte une annonce ouverte en tête du tri par date, au plus une
+// fois par intervalle de remontée
+func (s *Service) BumpListing(listingID, userID int) (*models.ListingWithDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusOpen {
+		return nil, ErrListingNotOpen
+	}
+
+	// Une annonce qui n'a jamais été remontée compte depuis sa publication
+	var last time.Time
+	err = tx.QueryRow("SELECT COALESCE(bumped_at, created_at) FROM listings WHERE id = $1", listingID).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if next := last.Add(s.cfg.BumpInterval); time.Now().Before(next) {
+		return nil, fmt.Errorf("%w, next bump possible at %s", ErrBumpTooSoon, next.Format(time.RFC3339))
+	}
+
+	if _, err := tx.Exec("UPDATE listings SET bumped_at = NOW() WHERE id = $1", listingID); err != nil {
+		return nil, fmt.Errorf("failed to bump listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing bump: %w", err)
+	}
+	return s.GetListing(listingID)
+}
+
+// SendReminders prévient les propriétaires des annonces ouvertes proches de
+// leur fin de vie
+func (s *Service) SendReminders() (int, error) {
+	if s.cfg.ListingReminder <= 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE listings l SET reminded_at = NOW()
+		FROM products p
+		WHERE p.id = l.product_id
+		  AND l.status = $1 AND l.reminded_at IS NULL
+		  AND l.expires_at > NOW() AND l.expires_at <= NOW() + make_interval(secs => $2)
+		RETURNING l.id, l.user_id, p.name
+	`, StatusOpen, s.cfg.ListingReminder.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to select listings to remind: %w", err)
+	}
+
+	type reminder struct {
+		id, userID  int
+		productName string
+	}
+	reminders := []reminder{}
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.id, &r.userID, &r.productName); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan listing to remind: %w", err)
+		}
+		reminders = append(reminders, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to select listings to remind: %w", err)
+	}
+
+	for _, r := range reminders {
+		message := fmt.Sprintf("Votre annonce « %s » expire bientôt, renouvelez-la pour la garder en ligne", r.productName)
+		if err := notification.Notify(tx, r.userID, notification.TypeListingExpiring, notification.ObjectListing, r.id, message); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit listing reminders: %w", err)
+	}
+	return len(reminders), nil
+}
+
+// ExpireDue passe à expired les annonces ouvertes arrivées en fin de vie et
+// prévient leurs propriétaires. Les offres en cours ne peuvent plus être
+// acceptées tant que l'annonce n'est pas renouvelée.
+func (s *Service) ExpireDue() (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE listings l SET status = $1, updated_at = NOW()
+		FROM products p
+		WHERE p.id = l.product_id AND l.status = $2 AND l.expires_at <= NOW()
+		RETURNING l.id, l.user_id, p.name
+	`, StatusExpired, StatusOpen)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire listings: %w", err)
+	}
+
+	type expiredListing struct {
+		id, userID  int
+		productName string
+	}
+	expired := []expiredListing{}
+	for rows.Next() {
+		var e expiredListing
+		if err := rows.Scan(&e.id, &e.userID, &e.productName); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired listing: %w", err)
+		}
+		expired = append(expired, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to expire listings: %w", err)
+	}
+
+	for _, e := range expired {
+		message := fmt.Sprintf("Votre annonce « %s » a expiré, renouvelez-la pour la remettre en ligne", e.productName)
+		if err := notification.Notify(tx, e.userID, notification.TypeListingExpired, notification.ObjectListing, e.id, message); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit listing expiration: %w", err)
+	}
+	return len(expired), nil
+}
+
+// RunExpiry expire périodiquement les annonces échues et envoie les rappels
+func (s *Service) RunExpiry(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if n, err := s.SendReminders(); err != nil {
+			utils.LogError(fmt.Sprintf("Listing expiry: %v", err))
+		} else if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Listing expiry: %d rappel(s) envoyé(s)", n))
+		}
+
+		n, err := s.ExpireDue()
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Listing expiry: %v", err))
+			continue
+		}
+		if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Listing expiry: %d annonce(s) expirée(s)", n))
+		}
+	}
+}
@@ -11,12 +11,20 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
 	// Routes publiques
 	listings.GET("", handler.GetAllListings)
 	listings.GET("/:id", handler.GetListingByID)
+	listings.GET("/:id/images", handler.ListImages)
+	listings.GET("/:id/images/:image_id", handler.GetImage)
 	
 	// Routes protégées
 	protected := listings.Group("")
 	protected.Use(middleware.JWTAuthMiddleware(jwtSecret))
 	{
 		protected.POST("", handler.CreateListing)
+		protected.PUT("/:id", handler.UpdateListing)
+		protected.POST("/:id/close", handler.CloseListing)
+		protected.POST("/:id/renew", handler.RenewListing)
+		protected.POST("/:id/bump", handler.BumpListing)
 		protected.DELETE("/:id", handler.DeleteListing)
+		protected.POST("/:id/images", handler.UploadImage)
+		protected.DELETE("/:id/images/:image_id", handler.DeleteImage)
 	}
 }
@@ -1,15 +1,392 @@
 package listing
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/api/offer"
+	"github.com/okinrev/veza-web-app/internal/api/savedsearch"
+	"github.com/okinrev/veza-web-app/internal/blob"
+	"github.com/okinrev/veza-web-app/internal/config"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+)
+
+var (
+	ErrListingNotFound  = errors.New("listing not found")
+	ErrNotOwner         = errors.New("you are not the owner of this listing")
+	ErrProductNotOwned  = errors.New("product not found in your products")
+	ErrInvalidState     = errors.New("state must be one of new, like_new, good, fair, poor")
+	ErrInvalidStatus    = errors.New("status must be one of open, reserved, closed, sold, expired")
+	ErrInvalidPrice     = errors.New("min_price cannot be greater than max_price")
+	ErrNoTerms          = errors.New("a listing needs a price or something to exchange for")
+	ErrListingNotOpen   = errors.New("listing is no longer open")
+	ErrNothingToUpdate  = errors.New("no fields to update")
+	ErrEmptyDescription = errors.New("description cannot be empty")
+	ErrProductInTrade   = errors.New("product is engaged in a trade in progress")
+	ErrListingInTrade   = errors.New("listing is reserved by a trade in progress")
+	ErrListingTraded    = errors.New("listing has an accepted or completed trade, close it instead")
 )
 
+// selectListing lit une annonce avec son vendeur et sa réputation, le produit
+// proposé, les URLs de ses photos et le nombre d'offres reçues
+const selectListing = `
+	SELECT l.id, l.user_id, l.product_id, l.description, l.state, l.price, l.exchange_for,
+	       ARRAY(SELECT '/api/v1/listings/' || l.id || '/images/' || i.id
+	             FROM listing_images i WHERE i.listing_id = l.id ORDER BY i.position, i.id),
+	       l.status, l.expires_at, l.bumped_at, l.created_at, COALESCE(l.updated_at, l.created_at),
+	       u.username, u.avatar, p.name, NULLIF(p.brand, ''), NULLIF(p.model, ''),
+	       (SELECT COUNT(*) FROM offers o WHERE o.listing_id = l.id),
+	       u.rating_average, u.rating_count, u.completed_trades
+	FROM listings l
+	JOIN users u ON u.id = l.user_id
+	JOIN products p ON p.id = l.product_id
+`
+
 type Service struct {
-	db *database.DB
+	db     *database.DB
+	cfg    config.MarketplaceConfig
+	quotas *quota.Manager
+	store  *blob.Store
+}
+
+func NewService(db *database.DB, cfg config.MarketplaceConfig, quotas *quota.Manager, store *blob.Store) *Service {
+	return &Service{
+		db:     db,
+		cfg:    cfg,
+		quotas: quotas,
+		store:  store,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanListing(row rowScanner) (*models.ListingWithDetails, error) {
+	var l models.ListingWithDetails
+	err := row.Scan(
+		&l.ID, &l.UserID, &l.ProductID, &l.Description, &l.State, &l.Price, &l.ExchangeFor,
+		&l.Images, &l.Status, &l.ExpiresAt, &l.BumpedAt, &l.CreatedAt, &l.UpdatedAt,
+		&l.Username, &l.UserAvatar, &l.ProductName, &l.Brand, &l.Model,
+		&l.OfferCount,
+		&l.SellerReputation.RatingAverage, &l.SellerReputation.RatingCount, &l.SellerReputation.CompletedTrades,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// CreateListing met en vente un produit appartenant à l'utilisateur. Le
+// matériel des utilisateurs est enregistré dans la table products, dont
+// user_id désigne le propriétaire.
+func (s *Service) CreateListing(userID int, req CreateListingRequest) (*models.ListingWithDetails, error) {
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, ErrEmptyDescription
+	}
+	if !validStates[req.State] {
+		return nil, ErrInvalidState
+	}
+	exchangeFor := strings.TrimSpace(req.ExchangeFor)
+	if req.Price == nil && exchangeFor == "" {
+		return nil, ErrNoTerms
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var owned bool
+	err = tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND user_id = $2)",
+		req.ProductID, userID,
+	).Scan(&owned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product ownership: %w", err)
+	}
+	if !owned {
+		return nil, ErrProductNotOwned
+	}
+	inTrade, err := offer.ProductInTrade(tx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if inTrade {
+		return nil, ErrProductInTrade
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO listings (user_id, product_id, description, state, price, exchange_for, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NOW() + make_interval(secs => $8))
+		RETURNING id
+	`, userID, req.ProductID, description, req.State, req.Price, exchangeFor, StatusOpen, s.cfg.ListingTTL.Seconds()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing: %w", err)
+	}
+
+	// Alertes des recherches enregistrées auxquelles correspond la nouvelle annonce
+	if err := savedsearch.MatchListing(tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing: %w", err)
+	}
+	return s.GetListing(id)
+}
+
+// GetListing retourne une annonce avec ses détails
+func (s *Service) GetListing(listingID int) (*models.ListingWithDetails, error) {
+	l, err := scanListing(s.db.QueryRow(selectListing+" WHERE l.id = $1", listingID))
+	if err == sql.ErrNoRows {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	return l, nil
+}
+
+// ListListings retourne les annonces correspondant aux filtres, les plus récentes
+// ou les dernières remontées d'abord. Les annonces expirées n'apparaissent que
+// si elles sont demandées par le filtre de statut.
+func (s *Service) ListListings(filters ListFilters, page, limit int) ([]models.ListingWithDetails, int, error) {
+	if filters.State != "" && !validStates[filters.State] {
+		return nil, 0, ErrInvalidState
+	}
+	if filters.Status != "" && !validStatuses[filters.Status] {
+		return nil, 0, ErrInvalidStatus
+	}
+	if filters.MinPrice != nil && filters.MaxPrice != nil && *filters.MinPrice > *filters.MaxPrice {
+		return nil, 0, ErrInvalidPrice
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filters.State != "" {
+		addCondition("l.state = $%d", filters.State)
+	}
+	if filters.Status != "" {
+		addCondition("l.status = $%d", filters.Status)
+	} else {
+		addCondition("l.status <> $%d", StatusExpired)
+	}
+	if filters.MinPrice != nil {
+		addCondition("l.price >= $%d", *filters.MinPrice)
+	}
+	if filters.MaxPrice != nil {
+		addCondition("l.price <= $%d", *filters.MaxPrice)
+	}
+	if filters.CategoryID > 0 {
+		addCondition("p.category_id = $%d", filters.CategoryID)
+	}
+	if filters.Brand != "" {
+		addCondition("LOWER(p.brand) = LOWER($%d)", filters.Brand)
+	}
+	if filters.UserID > 0 {
+		addCondition("l.user_id = $%d", filters.UserID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM listings l JOIN products p ON p.id = l.product_id"+where, args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count listings: %w", err)
+	}
+
+	query := selectListing + where + " ORDER BY COALESCE(l.bumped_at, l.created_at) DESC, l.id DESC LIMIT $" +
+		strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	rows, err := s.db.Query(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve listings: %w", err)
+	}
+	defer rows.Close()
+
+	listings := []models.ListingWithDetails{}
+	for rows.Next() {
+		l, err := scanListing(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan listing: %w", err)
+		}
+		listings = append(listings, *l)
+	}
+	return listings, total, rows.Err()
+}
+
+// lockOwned verrouille une annonce dans la transaction, vérifie son propriétaire
+// et retourne son statut
+func lockOwned(tx *sql.Tx, listingID, userID int) (string, error) {
+	var ownerID int
+	var status string
+	err := tx.QueryRow(
+		"SELECT user_id, status FROM listings WHERE id = $1 FOR UPDATE", listingID,
+	).Scan(&ownerID, &status)
+	if err == sql.ErrNoRows {
+		return "", ErrListingNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get listing: %w", err)
+	}
+	if ownerID != userID {
+		return "", ErrNotOwner
+	}
+	return status, nil
+}
+
+// UpdateListing modifie une annonce encore ouverte de l'utilisateur
+func (s *Service) UpdateListing(listingID, userID int, req UpdateListingRequest) (*models.ListingWithDetails, error) {
+	if req.Description == nil && req.State == nil && req.Price == nil && req.ExchangeFor == nil {
+		return nil, ErrNothingToUpdate
+	}
+	if req.Description != nil && strings.TrimSpace(*req.Description) == "" {
+		return nil, ErrEmptyDescription
+	}
+	if req.State != nil && !validStates[*req.State] {
+		return nil, ErrInvalidState
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusOpen {
+		return nil, ErrListingNotOpen
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Description != nil {
+		setParts = append(setParts, "description = $"+strconv.Itoa(argCount))
+		args = append(args, strings.TrimSpace(*req.Description))
+		argCount++
+	}
+	if req.State != nil {
+		setParts = append(setParts, "state = $"+strconv.Itoa(argCount))
+		args = append(args, *req.State)
+		argCount++
+	}
+	if req.Price != nil {
+		setParts = append(setParts, "price = $"+strconv.Itoa(argCount))
+		args = append(args, *req.Price)
+		argCount++
+	}
+	if req.ExchangeFor != nil {
+		setParts = append(setParts, "exchange_for = NULLIF($"+strconv.Itoa(argCount)+", '')")
+		args = append(args, strings.TrimSpace(*req.ExchangeFor))
+		argCount++
+	}
+
+	setParts = append(setParts, "updated_at = NOW()")
+	args = append(args, listingID)
+
+	// Une annonce doit conserver un prix ou un échange souhaité
+	var hasTerms bool
+	query := "UPDATE listings SET " + strings.Join(setParts, ", ") + " WHERE id = $" + strconv.Itoa(argCount) +
+		" RETURNING price IS NOT NULL OR exchange_for IS NOT NULL"
+	if err := tx.QueryRow(query, args...).Scan(&hasTerms); err != nil {
+		return nil, fmt.Errorf("failed to update listing: %w", err)
+	}
+	if !hasTerms {
+		return nil, ErrNoTerms
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing update: %w", err)
+	}
+	return s.GetListing(listingID)
 }
 
-func NewService(db *database.DB) *Service {
-	return &Service{db: db}
+// CloseListing retire de la vente une annonce ouverte de l'utilisateur
+func (s *Service) CloseListing(listingID, userID int) (*models.ListingWithDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusOpen {
+		return nil, ErrListingNotOpen
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE listings SET status = $1, updated_at = NOW() WHERE id = $2", StatusClosed, listingID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to close listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing close: %w", err)
+	}
+	return s.GetListing(listingID)
 }
 
-// TODO: Implement methods based on doc_listing_handler.md
+// DeleteListing supprime une annonce de l'utilisateur sans échange accepté,
+// les offres reçues et ses photos
+func (s *Service) DeleteListing(listingID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockOwned(tx, listingID, userID)
+	if err != nil {
+		return err
+	}
+	if status == StatusReserved {
+		return ErrListingInTrade
+	}
+	// Une annonce échangée garde ses offres et les évaluations de l'échange
+	traded, err := offer.ListingTraded(tx, listingID)
+	if err != nil {
+		return err
+	}
+	if traded {
+		return ErrListingTraded
+	}
+	filenames, err := imageFilenames(tx, listingID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM listings WHERE id = $1", listingID); err != nil {
+		return fmt.Errorf("failed to delete listing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit listing deletion: %w", err)
+	}
+	s.removeImageFiles(filenames...)
+	return nil
+}
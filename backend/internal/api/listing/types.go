@@ -0,0 +1,86 @@
+package listing
+
+import "github.com/okinrev/veza-web-app/internal/utils/media"
+
+const (
+	// Statuts d'une annonce
+	StatusOpen     = "open"
+	StatusReserved = "reserved"
+	StatusClosed   = "closed"
+	StatusSold     = "sold"
+	StatusExpired  = "expired" // annonce ouverte arrivée en fin de vie, renouvelable
+)
+
+// validStates liste les états acceptés pour le matériel mis en vente
+var validStates = map[string]bool{
+	"new":      true,
+	"like_new": true,
+	"good":     true,
+	"fair":     true,
+	"poor":     true,
+}
+
+// validStatuses liste les statuts acceptés par le filtre des annonces
+var validStatuses = map[string]bool{
+	StatusOpen:     true,
+	StatusReserved: true,
+	StatusClosed:   true,
+	StatusSold:     true,
+	StatusExpired:  true,
+}
+
+// CreateListingRequest represents a request to put a user's product on the marketplace
+type CreateListingRequest struct {
+	ProductID   int    `json:"product_id" binding:"required"`
+	Description string `json:"description" binding:"required,max=5000"`
+	State       string `json:"state" binding:"required"`
+	Price       *int   `json:"price" binding:"omitempty,min=0"`
+	ExchangeFor string `json:"exchange_for" binding:"max=1000"`
+}
+
+// UpdateListingRequest represents a request to update an open listing
+type UpdateListingRequest struct {
+	Description *string `json:"description,omitempty" binding:"omitempty,max=5000"`
+	State       *string `json:"state,omitempty"`
+	Price       *int    `json:"price,omitempty" binding:"omitempty,min=0"`
+	ExchangeFor *string `json:"exchange_for,omitempty" binding:"omitempty,max=1000"`
+}
+
+// ListFilters represents the filters of the public listing search
+type ListFilters struct {
+	State      string
+	Status     string
+	MinPrice   *int
+	MaxPrice   *int
+	CategoryID int
+	Brand      string
+	UserID     int
+}
+
+const (
+	// MaxImages est le nombre maximal de photos par annonce
+	MaxImages = 10
+
+	imageMaxSize = 10 << 20 // 10MB
+	imageURLFmt  = "/api/v1/listings/%d/images/%d"
+)
+
+// imageSize est une taille de miniature, désignée par son nom dans les URLs
+type imageSize struct {
+	name    string
+	maxSide int
+}
+
+// imageSizes liste les miniatures générées, dans l'ordre de la colonne thumbnails
+var imageSizes = []imageSize{
+	{"thumb", 160},
+	{"small", 320},
+	{"medium", 800},
+}
+
+// imageFormats associe un format d'image accepté à son type MIME et son extension
+var imageFormats = map[string]struct{ contentType, ext string }{
+	media.FormatJPEG: {"image/jpeg", ".jpg"},
+	media.FormatPNG:  {"image/png", ".png"},
+	media.FormatWebP: {"image/webp", ".webp"},
+}
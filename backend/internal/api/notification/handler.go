@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotificationNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// ListNotifications liste les notifications de l'utilisateur (unread=true : non lues seulement)
+func (h *Handler) ListNotifications(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	notifications, total, err := h.service.ListNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve notifications")
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+	response.PaginatedJSON(c.Writer, notifications, meta, "Notifications retrieved successfully")
+}
+
+// UnreadCount retourne le nombre de notifications non lues
+func (h *Handler) UnreadCount(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.service.UnreadCount(userID)
+	if err != nil {
+		writeError(c, err, "Failed to count notifications")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, gin.H{"unread": count}, "Unread notifications counted successfully")
+}
+
+// MarkRead marque une notification comme lue
+func (h *Handler) MarkRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkRead(notificationID, userID); err != nil {
+		writeError(c, err, "Failed to mark notification as read")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Notification marked as read")
+}
+
+// MarkAllRead marque toutes les notifications comme lues
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	n, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		writeError(c, err, "Failed to mark notifications as read")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, gin.H{"updated": n}, "Notifications marked as read")
+}
@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	notifications := router.Group("/notifications")
+
+	// Routes protégées
+	notifications.Use(middleware.JWTAuthMiddleware(jwtSecret))
+	{
+		notifications.GET("", handler.ListNotifications)
+		notifications.GET("/unread-count", handler.UnreadCount)
+		notifications.POST("/read-all", handler.MarkAllRead)
+		notifications.POST("/:id/read", handler.MarkRead)
+	}
+}
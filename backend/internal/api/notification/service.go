@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// Notify enregistre une notification dans la transaction fournie
+func Notify(tx *sql.Tx, userID int, notificationType, objectType string, objectID int, message string) error {
+	_, err := tx.Exec(`
+		INSERT INTO notifications (user_id, type, object_type, object_id, message)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, notificationType, objectType, objectID, message)
+	if err != nil {
+		return fmt.Errorf("failed to record notification: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications retourne les notifications de l'utilisateur, les plus récentes d'abord
+func (s *Service) ListNotifications(userID int, unreadOnly bool, page, limit int) ([]models.Notification, int, error) {
+	where := " WHERE user_id = $1"
+	if unreadOnly {
+		where += " AND read_at IS NULL"
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM notifications"+where, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, object_type, object_id, message, read_at, created_at
+		FROM notifications`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ObjectType, &n.ObjectID, &n.Message, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, total, rows.Err()
+}
+
+// UnreadCount retourne le nombre de notifications non lues de l'utilisateur
+func (s *Service) UnreadCount(userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marque une notification de l'utilisateur comme lue
+func (s *Service) MarkRead(notificationID, userID int) error {
+	result, err := s.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marque toutes les notifications de l'utilisateur comme lues
+func (s *Service) MarkAllRead(userID int) (int64, error) {
+	result, err := s.db.Exec(
+		"UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL", userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,25 @@
+package notification
+
+// Types de notification
+const (
+	TypeOfferExpired    = "offer_expired"
+	TypeOfferExpiring   = "offer_expiring"
+	TypeOfferCountered  = "offer_countered"
+	TypeTradeShipped    = "trade_shipped"
+	TypeTradeConfirmed  = "trade_confirmed"
+	TypeTradeCompleted  = "trade_completed"
+	TypeTradeDisputed   = "trade_disputed"
+	TypeDisputeResolved = "dispute_resolved"
+	TypeTradeRated      = "trade_rated"
+	TypeListingMatch    = "listing_match" // nouvelle annonce pour une recherche enregistrée
+	TypeSearchDigest    = "search_digest" // récapitulatif quotidien d'une recherche enregistrée
+	TypeListingExpiring = "listing_expiring"
+	TypeListingExpired  = "listing_expired"
+)
+
+// Types d'objet visés par une notification
+const (
+	ObjectOffer       = "offer"
+	ObjectListing     = "listing"
+	ObjectSavedSearch = "saved_search"
+)
@@ -0,0 +1,388 @@
+package offer
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/api/notification"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+var (
+	ErrDisputeNotFound      = errors.New("dispute not found")
+	ErrDisputeClosed        = errors.New("dispute is already resolved")
+	ErrInvalidResolution    = errors.New("resolution must be complete or cancel")
+	ErrEmptyReason          = errors.New("reason cannot be empty")
+	ErrEvidenceNotFound     = errors.New("evidence not found")
+	ErrTooManyEvidence      = fmt.Errorf("a dispute cannot have more than %d attachments", MaxEvidence)
+	ErrInvalidDisputeFilter = errors.New("status must be open or resolved")
+)
+
+const selectDispute = `
+	SELECT d.id, d.offer_id, d.opened_by, u.username, d.reason, d.status, d.resolution, d.resolution_note,
+	       d.resolved_by, d.created_at, d.resolved_at
+	FROM trade_disputes d
+	JOIN users u ON u.id = d.opened_by
+`
+
+func scanDispute(row rowScanner) (*models.TradeDispute, error) {
+	var d models.TradeDispute
+	err := row.Scan(&d.ID, &d.OfferID, &d.OpenedBy, &d.OpenedByUsername, &d.Reason, &d.Status, &d.Resolution,
+		&d.ResolutionNote, &d.ResolvedBy, &d.CreatedAt, &d.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// evidenceKey retourne la clé de stockage d'une pièce jointe à un litige
+func evidenceKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceDisputes, filename)
+}
+
+// OpenDispute ouvre un litige sur un échange en cours ; l'échange reste bloqué
+// jusqu'à la décision d'un administrateur
+func (s *Service) OpenDispute(offerID, userID int, req OpenDisputeRequest) (*models.TradeDispute, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrEmptyReason
+	}
+
+	_, err := s.transition(offerID, userID, StatusDisputed, func(tx *sql.Tx, o *lockedOffer) error {
+		// Un litige tranché clôt l'échange : il n'y en a qu'un par offre
+		if _, err := tx.Exec(
+			"INSERT INTO trade_disputes (offer_id, opened_by, reason) VALUES ($1, $2, $3)",
+			o.id, userID, reason,
+		); err != nil {
+			return fmt.Errorf("failed to open dispute: %w", err)
+		}
+
+		productName, err := listingProductName(tx, o)
+		if err != nil {
+			return err
+		}
+		counterpart := o.listingOwnerID
+		if userID == o.listingOwnerID {
+			counterpart = o.fromUserID
+		}
+		message := fmt.Sprintf("Un litige a été ouvert sur l'échange de « %s »", productName)
+		return notification.Notify(tx, counterpart, notification.TypeTradeDisputed, notification.ObjectOffer, o.id, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetDispute(offerID, userID)
+}
+
+// GetDispute retourne le litige d'un échange dont l'utilisateur est une partie
+func (s *Service) GetDispute(offerID, userID int) (*models.TradeDispute, error) {
+	d, err := scanDispute(s.db.QueryRow(selectDispute+`
+		JOIN offers o ON o.id = d.offer_id
+		JOIN listings l ON l.id = o.listing_id
+		WHERE d.offer_id = $2 AND `+visibleOffer, userID, offerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrDisputeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	return d, s.loadEvidence(d, false)
+}
+
+// GetDisputeByID retourne un litige pour un administrateur
+func (s *Service) GetDisputeByID(disputeID int) (*models.TradeDispute, error) {
+	d, err := scanDispute(s.db.QueryRow(selectDispute+" WHERE d.id = $1", disputeID))
+	if err == sql.ErrNoRows {
+		return nil, ErrDisputeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	return d, s.loadEvidence(d, true)
+}
+
+// ListDisputes retourne les litiges pour les administrateurs, les plus anciens d'abord
+func (s *Service) ListDisputes(status string, page, limit int) ([]models.TradeDispute, int, error) {
+	where := ""
+	args := []interface{}{}
+	if status != "" {
+		if status != DisputeOpen && status != DisputeResolved {
+			return nil, 0, ErrInvalidDisputeFilter
+		}
+		where = " WHERE d.status = $1"
+		args = append(args, status)
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM trade_disputes d"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count disputes: %w", err)
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY d.created_at, d.id LIMIT $%d OFFSET $%d", selectDispute, where, len(args)+1, len(args)+2)
+	rows, err := s.db.Query(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve disputes: %w", err)
+	}
+	defer rows.Close()
+
+	disputes := []models.TradeDispute{}
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan dispute: %w", err)
+		}
+		disputes = append(disputes, *d)
+	}
+	return disputes, total, rows.Err()
+}
+
+// ResolveDispute tranche un litige : l'échange est mené à terme (transfert des
+// produits) ou annulé (l'annonce est remise en vente)
+func (s *Service) ResolveDispute(disputeID, adminID int, req ResolveDisputeRequest) (*models.TradeDispute, error) {
+	target := map[string]string{ResolutionComplete: StatusCompleted, ResolutionCancel: StatusCancelled}[req.Resolution]
+	if target == "" {
+		return nil, ErrInvalidResolution
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var offerID int
+	var status string
+	err = tx.QueryRow("SELECT offer_id, status FROM trade_disputes WHERE id = $1", disputeID).Scan(&offerID, &status)
+	if err == sql.ErrNoRows {
+		return nil, ErrDisputeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+
+	o, err := lockOffer(tx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	// L'offre verrouillée fait foi : le litige peut avoir été tranché entre-temps
+	if err := tx.QueryRow("SELECT status FROM trade_disputes WHERE id = $1", disputeID).Scan(&status); err != nil {
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	if status != DisputeOpen {
+		return nil, ErrDisputeClosed
+	}
+	if err := checkTransition(o.status, target, ActorAdmin); err != nil {
+		return nil, err
+	}
+
+	if target == StatusCompleted {
+		err = completeTrade(tx, o)
+	} else {
+		err = cancelTrade(tx, o, s.cfg.ListingTTL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if _, err := tx.Exec(`
+		UPDATE trade_disputes
+		SET status = $1, resolution = $2, resolution_note = $3, resolved_by = $4, resolved_at = NOW()
+		WHERE id = $5
+	`, DisputeResolved, req.Resolution, note, adminID, disputeID); err != nil {
+		return nil, fmt.Errorf("failed to resolve dispute: %w", err)
+	}
+
+	productName, err := listingProductName(tx, o)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Le litige sur l'échange de « %s » a été tranché : échange terminé", productName)
+	if target == StatusCancelled {
+		message = fmt.Sprintf("Le litige sur l'échange de « %s » a été tranché : échange annulé", productName)
+	}
+	for _, userID := range []int{o.fromUserID, o.listingOwnerID} {
+		if err := notification.Notify(tx, userID, notification.TypeDisputeResolved, notification.ObjectOffer, o.id, message); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit dispute resolution: %w", err)
+	}
+	return s.GetDisputeByID(disputeID)
+}
+
+const selectEvidence = `
+	SELECT e.id, e.dispute_id, e.user_id, u.username, e.filename, e.content_type, e.width, e.height, e.size, e.created_at
+	FROM trade_dispute_evidence e
+	JOIN users u ON u.id = e.user_id
+`
+
+func scanEvidence(row rowScanner) (*models.DisputeEvidence, error) {
+	var e models.DisputeEvidence
+	err := row.Scan(&e.ID, &e.DisputeID, &e.UserID, &e.Username, &e.Filename, &e.ContentType,
+		&e.Width, &e.Height, &e.Size, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// loadEvidence complète un litige avec ses pièces jointes, servies depuis
+// l'échange pour les parties ou depuis le litige pour les administrateurs
+func (s *Service) loadEvidence(d *models.TradeDispute, admin bool) error {
+	rows, err := s.db.Query(selectEvidence+" WHERE e.dispute_id = $1 ORDER BY e.id", d.ID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve dispute evidence: %w", err)
+	}
+	defer rows.Close()
+
+	d.Evidence = []models.DisputeEvidence{}
+	for rows.Next() {
+		e, err := scanEvidence(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan dispute evidence: %w", err)
+		}
+		e.URL = fmt.Sprintf(evidenceURLFmt, d.OfferID, e.ID)
+		if admin {
+			e.URL = fmt.Sprintf(adminEvidenceURLFmt, d.ID, e.ID)
+		}
+		d.Evidence = append(d.Evidence, *e)
+	}
+	return rows.Err()
+}
+
+// AddEvidence joint une photo, débarrassée de ses métadonnées, au litige en
+// cours d'un échange dont l'utilisateur est une partie
+func (s *Service) AddEvidence(offerID, userID int, data []byte) (*models.DisputeEvidence, error) {
+	dispute, err := s.GetDispute(offerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != DisputeOpen {
+		return nil, ErrDisputeClosed
+	}
+	if len(dispute.Evidence) >= MaxEvidence {
+		return nil, ErrTooManyEvidence
+	}
+	if err := s.quotas.Check(userID, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	clean, err := media.CleanImageData(data)
+	if err != nil {
+		return nil, err
+	}
+	format := evidenceFormats[clean.Format]
+
+	filename := fmt.Sprintf("%d_%d_%d%s", dispute.ID, userID, time.Now().UnixNano(), format.ext)
+	key, err := evidenceKey(filename)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.store.Put(key, bytes.NewReader(clean.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store dispute evidence: %w", err)
+	}
+
+	evidenceID, err := s.recordEvidence(dispute.ID, userID, filename, format.contentType, clean, size)
+	if err != nil {
+		s.store.Remove(key)
+		return nil, err
+	}
+
+	e, err := scanEvidence(s.db.QueryRow(selectEvidence+" WHERE e.id = $1", evidenceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute evidence: %w", err)
+	}
+	e.URL = fmt.Sprintf(evidenceURLFmt, offerID, e.ID)
+	return e, nil
+}
+
+// recordEvidence enregistre une pièce jointe après avoir vérifié, litige
+// verrouillé, qu'il est toujours ouvert, le nombre de pièces et le quota
+func (s *Service) recordEvidence(disputeID, userID int, filename, contentType string, clean *media.CleanImage, size int64) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM trade_disputes WHERE id = $1 FOR UPDATE", disputeID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return 0, ErrDisputeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock dispute: %w", err)
+	}
+	if status != DisputeOpen {
+		return 0, ErrDisputeClosed
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM trade_dispute_evidence WHERE dispute_id = $1", disputeID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dispute evidence: %w", err)
+	}
+	if count >= MaxEvidence {
+		return 0, ErrTooManyEvidence
+	}
+	if err := s.quotas.Reserve(tx, userID, size); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO trade_dispute_evidence (dispute_id, user_id, filename, content_type, width, height, size)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, disputeID, userID, filename, contentType, clean.Width, clean.Height, size).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record dispute evidence: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit dispute evidence: %w", err)
+	}
+	return id, nil
+}
+
+// EvidenceKey retourne la clé de stockage et le type MIME d'une pièce jointe
+// au litige d'un échange dont l'utilisateur est une partie
+func (s *Service) EvidenceKey(offerID, evidenceID, userID int) (string, string, error) {
+	dispute, err := s.GetDispute(offerID, userID)
+	if err != nil {
+		return "", "", err
+	}
+	return findEvidence(dispute, evidenceID)
+}
+
+// AdminEvidenceKey retourne la clé de stockage et le type MIME d'une pièce
+// jointe à un litige, pour un administrateur
+func (s *Service) AdminEvidenceKey(disputeID, evidenceID int) (string, string, error) {
+	dispute, err := s.GetDisputeByID(disputeID)
+	if err != nil {
+		return "", "", err
+	}
+	return findEvidence(dispute, evidenceID)
+}
+
+func findEvidence(dispute *models.TradeDispute, evidenceID int) (string, string, error) {
+	for _, e := range dispute.Evidence {
+		if e.ID == evidenceID {
+			key, err := evidenceKey(e.Filename)
+			if err != nil {
+				return "", "", err
+			}
+			return key, e.ContentType, nil
+		}
+	}
+	return "", "", ErrEvidenceNotFound
+}
@@ -2,12 +2,20 @@
 package offer
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
-	"github.com/okinrev/veza-web-app/internal/utils/response"  // ADD THIS
-    "github.com/okinrev/veza-web-app/internal/common"
 
 	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
 )
 
 type Handler struct {
@@ -18,10 +26,50 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// CreateOffer crée une nouvelle offre
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrOfferNotFound), errors.Is(err, ErrListingNotFound), errors.Is(err, ErrProductNotOwned),
+		errors.Is(err, ErrDisputeNotFound), errors.Is(err, ErrEvidenceNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotAllowed):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrListingNotOpen), errors.Is(err, ErrActiveOffer),
+		errors.Is(err, ErrProductUnavailable), errors.Is(err, ErrOfferExpired), errors.Is(err, ErrStaleRound),
+		errors.Is(err, ErrTradeNotCompleted), errors.Is(err, ErrAlreadyRated), errors.Is(err, ErrProductInTrade),
+		errors.Is(err, ErrAlreadyConfirmed), errors.Is(err, ErrDisputeClosed), errors.Is(err, ErrTooManyEvidence):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, media.ErrInvalidImage):
+		response.ErrorJSON(c.Writer, "Attachment must be a JPEG, PNG or WebP image", http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrOwnListing), errors.Is(err, ErrNoPrice), errors.Is(err, ErrInvalidRole),
+		errors.Is(err, ErrEmptyRound), errors.Is(err, ErrNoTerms), errors.Is(err, ErrInvalidExpiry),
+		errors.Is(err, ErrEmptyReason), errors.Is(err, ErrInvalidResolution), errors.Is(err, ErrInvalidDisputeFilter),
+		errors.Is(err, media.ErrImageTooLarge), errors.Is(err, ErrTooManyProducts):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// pagination lit et normalise les paramètres page et limit
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// CreateOffer crée une offre sur une annonce
 func (h *Handler) CreateOffer(c *gin.Context) {
-	listingIDStr := c.Param("id")
-	listingID, err := strconv.Atoi(listingIDStr)
+	listingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
 		return
@@ -33,34 +81,124 @@ func (h *Handler) CreateOffer(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		ProposedProductID int    `json:"proposed_product_id" binding:"required"`
-		Message           string `json:"message"`
-	}
-
+	var req CreateOfferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
 		return
 	}
 
-	// TODO: Sauvegarder en BDD
-	offer := map[string]interface{}{
-		"id":                  1,
-		"listing_id":          listingID,
-		"from_user_id":        userID,
-		"proposed_product_id": req.ProposedProductID,
-		"message":             req.Message,
-		"status":              "pending",
-		"created_at":          "2025-01-01T00:00:00Z",
+	offer, err := h.service.CreateOffer(listingID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to create offer")
+		return
 	}
 
 	response.SuccessJSON(c.Writer, offer, "Offer created successfully")
 }
 
-// AcceptOffer accepte une offre
+// ListOffers liste les offres envoyées (role=sent, par défaut) ou reçues (role=received)
+func (h *Handler) ListOffers(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	page, limit := pagination(c)
+	offers, total, err := h.service.ListUserOffers(userID, c.DefaultQuery("role", "sent"), c.Query("status"), page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve offers")
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+	response.PaginatedJSON(c.Writer, offers, meta, "Offers retrieved successfully")
+}
+
+// ListListingOffers liste les offres d'une annonce visibles par l'utilisateur
+func (h *Handler) ListListingOffers(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid listing ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	offers, err := h.service.ListListingOffers(listingID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve offers")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, offers, "Offers retrieved successfully")
+}
+
+// GetOffer retourne une offre dont l'utilisateur est l'auteur ou le destinataire
+func (h *Handler) GetOffer(c *gin.Context) {
+	h.withOffer(c, "Failed to retrieve offer", "Offer retrieved successfully", h.service.GetOffer)
+}
+
+// AcceptOffer accepte les termes du dernier tour d'une offre
 func (h *Handler) AcceptOffer(c *gin.Context) {
-	offerIDStr := c.Param("id")
-	offerID, err := strconv.Atoi(offerIDStr)
+	var req AcceptOfferRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.withOffer(c, "Failed to accept offer", "Offer accepted successfully", func(offerID, userID int) (*models.OfferWithDetails, error) {
+		return h.service.AcceptOffer(offerID, userID, req.RoundID)
+	})
+}
+
+// RejectOffer refuse une offre ou une contre-proposition
+func (h *Handler) RejectOffer(c *gin.Context) {
+	h.withOffer(c, "Failed to reject offer", "Offer rejected successfully", h.service.RejectOffer)
+}
+
+// WithdrawOffer retire une offre
+func (h *Handler) WithdrawOffer(c *gin.Context) {
+	h.withOffer(c, "Failed to withdraw offer", "Offer withdrawn successfully", h.service.WithdrawOffer)
+}
+
+// CounterOffer ajoute un tour de négociation à une offre
+func (h *Handler) CounterOffer(c *gin.Context) {
+	var req CounterOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	h.withOffer(c, "Failed to counter offer", "Counter offer sent successfully", func(offerID, userID int) (*models.OfferWithDetails, error) {
+		return h.service.CounterOffer(offerID, userID, req)
+	})
+}
+
+// ShipOffer indique que le produit de l'annonce a été expédié ou remis
+func (h *Handler) ShipOffer(c *gin.Context) {
+	h.withOffer(c, "Failed to mark trade as shipped", "Trade marked as shipped successfully", h.service.ShipOffer)
+}
+
+// ConfirmOffer confirme l'échange pour l'utilisateur connecté
+func (h *Handler) ConfirmOffer(c *gin.Context) {
+	h.withOffer(c, "Failed to confirm trade", "Trade confirmed successfully", h.service.ConfirmOffer)
+}
+
+// RateTrade évalue l'autre partie d'un échange terminé
+func (h *Handler) RateTrade(c *gin.Context) {
+	offerID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.ErrorJSON(c.Writer, "Invalid offer ID", http.StatusBadRequest)
 		return
@@ -72,9 +210,264 @@ func (h *Handler) AcceptOffer(c *gin.Context) {
 		return
 	}
 
-	// TODO: Vérifier que l'utilisateur est propriétaire du listing + accepter l'offre
-	_ = offerID
-	_ = userID
+	var req RateTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := h.service.RateTrade(offerID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to rate trade")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, rating, "Trade rated successfully")
+}
+
+// ListOfferRatings liste les évaluations d'un échange
+func (h *Handler) ListOfferRatings(c *gin.Context) {
+	offerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid offer ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
 
-	response.SuccessJSON(c.Writer, nil, "Offer accepted successfully")
-}
\ No newline at end of file
+	ratings, err := h.service.ListOfferRatings(offerID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve trade ratings")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, ratings, "Trade ratings retrieved successfully")
+}
+
+// withOffer applique une action du service à l'offre désignée par l'URL
+func (h *Handler) withOffer(c *gin.Context, fallback, message string, action func(offerID, userID int) (*models.OfferWithDetails, error)) {
+	offerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid offer ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	offer, err := action(offerID, userID)
+	if err != nil {
+		writeError(c, err, fallback)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, offer, message)
+}
+
+// offerParams lit l'identifiant de l'offre et l'utilisateur connecté
+func offerParams(c *gin.Context) (int, int, bool) {
+	offerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid offer ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	return offerID, userID, true
+}
+
+// OpenDispute ouvre un litige sur un échange en cours
+func (h *Handler) OpenDispute(c *gin.Context) {
+	offerID, userID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+
+	var req OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.service.OpenDispute(offerID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to open dispute")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, dispute, "Dispute opened successfully")
+}
+
+// GetDispute récupère le litige d'un échange et ses pièces jointes
+func (h *Handler) GetDispute(c *gin.Context) {
+	offerID, userID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.service.GetDispute(offerID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve dispute")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, dispute, "Dispute retrieved successfully")
+}
+
+// UploadEvidence joint une photo au litige d'un échange
+func (h *Handler) UploadEvidence(c *gin.Context) {
+	offerID, userID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("evidence")
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Attachment file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > evidenceMaxSize {
+		response.ErrorJSON(c.Writer, "Attachment exceeds 10MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// La photo est nettoyée en mémoire avant tout enregistrement
+	data, err := io.ReadAll(io.LimitReader(file, evidenceMaxSize))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to read attachment", http.StatusInternalServerError)
+		return
+	}
+
+	evidence, err := h.service.AddEvidence(offerID, userID, data)
+	if err != nil {
+		writeError(c, err, "Failed to add dispute evidence")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, evidence, "Evidence added successfully")
+}
+
+// GetEvidence envoie une pièce jointe au litige d'un échange
+func (h *Handler) GetEvidence(c *gin.Context) {
+	offerID, userID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+	evidenceID, err := strconv.Atoi(c.Param("evidence_id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid evidence ID", http.StatusBadRequest)
+		return
+	}
+
+	key, contentType, err := h.service.EvidenceKey(offerID, evidenceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve dispute evidence")
+		return
+	}
+	h.serveEvidence(c, key, contentType)
+}
+
+func (h *Handler) serveEvidence(c *gin.Context, key, contentType string) {
+	if err := h.service.store.Serve(c.Writer, c.Request, key, storage.ServeOptions{ContentType: contentType, Inline: true}); err != nil {
+		utils.LogError(fmt.Sprintf("Failed to serve dispute evidence: %v", err))
+		response.ErrorJSON(c.Writer, "Evidence file not found", http.StatusNotFound)
+	}
+}
+
+// ListDisputes liste les litiges pour les administrateurs (status=open|resolved)
+func (h *Handler) ListDisputes(c *gin.Context) {
+	page, limit := pagination(c)
+
+	disputes, total, err := h.service.ListDisputes(c.Query("status"), page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve disputes")
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+	response.PaginatedJSON(c.Writer, disputes, meta, "Disputes retrieved successfully")
+}
+
+// GetAdminDispute récupère un litige et ses pièces jointes pour un administrateur
+func (h *Handler) GetAdminDispute(c *gin.Context) {
+	disputeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.service.GetDisputeByID(disputeID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve dispute")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, dispute, "Dispute retrieved successfully")
+}
+
+// GetAdminEvidence envoie une pièce jointe à un litige pour un administrateur
+func (h *Handler) GetAdminEvidence(c *gin.Context) {
+	disputeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+	evidenceID, err := strconv.Atoi(c.Param("evidence_id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid evidence ID", http.StatusBadRequest)
+		return
+	}
+
+	key, contentType, err := h.service.AdminEvidenceKey(disputeID, evidenceID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve dispute evidence")
+		return
+	}
+	h.serveEvidence(c, key, contentType)
+}
+
+// ResolveDispute tranche un litige (resolution=complete|cancel)
+func (h *Handler) ResolveDispute(c *gin.Context) {
+	disputeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	adminID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.service.ResolveDispute(disputeID, adminID, req)
+	if err != nil {
+		writeError(c, err, "Failed to resolve dispute")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, dispute, "Dispute resolved successfully")
+}
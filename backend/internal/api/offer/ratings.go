@@ -0,0 +1,139 @@
+package offer
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/api/notification"
+	"github.com/okinrev/veza-web-app/internal/models"
+)
+
+const selectRating = `
+	SELECT r.id, COALESCE(r.offer_id, 0), r.rater_id, u.username, COALESCE(u.avatar, ''), r.rated_user_id,
+	       r.role, r.rating, r.comment, r.created_at
+	FROM trade_ratings r
+	JOIN users u ON u.id = r.rater_id
+`
+
+func scanRating(row rowScanner) (*models.TradeRating, error) {
+	var r models.TradeRating
+	err := row.Scan(&r.ID, &r.OfferID, &r.RaterID, &r.RaterUsername, &r.RaterAvatar, &r.RatedUserID,
+		&r.Role, &r.Rating, &r.Comment, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// refreshReputation recalcule la note moyenne et le nombre d'évaluations d'un utilisateur
+func refreshReputation(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec(`
+		UPDATE users SET
+			rating_average = COALESCE((SELECT AVG(rating) FROM trade_ratings WHERE rated_user_id = $1), 0),
+			rating_count = (SELECT COUNT(*) FROM trade_ratings WHERE rated_user_id = $1)
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh user reputation: %w", err)
+	}
+	return nil
+}
+
+// RateTrade enregistre l'évaluation de l'autre partie d'un échange terminé,
+// une seule fois par partie
+func (s *Service) RateTrade(offerID, userID int, req RateTradeRequest) (*models.TradeRating, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status, productName string
+	var offererID, ownerID int
+	err = tx.QueryRow(`
+		SELECT o.status, o.from_user_id, l.user_id, p.name
+		FROM offers o
+		JOIN listings l ON l.id = o.listing_id
+		JOIN products p ON p.id = l.product_id
+		WHERE o.id = $1 AND `+visibleOffer, userID, offerID,
+	).Scan(&status, &offererID, &ownerID, &productName)
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+	if status != StatusCompleted {
+		return nil, ErrTradeNotCompleted
+	}
+
+	// L'utilisateur évalue l'autre partie
+	ratedUserID, role := ownerID, RoleSeller
+	if userID == ownerID {
+		ratedUserID, role = offererID, RoleBuyer
+	}
+
+	var ratingID int
+	err = tx.QueryRow(`
+		INSERT INTO trade_ratings (offer_id, rater_id, rated_user_id, role, rating, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (offer_id, rater_id) DO NOTHING
+		RETURNING id
+	`, offerID, userID, ratedUserID, role, req.Rating, strings.TrimSpace(req.Comment)).Scan(&ratingID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAlreadyRated
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trade rating: %w", err)
+	}
+
+	if err := refreshReputation(tx, ratedUserID); err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Vous avez reçu une évaluation de %d/5 pour l'échange de « %s »", req.Rating, productName)
+	if err := notification.Notify(tx, ratedUserID, notification.TypeTradeRated, notification.ObjectOffer, offerID, message); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit trade rating: %w", err)
+	}
+
+	rating, err := scanRating(s.db.QueryRow(selectRating+" WHERE r.id = $1", ratingID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade rating: %w", err)
+	}
+	return rating, nil
+}
+
+// ListOfferRatings retourne les évaluations d'un échange dont l'utilisateur est une partie
+func (s *Service) ListOfferRatings(offerID, userID int) ([]models.TradeRating, error) {
+	var exists bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM offers o JOIN listings l ON l.id = o.listing_id WHERE o.id = $2 AND "+visibleOffer+")",
+		userID, offerID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+	if !exists {
+		return nil, ErrOfferNotFound
+	}
+
+	rows, err := s.db.Query(selectRating+" WHERE r.offer_id = $1 ORDER BY r.created_at", offerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve trade ratings: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := []models.TradeRating{}
+	for rows.Next() {
+		r, err := scanRating(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trade rating: %w", err)
+		}
+		ratings = append(ratings, *r)
+	}
+	return ratings, rows.Err()
+}
@@ -7,12 +7,37 @@ import (
 
 func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
 	offers := router.Group("/offers")
-	
+
 	// Routes protégées
 	protected := offers.Group("")
 	protected.Use(middleware.JWTAuthMiddleware(jwtSecret))
 	{
+		protected.GET("", handler.ListOffers)
 		protected.POST("/listings/:id/offers", handler.CreateOffer)
+		protected.GET("/listings/:id/offers", handler.ListListingOffers)
+		protected.GET("/:id", handler.GetOffer)
 		protected.POST("/:id/accept", handler.AcceptOffer)
+		protected.POST("/:id/reject", handler.RejectOffer)
+		protected.POST("/:id/withdraw", handler.WithdrawOffer)
+		protected.POST("/:id/counter", handler.CounterOffer)
+		protected.POST("/:id/ship", handler.ShipOffer)
+		protected.POST("/:id/confirm", handler.ConfirmOffer)
+		protected.POST("/:id/dispute", handler.OpenDispute)
+		protected.GET("/:id/dispute", handler.GetDispute)
+		protected.POST("/:id/dispute/evidence", handler.UploadEvidence)
+		protected.GET("/:id/dispute/evidence/:evidence_id", handler.GetEvidence)
+		protected.GET("/:id/ratings", handler.ListOfferRatings)
+		protected.POST("/:id/ratings", handler.RateTrade)
+	}
+
+	// Litiges sur les échanges, tranchés par les administrateurs
+	disputes := router.Group("/admin/disputes")
+	disputes.Use(middleware.JWTAuthMiddleware(jwtSecret))
+	disputes.Use(middleware.AdminMiddleware())
+	{
+		disputes.GET("", handler.ListDisputes)
+		disputes.GET("/:id", handler.GetAdminDispute)
+		disputes.GET("/:id/evidence/:evidence_id", handler.GetAdminEvidence)
+		disputes.POST("/:id/resolve", handler.ResolveDispute)
 	}
 }
@@ -1,15 +1,1047 @@
 package offer
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/okinrev/veza-web-app/internal/api/conversation"
+	"github.com/okinrev/veza-web-app/internal/api/notification"
+	"github.com/okinrev/veza-web-app/internal/api/pricing"
+	"github.com/okinrev/veza-web-app/internal/blob"
+	"github.com/okinrev/veza-web-app/internal/config"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrOfferNotFound      = errors.New("offer not found")
+	ErrListingNotFound    = errors.New("listing not found")
+	ErrNotAllowed         = errors.New("you are not allowed to perform this action on this offer")
+	ErrInvalidTransition  = errors.New("invalid offer status transition")
+	ErrListingNotOpen     = errors.New("listing is no longer open")
+	ErrOwnListing         = errors.New("you cannot make an offer on your own listing")
+	ErrProductNotOwned    = errors.New("product not found in your products")
+	ErrNoPrice            = errors.New("listing has no price, propose an amount or a product to exchange")
+	ErrNoTerms            = errors.New("an offer needs an amount or a product to exchange")
+	ErrActiveOffer        = errors.New("you already have an offer in progress on this listing")
+	ErrProductUnavailable = errors.New("a product of this offer has changed owner")
+	ErrInvalidRole        = errors.New("role must be sent or received")
+	ErrEmptyRound         = errors.New("a counter offer must propose an amount, a product or a message")
+	ErrStaleRound         = errors.New("only the latest round of an offer can be accepted")
+	ErrInvalidExpiry      = errors.New("expires_at must be in the future and within the maximum offer validity")
+	ErrOfferExpired       = errors.New("offer has expired")
+	ErrTradeNotCompleted  = errors.New("only completed trades can be rated")
+	ErrAlreadyRated       = errors.New("you have already rated this trade")
+	ErrProductInTrade     = errors.New("this product is engaged in a trade in progress")
+	ErrTooManyProducts    = fmt.Errorf("an offer cannot propose more than %d products", MaxBundleProducts)
+	ErrAlreadyConfirmed   = errors.New("you have already confirmed this trade")
 )
 
+// selectOffer lit une offre avec son auteur, les produits proposés, l'annonce
+// visée et la réputation des deux parties
+const selectOffer = `
+	SELECT o.id, o.listing_id, o.from_user_id, o.amount, o.message, o.status,
+	       o.expires_at, o.viewed_at, o.shipped_at, o.owner_confirmed_at, o.offerer_confirmed_at, o.completed_at,
+	       o.created_at, COALESCE(o.updated_at, o.created_at),
+	       ARRAY(SELECT op.product_id FROM offer_products op WHERE op.offer_id = o.id ORDER BY op.product_id),
+	       ARRAY(SELECT pp.name FROM offer_products op JOIN products pp ON pp.id = op.product_id
+	             WHERE op.offer_id = o.id ORDER BY op.product_id),
+	       u.username, u.avatar, lp.name, l.user_id,
+	       u.rating_average, u.rating_count, u.completed_trades,
+	       lu.rating_average, lu.rating_count, lu.completed_trades
+	FROM offers o
+	JOIN users u ON u.id = o.from_user_id
+	JOIN listings l ON l.id = o.listing_id
+	JOIN users lu ON lu.id = l.user_id
+	JOIN products lp ON lp.id = l.product_id
+`
+
+// visibleOffer restreint aux offres dont l'utilisateur $1 est l'auteur ou le destinataire
+const visibleOffer = "(o.from_user_id = $1 OR l.user_id = $1)"
+
 type Service struct {
-	db *database.DB
+	db     *database.DB
+	cfg    config.MarketplaceConfig
+	quotas *quota.Manager
+	store  *blob.Store
+}
+
+func NewService(db *database.DB, cfg config.MarketplaceConfig, quotas *quota.Manager, store *blob.Store) *Service {
+	return &Service{
+		db:     db,
+		cfg:    cfg,
+		quotas: quotas,
+		store:  store,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// offerProducts associe les identifiants et les noms des produits proposés
+func offerProducts(ids pq.Int64Array, names pq.StringArray) []models.OfferProduct {
+	products := make([]models.OfferProduct, 0, len(ids))
+	for i, id := range ids {
+		product := models.OfferProduct{ID: int(id)}
+		if i < len(names) {
+			product.Name = names[i]
+		}
+		products = append(products, product)
+	}
+	return products
+}
+
+func scanOffer(row rowScanner) (*models.OfferWithDetails, error) {
+	var o models.OfferWithDetails
+	var productIDs pq.Int64Array
+	var productNames pq.StringArray
+	err := row.Scan(
+		&o.ID, &o.ListingID, &o.FromUserID, &o.Amount, &o.Message, &o.Status,
+		&o.ExpiresAt, &o.ViewedAt, &o.ShippedAt, &o.OwnerConfirmedAt, &o.OffererConfirmedAt, &o.CompletedAt,
+		&o.CreatedAt, &o.UpdatedAt, &productIDs, &productNames,
+		&o.FromUsername, &o.FromUserAvatar, &o.ListingTitle, &o.ListingOwnerID,
+		&o.FromUserReputation.RatingAverage, &o.FromUserReputation.RatingCount, &o.FromUserReputation.CompletedTrades,
+		&o.ListingOwnerReputation.RatingAverage, &o.ListingOwnerReputation.RatingCount, &o.ListingOwnerReputation.CompletedTrades,
+	)
+	if err != nil {
+		return nil, err
+	}
+	o.ProposedProducts = offerProducts(productIDs, productNames)
+	return &o, nil
+}
+
+// checkTransition vérifie qu'un acteur peut faire passer une offre d'un statut à l'autre
+func checkTransition(from, to, actor string) error {
+	allowed, ok := transitions[from][to]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	if allowed != actor && !(allowed == ActorParty && (actor == ActorOwner || actor == ActorOfferer)) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// CreateOffer propose un échange ou un achat sur une annonce ouverte
+func (s *Service) CreateOffer(listingID, userID int, req CreateOfferRequest) (*models.OfferWithDetails, error) {
+	var ownerID int
+	var status string
+	var price sql.NullInt32
+	err := s.db.QueryRow(
+		"SELECT user_id, status, price FROM listings WHERE id = $1", listingID,
+	).Scan(&ownerID, &status, &price)
+	if err == sql.ErrNoRows {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if ownerID == userID {
+		return nil, ErrOwnListing
+	}
+	if status != listingOpen {
+		return nil, ErrListingNotOpen
+	}
+
+	// expires_at est un TIMESTAMP sans fuseau : l'échéance est enregistrée en UTC
+	expiresAt := time.Now().Add(s.cfg.OfferTTL).UTC()
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) || req.ExpiresAt.After(time.Now().Add(s.cfg.OfferMaxTTL)) {
+			return nil, ErrInvalidExpiry
+		}
+		expiresAt = req.ExpiresAt.UTC()
+	}
+
+	productIDs, err := bundleProducts(req.ProposedProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sans produit ni montant, l'offre porte sur le prix de l'annonce
+	amount := req.Amount
+	if len(productIDs) == 0 && amount == nil {
+		if !price.Valid {
+			return nil, ErrNoPrice
+		}
+		listingPrice := int(price.Int32)
+		amount = &listingPrice
+	}
+	if err := checkProducts(s.db, productIDs, userID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	message := strings.TrimSpace(req.Message)
+	err = tx.QueryRow(`
+		INSERT INTO offers (listing_id, from_user_id, amount, message, status, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (listing_id, from_user_id) WHERE status IN ('pending', 'countered') DO NOTHING
+		RETURNING id
+	`, listingID, userID, amount, message, StatusPending, expiresAt).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrActiveOffer
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offer: %w", err)
+	}
+	if err := setProducts(tx, id, productIDs); err != nil {
+		return nil, err
+	}
+	if err := addRound(tx, id, userID, amount, productIDs, message); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit offer: %w", err)
+	}
+	return s.GetOffer(id, userID)
+}
+
+// queryer est satisfait par la base et par une transaction
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// bundleProducts dédoublonne les produits proposés dans une offre et vérifie leur nombre
+func bundleProducts(ids []int) ([]int, error) {
+	products := []int{}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, ErrProductNotOwned
+		}
+		if !seen[id] {
+			seen[id] = true
+			products = append(products, id)
+		}
+	}
+	if len(products) > MaxBundleProducts {
+		return nil, ErrTooManyProducts
+	}
+	return products, nil
+}
+
+// checkProducts vérifie chacun des produits proposés en échange
+func checkProducts(q queryer, productIDs []int, userID int) error {
+	for _, productID := range productIDs {
+		if err := checkProduct(q, productID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkProduct vérifie qu'un produit proposé en échange appartient à l'utilisateur
+func checkProduct(q queryer, productID, userID int) error {
+	var owned bool
+	err := q.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND user_id = $2)", productID, userID,
+	).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to check product ownership: %w", err)
+	}
+	if !owned {
+		return ErrProductNotOwned
+	}
+
+	inTrade, err := ProductInTrade(q, productID)
+	if err != nil {
+		return err
+	}
+	if inTrade {
+		return ErrProductInTrade
+	}
+	return nil
+}
+
+// ProductInTrade indique si un produit fait partie d'un échange accepté mais pas
+// encore terminé : il ne peut alors être ni remis en vente ni proposé ailleurs
+func ProductInTrade(q queryer, productID int) (bool, error) {
+	var inTrade bool
+	err := q.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM offers o JOIN listings l ON l.id = o.listing_id
+			WHERE o.status = ANY($2)
+			  AND (l.product_id = $1
+			       OR EXISTS (SELECT 1 FROM offer_products op WHERE op.offer_id = o.id AND op.product_id = $1))
+		)
+	`, productID, pq.Array(tradeStatuses)).Scan(&inTrade)
+	if err != nil {
+		return false, fmt.Errorf("failed to check product trades: %w", err)
+	}
+	return inTrade, nil
+}
+
+// ListingTraded indique si une annonce a un échange accepté, en cours ou terminé :
+// l'annonce porte alors les évaluations de l'échange et ne peut plus être supprimée
+func ListingTraded(q queryer, listingID int) (bool, error) {
+	var traded bool
+	err := q.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM offers WHERE listing_id = $1 AND status = ANY($2))",
+		listingID, pq.Array(append([]string{StatusCompleted}, tradeStatuses...)),
+	).Scan(&traded)
+	if err != nil {
+		return false, fmt.Errorf("failed to check listing trades: %w", err)
+	}
+	return traded, nil
+}
+
+// setProducts remplace les produits proposés selon les termes en cours d'une offre
+func setProducts(tx *sql.Tx, offerID int, productIDs []int) error {
+	if _, err := tx.Exec("DELETE FROM offer_products WHERE offer_id = $1", offerID); err != nil {
+		return fmt.Errorf("failed to clear offer products: %w", err)
+	}
+	_, err := tx.Exec(
+		"INSERT INTO offer_products (offer_id, product_id) SELECT $1, unnest($2::int[])", offerID, pq.Array(productIDs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record offer products: %w", err)
+	}
+	return nil
+}
+
+// addRound enregistre un tour de négociation et les produits qu'il propose
+func addRound(tx *sql.Tx, offerID, userID int, amount *int, productIDs []int, message string) error {
+	var roundID int
+	err := tx.QueryRow(`
+		INSERT INTO offer_rounds (offer_id, user_id, amount, message)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id
+	`, offerID, userID, amount, message).Scan(&roundID)
+	if err != nil {
+		return fmt.Errorf("failed to record offer round: %w", err)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO offer_round_products (round_id, product_id) SELECT $1, unnest($2::int[])", roundID, pq.Array(productIDs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record offer round products: %w", err)
+	}
+	return nil
+}
+
+// loadRounds retourne l'historique complet de la négociation d'une offre
+func (s *Service) loadRounds(offerID int) ([]models.OfferRound, error) {
+	rows, err := s.db.Query(`
+		SELECT r.id, r.offer_id, r.user_id, u.username, r.amount, r.message, r.created_at,
+		       ARRAY(SELECT rp.product_id FROM offer_round_products rp WHERE rp.round_id = r.id ORDER BY rp.product_id),
+		       ARRAY(SELECT p.name FROM offer_round_products rp JOIN products p ON p.id = rp.product_id
+		             WHERE rp.round_id = r.id ORDER BY rp.product_id)
+		FROM offer_rounds r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.offer_id = $1
+		ORDER BY r.id
+	`, offerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve offer rounds: %w", err)
+	}
+	defer rows.Close()
+
+	rounds := []models.OfferRound{}
+	for rows.Next() {
+		var r models.OfferRound
+		var productIDs pq.Int64Array
+		var productNames pq.StringArray
+		if err := rows.Scan(&r.ID, &r.OfferID, &r.UserID, &r.Username, &r.Amount, &r.Message, &r.CreatedAt,
+			&productIDs, &productNames); err != nil {
+			return nil, fmt.Errorf("failed to scan offer round: %w", err)
+		}
+		r.Products = offerProducts(productIDs, productNames)
+		rounds = append(rounds, r)
+	}
+	return rounds, rows.Err()
+}
+
+// GetOffer retourne une offre dont l'utilisateur est l'auteur ou le destinataire,
+// avec l'historique de sa négociation.
+// La première consultation par le propriétaire de l'annonce est enregistrée,
+// pour que l'auteur de l'offre sache qu'elle a été lue.
+func (s *Service) GetOffer(offerID, userID int) (*models.OfferWithDetails, error) {
+	o, err := scanOffer(s.db.QueryRow(selectOffer+" WHERE o.id = $2 AND "+visibleOffer, userID, offerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+
+	if userID == o.ListingOwnerID && !o.ViewedAt.Valid {
+		err := s.db.QueryRow(
+			"UPDATE offers SET viewed_at = COALESCE(viewed_at, NOW()) WHERE id = $1 RETURNING viewed_at", offerID,
+		).Scan(&o.ViewedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark offer as viewed: %w", err)
+		}
+	}
+
+	o.Rounds, err = s.loadRounds(offerID)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ListListingOffers retourne les offres d'une annonce : toutes pour son
+// propriétaire, celles de l'utilisateur pour les autres
+func (s *Service) ListListingOffers(listingID, userID int) ([]models.OfferWithDetails, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM listings WHERE id = $1)", listingID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if !exists {
+		return nil, ErrListingNotFound
+	}
+
+	offers, _, err := s.queryOffers(" WHERE o.listing_id = $2 AND "+visibleOffer, []interface{}{userID, listingID}, 1, 0)
+	return offers, err
+}
+
+// ListUserOffers retourne les offres envoyées ou reçues par l'utilisateur
+func (s *Service) ListUserOffers(userID int, role, status string, page, limit int) ([]models.OfferWithDetails, int, error) {
+	var where string
+	switch role {
+	case "sent":
+		where = " WHERE o.from_user_id = $1"
+	case "received":
+		where = " WHERE l.user_id = $1"
+	default:
+		return nil, 0, ErrInvalidRole
+	}
+	args := []interface{}{userID}
+	if status != "" {
+		args = append(args, status)
+		where += " AND o.status = $2"
+	}
+	return s.queryOffers(where, args, page, limit)
+}
+
+// queryOffers exécute une recherche d'offres ; limit = 0 retourne tous les résultats
+func (s *Service) queryOffers(where string, args []interface{}, page, limit int) ([]models.OfferWithDetails, int, error) {
+	var total int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM offers o JOIN listings l ON l.id = o.listing_id"+where, args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count offers: %w", err)
+	}
+
+	query := selectOffer + where + " ORDER BY o.created_at DESC, o.id DESC"
+	if limit > 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, limit, (page-1)*limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve offers: %w", err)
+	}
+	defer rows.Close()
+
+	offers := []models.OfferWithDetails{}
+	for rows.Next() {
+		o, err := scanOffer(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan offer: %w", err)
+		}
+		offers = append(offers, *o)
+	}
+	return offers, total, rows.Err()
+}
+
+// lockedOffer est une offre verrouillée avec l'annonce qu'elle vise
+type lockedOffer struct {
+	id               int
+	listingID        int
+	fromUserID       int
+	proposedProducts []int // produits proposés par l'auteur de l'offre
+	amount           sql.NullInt32
+	status           string
+	listingOwnerID   int
+	listingProductID int
+	listingStatus    string
+	expired          bool // offre en cours dont l'échéance est passée, en attente de la tâche d'expiration
+	ownerConfirmed   bool
+	offererConfirmed bool
+}
+
+// actor retourne le rôle de l'utilisateur dans l'offre
+func (o *lockedOffer) actor(userID int) string {
+	switch userID {
+	case o.listingOwnerID:
+		return ActorOwner
+	case o.fromUserID:
+		return ActorOfferer
+	}
+	return ""
+}
+
+// lockOffer verrouille une offre et son annonce dans la transaction. L'annonce
+// est toujours verrouillée avant ses offres, comme à l'acceptation.
+func lockOffer(tx *sql.Tx, offerID int) (*lockedOffer, error) {
+	var o lockedOffer
+	err := tx.QueryRow("SELECT listing_id FROM offers WHERE id = $1", offerID).Scan(&o.listingID)
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+
+	err = tx.QueryRow(
+		"SELECT user_id, product_id, status FROM listings WHERE id = $1 FOR UPDATE", o.listingID,
+	).Scan(&o.listingOwnerID, &o.listingProductID, &o.listingStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock listing: %w", err)
+	}
+
+	var productIDs pq.Int64Array
+	err = tx.QueryRow(
+		`SELECT id, from_user_id, amount, status,
+		        COALESCE(expires_at <= NOW() AND status = ANY($2), false),
+		        owner_confirmed_at IS NOT NULL, offerer_confirmed_at IS NOT NULL,
+		        ARRAY(SELECT product_id FROM offer_products WHERE offer_id = offers.id ORDER BY product_id)
+		 FROM offers WHERE id = $1 FOR UPDATE`, offerID, pq.Array(activeStatuses),
+	).Scan(&o.id, &o.fromUserID, &o.amount, &o.status, &o.expired,
+		&o.ownerConfirmed, &o.offererConfirmed, &productIDs)
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock offer: %w", err)
+	}
+	for _, id := range productIDs {
+		o.proposedProducts = append(o.proposedProducts, int(id))
+	}
+	return &o, nil
+}
+
+// transition fait évoluer une offre après vérification de l'acteur ; apply
+// complète le changement dans la même transaction
+func (s *Service) transition(offerID, userID int, to string, apply func(tx *sql.Tx, o *lockedOffer) error) (*models.OfferWithDetails, error) {
+	return s.transitionFor(offerID, userID, func(string) string { return to }, apply)
+}
+
+// transitionFor fait évoluer une offre vers un statut qui dépend de l'acteur
+func (s *Service) transitionFor(offerID, userID int, target func(actor string) string, apply func(tx *sql.Tx, o *lockedOffer) error) (*models.OfferWithDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	o, err := lockOffer(tx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	actor := o.actor(userID)
+	if actor == "" {
+		return nil, ErrOfferNotFound
+	}
+	to := target(actor)
+	if err := checkTransition(o.status, to, actor); err != nil {
+		return nil, err
+	}
+	if o.expired {
+		return nil, ErrOfferExpired
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE offers SET status = $1, updated_at = NOW() WHERE id = $2", to, offerID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update offer: %w", err)
+	}
+	if apply != nil {
+		if err := apply(tx, o); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit offer update: %w", err)
+	}
+	return s.GetOffer(offerID, userID)
+}
+
+// AcceptOffer accepte les termes du dernier tour d'une offre : les produits
+// changent de propriétaire, l'annonce est vendue (achat) ou fermée (échange) et
+// les offres concurrentes sont refusées, en une seule transaction. roundID > 0
+// garantit que les termes acceptés sont ceux que l'utilisateur a consultés.
+func (s *Service) AcceptOffer(offerID, userID, roundID int) (*models.OfferWithDetails, error) {
+	return s.transition(offerID, userID, StatusAccepted, func(tx *sql.Tx, o *lockedOffer) error {
+		if roundID > 0 {
+			var latest int
+			err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM offer_rounds WHERE offer_id = $1", o.id).Scan(&latest)
+			if err != nil {
+				return fmt.Errorf("failed to get latest offer round: %w", err)
+			}
+			if latest != roundID {
+				return ErrStaleRound
+			}
+		}
+		return acceptOffer(tx, o)
+	})
+}
+
+// acceptOffer réserve l'annonce pour l'échange accepté. Les produits ne
+// changent de propriétaire qu'à la fin de l'échange (completeTrade).
+func acceptOffer(tx *sql.Tx, o *lockedOffer) error {
+	if o.listingStatus != listingOpen {
+		return ErrListingNotOpen
+	}
+
+	products, err := lockProducts(tx, o)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE listings SET status = $1, updated_at = NOW() WHERE id = $2", listingReserved, o.listingID,
+	); err != nil {
+		return fmt.Errorf("failed to reserve listing: %w", err)
+	}
+
+	// Les autres annonces des produits engagés sont fermées (verrouillées avant
+	// leurs offres), puis les offres concurrentes, celles qui proposent un
+	// produit engagé et celles visant une annonce d'un produit engagé sont refusées
+	if _, err := tx.Exec(
+		"UPDATE listings SET status = $1, updated_at = NOW() WHERE product_id = ANY($2) AND status = $3",
+		listingClosed, pq.Array(products), listingOpen,
+	); err != nil {
+		return fmt.Errorf("failed to close listings of traded products: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE offers SET status = $1, updated_at = NOW()
+		WHERE id <> $2 AND status = ANY($3)
+		  AND (listing_id = $4
+		       OR id IN (SELECT offer_id FROM offer_products WHERE product_id = ANY($5))
+		       OR listing_id IN (SELECT id FROM listings WHERE product_id = ANY($5)))
+	`, StatusRejected, o.id, pq.Array(activeStatuses), o.listingID, pq.Array(products)); err != nil {
+		return fmt.Errorf("failed to reject competing offers: %w", err)
+	}
+	return nil
 }
 
-func NewService(db *database.DB) *Service {
-	return &Service{db: db}
+// lockProducts verrouille les produits d'une offre et vérifie qu'ils
+// appartiennent toujours aux deux parties
+func lockProducts(tx *sql.Tx, o *lockedOffer) ([]int, error) {
+	products := []int{o.listingProductID}
+	owners := map[int]int{o.listingProductID: o.listingOwnerID}
+	for _, proposed := range o.proposedProducts {
+		products = append(products, proposed)
+		owners[proposed] = o.fromUserID
+	}
+
+	rows, err := tx.Query("SELECT id, user_id FROM products WHERE id = ANY($1) FOR UPDATE", pq.Array(products))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock products: %w", err)
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var id, ownerID int
+		if err := rows.Scan(&id, &ownerID); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		if owners[id] != ownerID {
+			return nil, ErrProductUnavailable
+		}
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock products: %w", err)
+	}
+	if found != len(products) {
+		return nil, ErrProductUnavailable
+	}
+	return products, nil
+}
+
+// completeTrade termine un échange : tous les produits changent de propriétaire
+// dans la transaction et l'annonce est vendue (achat) ou fermée (échange)
+func completeTrade(tx *sql.Tx, o *lockedOffer) error {
+	if _, err := lockProducts(tx, o); err != nil {
+		return err
+	}
+
+	listingStatus := listingSold
+	_, err := tx.Exec("UPDATE products SET user_id = $1 WHERE id = $2", o.fromUserID, o.listingProductID)
+	if err == nil && len(o.proposedProducts) > 0 {
+		listingStatus = listingClosed
+		_, err = tx.Exec(
+			"UPDATE products SET user_id = $1 WHERE id = ANY($2)", o.listingOwnerID, pq.Array(o.proposedProducts),
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to transfer products: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE listings SET status = $1, updated_at = NOW() WHERE id = $2", listingStatus, o.listingID,
+	); err != nil {
+		return fmt.Errorf("failed to update listing: %w", err)
+	}
+	if _, err := tx.Exec(
+		"UPDATE offers SET status = $1, completed_at = NOW(), updated_at = NOW() WHERE id = $2", StatusCompleted, o.id,
+	); err != nil {
+		return fmt.Errorf("failed to complete offer: %w", err)
+	}
+	if _, err := tx.Exec(
+		"UPDATE users SET completed_trades = completed_trades + 1 WHERE id IN ($1, $2)",
+		o.fromUserID, o.listingOwnerID,
+	); err != nil {
+		return fmt.Errorf("failed to update completed trades: %w", err)
+	}
+	// Prix de vente de référence pour l'historique et les prix suggérés
+	if err := pricing.RecordSale(tx, o.id); err != nil {
+		return err
+	}
+	// La conversation entre les deux parties passe en lecture seule
+	if err := conversation.ArchiveTrade(tx, o.listingID, o.fromUserID); err != nil {
+		return err
+	}
+
+	productName, err := listingProductName(tx, o)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("L'échange de « %s » est terminé, vous pouvez évaluer l'autre partie", productName)
+	for _, userID := range []int{o.fromUserID, o.listingOwnerID} {
+		if err := notification.Notify(tx, userID, notification.TypeTradeCompleted, notification.ObjectOffer, o.id, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelTrade annule un échange sans transfert : l'annonce est remise en vente
+// pour au moins une durée de vie ttl. Les autres annonces fermées à
+// l'acceptation le restent.
+func cancelTrade(tx *sql.Tx, o *lockedOffer, ttl time.Duration) error {
+	if _, err := tx.Exec(
+		"UPDATE offers SET status = $1, updated_at = NOW() WHERE id = $2", StatusCancelled, o.id,
+	); err != nil {
+		return fmt.Errorf("failed to cancel offer: %w", err)
+	}
+	if _, err := tx.Exec(
+		`UPDATE listings
+		 SET status = $1, expires_at = GREATEST(expires_at, NOW() + make_interval(secs => $4)),
+		     reminded_at = NULL, updated_at = NOW()
+		 WHERE id = $2 AND status = $3`,
+		listingOpen, o.listingID, listingReserved, ttl.Seconds(),
+	); err != nil {
+		return fmt.Errorf("failed to reopen listing: %w", err)
+	}
+	return nil
+}
+
+// listingProductName retourne le nom du produit de l'annonce visée par une offre
+func listingProductName(tx *sql.Tx, o *lockedOffer) (string, error) {
+	var name string
+	if err := tx.QueryRow("SELECT name FROM products WHERE id = $1", o.listingProductID).Scan(&name); err != nil {
+		return "", fmt.Errorf("failed to get listing product: %w", err)
+	}
+	return name, nil
+}
+
+// RejectOffer refuse une offre (propriétaire) ou une contre-proposition (auteur de l'offre)
+func (s *Service) RejectOffer(offerID, userID int) (*models.OfferWithDetails, error) {
+	return s.transition(offerID, userID, StatusRejected, nil)
+}
+
+// WithdrawOffer retire une offre en cours
+func (s *Service) WithdrawOffer(offerID, userID int) (*models.OfferWithDetails, error) {
+	return s.transition(offerID, userID, StatusWithdrawn, nil)
 }
 
-// TODO: Implement methods based on doc_offer_handler.md
+// ShipOffer indique que le propriétaire de l'annonce a expédié ou remis le produit
+func (s *Service) ShipOffer(offerID, userID int) (*models.OfferWithDetails, error) {
+	return s.transition(offerID, userID, StatusShipped, func(tx *sql.Tx, o *lockedOffer) error {
+		if _, err := tx.Exec("UPDATE offers SET shipped_at = NOW() WHERE id = $1", o.id); err != nil {
+			return fmt.Errorf("failed to update offer: %w", err)
+		}
+
+		productName, err := listingProductName(tx, o)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("« %s » a été expédié ou remis, confirmez l'échange à réception", productName)
+		return notification.Notify(tx, o.fromUserID, notification.TypeTradeShipped, notification.ObjectOffer, o.id, message)
+	})
+}
+
+// ConfirmOffer enregistre la confirmation d'une partie après la remise du
+// produit ; l'échange est terminé quand les deux parties ont confirmé
+func (s *Service) ConfirmOffer(offerID, userID int) (*models.OfferWithDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	o, err := lockOffer(tx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	actor := o.actor(userID)
+	if actor == "" {
+		return nil, ErrOfferNotFound
+	}
+	// La fin de l'échange découle des deux confirmations, pas d'un acteur seul
+	if err := checkTransition(o.status, StatusCompleted, ActorSystem); err != nil {
+		return nil, err
+	}
+
+	column, counterpart := "owner_confirmed_at", o.fromUserID
+	confirmed, otherConfirmed := o.ownerConfirmed, o.offererConfirmed
+	if actor == ActorOfferer {
+		column, counterpart = "offerer_confirmed_at", o.listingOwnerID
+		confirmed, otherConfirmed = o.offererConfirmed, o.ownerConfirmed
+	}
+	if confirmed {
+		return nil, ErrAlreadyConfirmed
+	}
+	if _, err := tx.Exec("UPDATE offers SET "+column+" = NOW(), updated_at = NOW() WHERE id = $1", offerID); err != nil {
+		return nil, fmt.Errorf("failed to confirm offer: %w", err)
+	}
+
+	if otherConfirmed {
+		err = completeTrade(tx, o)
+	} else {
+		var productName string
+		productName, err = listingProductName(tx, o)
+		if err == nil {
+			message := fmt.Sprintf("L'autre partie a confirmé l'échange de « %s », confirmez à votre tour", productName)
+			err = notification.Notify(tx, counterpart, notification.TypeTradeConfirmed, notification.ObjectOffer, offerID, message)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit offer confirmation: %w", err)
+	}
+	return s.GetOffer(offerID, userID)
+}
+
+// CounterOffer ajoute un tour de négociation : le propriétaire de l'annonce
+// répond à une offre (countered), l'auteur de l'offre à une contre-proposition
+// (retour à pending). Les termes non précisés sont repris du tour précédent.
+func (s *Service) CounterOffer(offerID, userID int, req CounterOfferRequest) (*models.OfferWithDetails, error) {
+	message := strings.TrimSpace(req.Message)
+	if req.Amount == nil && req.ProposedProductIDs == nil && message == "" {
+		return nil, ErrEmptyRound
+	}
+	var requested []int
+	if req.ProposedProductIDs != nil {
+		var err error
+		if requested, err = bundleProducts(*req.ProposedProductIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	target := func(actor string) string {
+		if actor == ActorOfferer {
+			return StatusPending
+		}
+		return StatusCountered
+	}
+	return s.transitionFor(offerID, userID, target, func(tx *sql.Tx, o *lockedOffer) error {
+		if o.listingStatus != listingOpen {
+			return ErrListingNotOpen
+		}
+
+		var amount *int
+		if o.amount.Valid {
+			v := int(o.amount.Int32)
+			amount = &v
+		}
+		productIDs := o.proposedProducts
+		if req.Amount != nil {
+			amount = req.Amount
+		}
+		if req.ProposedProductIDs != nil {
+			productIDs = requested
+		}
+		if len(productIDs) == 0 && amount == nil {
+			return ErrNoTerms
+		}
+		// Les produits échangés appartiennent toujours à l'auteur de l'offre
+		if err := checkProducts(tx, productIDs, o.fromUserID); err != nil {
+			return err
+		}
+
+		if err := setProducts(tx, o.id, productIDs); err != nil {
+			return err
+		}
+		if err := addRound(tx, o.id, userID, amount, productIDs, message); err != nil {
+			return err
+		}
+
+		// La partie qui doit répondre dispose au moins de la validité par défaut
+		_, err := tx.Exec(`
+			UPDATE offers
+			SET amount = $1, reminded_at = NULL,
+			    expires_at = GREATEST(expires_at, NOW() + make_interval(secs => $2))
+			WHERE id = $3
+		`, amount, s.cfg.OfferTTL.Seconds(), o.id)
+		if err != nil {
+			return fmt.Errorf("failed to update offer terms: %w", err)
+		}
+
+		recipient := o.fromUserID
+		if userID == o.fromUserID {
+			recipient = o.listingOwnerID
+		}
+		return notification.Notify(tx, recipient, notification.TypeOfferCountered, notification.ObjectOffer, o.id,
+			"Une nouvelle proposition a été faite sur votre négociation")
+	})
+}
+
+// ExpireDue passe à expired les offres en cours dont l'échéance est passée et
+// prévient les deux parties
+func (s *Service) ExpireDue() (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Transition réservée aux tâches planifiées (ActorSystem) depuis chaque statut en cours
+	rows, err := tx.Query(`
+		UPDATE offers o SET status = $1, updated_at = NOW()
+		FROM listings l, products p
+		WHERE l.id = o.listing_id AND p.id = l.product_id
+		  AND o.status = ANY($2) AND o.expires_at <= NOW()
+		RETURNING o.id, o.from_user_id, l.user_id, p.name
+	`, StatusExpired, pq.Array(activeStatuses))
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire offers: %w", err)
+	}
+
+	type expiredOffer struct {
+		id, offererID, ownerID int
+		productName            string
+	}
+	expired := []expiredOffer{}
+	for rows.Next() {
+		var e expiredOffer
+		if err := rows.Scan(&e.id, &e.offererID, &e.ownerID, &e.productName); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired offer: %w", err)
+		}
+		expired = append(expired, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to expire offers: %w", err)
+	}
+
+	for _, e := range expired {
+		message := fmt.Sprintf("L'offre sur « %s » a expiré sans réponse", e.productName)
+		for _, userID := range []int{e.offererID, e.ownerID} {
+			if err := notification.Notify(tx, userID, notification.TypeOfferExpired, notification.ObjectOffer, e.id, message); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit offer expiration: %w", err)
+	}
+	return len(expired), nil
+}
+
+// SendReminders prévient la partie qui doit répondre à une offre proche de son
+// échéance : le propriétaire de l'annonce, ou l'auteur d'une offre contre-proposée
+func (s *Service) SendReminders() (int, error) {
+	if s.cfg.OfferReminder <= 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE offers o SET reminded_at = NOW()
+		FROM listings l, products p
+		WHERE l.id = o.listing_id AND p.id = l.product_id
+		  AND o.status = ANY($1) AND o.reminded_at IS NULL
+		  AND o.expires_at > NOW() AND o.expires_at <= NOW() + make_interval(secs => $2)
+		RETURNING o.id, CASE WHEN o.status = $3 THEN o.from_user_id ELSE l.user_id END, p.name
+	`, pq.Array(activeStatuses), s.cfg.OfferReminder.Seconds(), StatusCountered)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select offers to remind: %w", err)
+	}
+
+	type reminder struct {
+		id, userID  int
+		productName string
+	}
+	reminders := []reminder{}
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.id, &r.userID, &r.productName); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan offer to remind: %w", err)
+		}
+		reminders = append(reminders, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to select offers to remind: %w", err)
+	}
+
+	for _, r := range reminders {
+		message := fmt.Sprintf("Une offre sur « %s » attend votre réponse et expire bientôt", r.productName)
+		if err := notification.Notify(tx, r.userID, notification.TypeOfferExpiring, notification.ObjectOffer, r.id, message); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit offer reminders: %w", err)
+	}
+	return len(reminders), nil
+}
+
+// RunExpiry expire périodiquement les offres échues et envoie les rappels
+func (s *Service) RunExpiry(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if n, err := s.SendReminders(); err != nil {
+			utils.LogError(fmt.Sprintf("Offer expiry: %v", err))
+		} else if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Offer expiry: %d rappel(s) envoyé(s)", n))
+		}
+
+		n, err := s.ExpireDue()
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Offer expiry: %v", err))
+			continue
+		}
+		if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Offer expiry: %d offre(s) expirée(s)", n))
+		}
+	}
+}
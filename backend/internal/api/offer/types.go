@@ -0,0 +1,146 @@
+package offer
+
+import (
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+const (
+	// Statuts d'une offre
+	StatusPending   = "pending"
+	StatusAccepted  = "accepted"
+	StatusRejected  = "rejected"
+	StatusWithdrawn = "withdrawn"
+	StatusCountered = "countered"
+	StatusExpired   = "expired"
+	StatusShipped   = "shipped"
+	StatusCompleted = "completed"
+	StatusDisputed  = "disputed"
+	StatusCancelled = "cancelled"
+
+	// Acteurs autorisés à faire évoluer une offre
+	ActorOwner   = "owner"   // propriétaire de l'annonce
+	ActorOfferer = "offerer" // auteur de l'offre
+	ActorSystem  = "system"  // tâches planifiées et confirmation des deux parties
+	ActorParty   = "party"   // l'une ou l'autre partie
+	ActorAdmin   = "admin"   // résolution des litiges
+
+	// Statuts d'annonce utilisés au fil d'un échange
+	listingOpen     = "open"
+	listingReserved = "reserved"
+	listingSold     = "sold"
+	listingClosed   = "closed"
+
+	// Rôle de l'utilisateur évalué dans un échange
+	RoleSeller = "seller" // propriétaire de l'annonce
+	RoleBuyer  = "buyer"  // auteur de l'offre
+
+	// MaxBundleProducts est le nombre maximal de produits proposés dans une offre
+	MaxBundleProducts = 10
+)
+
+// transitions associe à chaque statut les statuts atteignables et l'acteur
+// autorisé à effectuer le changement. Les statuts absents sont définitifs.
+var transitions = map[string]map[string]string{
+	StatusPending: {
+		StatusAccepted:  ActorOwner,
+		StatusRejected:  ActorOwner,
+		StatusCountered: ActorOwner,
+		StatusWithdrawn: ActorOfferer,
+		StatusExpired:   ActorSystem,
+	},
+	// Une contre-proposition attend la réponse de l'auteur de l'offre, qui peut
+	// à son tour proposer de nouveaux termes (retour à pending)
+	StatusCountered: {
+		StatusPending:   ActorOfferer,
+		StatusAccepted:  ActorOfferer,
+		StatusRejected:  ActorOfferer,
+		StatusWithdrawn: ActorOfferer,
+		StatusExpired:   ActorSystem,
+	},
+	// Une offre acceptée ouvre l'échange : le propriétaire remet le produit, puis
+	// l'échange se termine quand les deux parties l'ont confirmé. Chaque partie
+	// peut ouvrir un litige, tranché par un administrateur.
+	StatusAccepted: {
+		StatusShipped:  ActorOwner,
+		StatusDisputed: ActorParty,
+	},
+	StatusShipped: {
+		StatusCompleted: ActorSystem,
+		StatusDisputed:  ActorParty,
+	},
+	StatusDisputed: {
+		StatusCompleted: ActorAdmin,
+		StatusCancelled: ActorAdmin,
+	},
+}
+
+// activeStatuses sont les statuts d'une offre encore en cours
+var activeStatuses = []string{StatusPending, StatusCountered}
+
+// tradeStatuses sont les statuts d'un échange accepté mais pas encore terminé
+var tradeStatuses = []string{StatusAccepted, StatusShipped, StatusDisputed}
+
+const (
+	// Statuts et décisions d'un litige
+	DisputeOpen        = "open"
+	DisputeResolved    = "resolved"
+	ResolutionComplete = "complete" // l'échange est mené à terme
+	ResolutionCancel   = "cancel"   // l'échange est annulé, l'annonce est remise en vente
+
+	// MaxEvidence est le nombre maximal de photos jointes à un litige
+	MaxEvidence = 10
+
+	evidenceMaxSize     = 10 << 20 // 10MB
+	evidenceURLFmt      = "/api/v1/offers/%d/dispute/evidence/%d"
+	adminEvidenceURLFmt = "/api/v1/admin/disputes/%d/evidence/%d"
+)
+
+// evidenceFormats associe un format d'image accepté à son type MIME et son extension
+var evidenceFormats = map[string]struct{ contentType, ext string }{
+	media.FormatJPEG: {"image/jpeg", ".jpg"},
+	media.FormatPNG:  {"image/png", ".png"},
+	media.FormatWebP: {"image/webp", ".webp"},
+}
+
+// CreateOfferRequest represents an offer on a listing: an exchange against some of
+// the offerer's products, optionally with a cash amount, or a purchase at the
+// listing price when neither products nor amount are given
+type CreateOfferRequest struct {
+	ProposedProductIDs []int      `json:"proposed_product_ids"`
+	Amount             *int       `json:"amount" binding:"omitempty,min=0"` // prix de l'annonce si ni produit ni montant
+	Message            string     `json:"message" binding:"max=2000"`
+	ExpiresAt          *time.Time `json:"expires_at"` // validité par défaut si absent
+}
+
+// CounterOfferRequest represents a new negotiation round. Omitted terms are kept
+// from the previous round; an empty proposed_product_ids removes the swap products.
+type CounterOfferRequest struct {
+	Amount             *int   `json:"amount" binding:"omitempty,min=0"`
+	ProposedProductIDs *[]int `json:"proposed_product_ids"`
+	Message            string `json:"message" binding:"max=2000"`
+}
+
+// AcceptOfferRequest optionally names the round being accepted, which must be
+// the latest one
+type AcceptOfferRequest struct {
+	RoundID int `json:"round_id"`
+}
+
+// RateTradeRequest represents the rating of the other party of a completed trade
+type RateTradeRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// OpenDisputeRequest represents a dispute opened by a party of a trade
+type OpenDisputeRequest struct {
+	Reason string `json:"reason" binding:"required,max=5000"`
+}
+
+// ResolveDisputeRequest represents an admin decision on a dispute
+type ResolveDisputeRequest struct {
+	Resolution string `json:"resolution" binding:"required"` // complete or cancel
+	Note       string `json:"note" binding:"max=5000"`
+}
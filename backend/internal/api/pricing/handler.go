@@ -0,0 +1,72 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidState):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// GetHistory retourne l'historique des prix de vente d'un produit (state, limit)
+func (h *Handler) GetHistory(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistorySales)))
+	if limit < 1 || limit > MaxHistorySales {
+		limit = defaultHistorySales
+	}
+
+	history, err := h.service.GetHistory(productID, c.Query("state"), limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve price history")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, history, "Price history retrieved successfully")
+}
+
+// SuggestPrice propose une fourchette de prix pour mettre un produit en vente (state requis)
+func (h *Handler) SuggestPrice(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	suggestion, err := h.service.SuggestPrice(productID, c.Query("state"))
+	if err != nil {
+		writeError(c, err, "Failed to suggest a price")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, suggestion, "Price suggestion retrieved successfully")
+}
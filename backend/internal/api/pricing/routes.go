@@ -0,0 +1,15 @@
+package pricing
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler) {
+	products := router.Group("/products")
+
+	// Routes publiques : prix constatés, pour la fiche produit et le formulaire d'annonce
+	{
+		products.GET("/:id/price-history", handler.GetHistory)
+		products.GET("/:id/price-suggestion", handler.SuggestPrice)
+	}
+}
@@ -0,0 +1,181 @@
+package pricing
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/okinrev/veza-web-app/internal/config"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidState    = errors.New("state must be one of new, like_new, good, fair, poor")
+)
+
+// comparable indique si la vente h porte sur le même produit du catalogue que
+// le produit p : même marque et même modèle, ou même nom pour un produit sans modèle
+const comparable = `
+	CASE WHEN COALESCE(p.model, '') <> ''
+	     THEN LOWER(h.brand) = LOWER(COALESCE(p.brand, '')) AND LOWER(h.model) = LOWER(p.model)
+	     ELSE h.model = '' AND LOWER(h.name) = LOWER(p.name)
+	END
+`
+
+type Service struct {
+	db  *database.DB
+	cfg config.MarketplaceConfig
+}
+
+func NewService(db *database.DB, cfg config.MarketplaceConfig) *Service {
+	return &Service{db: db, cfg: cfg}
+}
+
+// RecordSale enregistre, dans la transaction de fin d'un échange, le prix payé
+// pour le produit de l'annonce. Les échanges sans montant ou contre des produits
+// ne donnent pas de prix de vente.
+func RecordSale(tx *sql.Tx, offerID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO price_history (offer_id, listing_id, product_id, name, brand, model, category_id, state, price)
+		SELECT o.id, l.id, p.id, p.name, COALESCE(p.brand, ''), COALESCE(p.model, ''), p.category_id, l.state, o.amount
+		FROM offers o
+		JOIN listings l ON l.id = o.listing_id
+		JOIN products p ON p.id = l.product_id
+		WHERE o.id = $1 AND o.amount IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM offer_products op WHERE op.offer_id = o.id)
+		ON CONFLICT (offer_id) DO NOTHING
+	`, offerID)
+	if err != nil {
+		return fmt.Errorf("failed to record sale price: %w", err)
+	}
+	return nil
+}
+
+// checkProduct vérifie que le produit existe
+func (s *Service) checkProduct(productID int) error {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check product: %w", err)
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// GetHistory retourne les prix de vente du produit du catalogue correspondant à
+// un produit : statistiques par état et ventes les plus récentes (state filtre
+// sur un état)
+func (s *Service) GetHistory(productID int, state string, limit int) (*models.PriceHistory, error) {
+	if state != "" && !validStates[state] {
+		return nil, ErrInvalidState
+	}
+
+	history := models.PriceHistory{
+		ProductID:  productID,
+		Conditions: []models.PriceStats{},
+		Sales:      []models.PriceSale{},
+	}
+	err := s.db.QueryRow(
+		"SELECT name, NULLIF(brand, ''), NULLIF(model, '') FROM products WHERE id = $1", productID,
+	).Scan(&history.Name, &history.Brand, &history.Model)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT h.state, COUNT(*), MIN(h.price), MAX(h.price), AVG(h.price),
+		       ROUND(percentile_cont(0.5) WITHIN GROUP (ORDER BY h.price))::int, MAX(h.sold_at)
+		FROM price_history h, products p
+		WHERE p.id = $1 AND ($2 = '' OR h.state = $2) AND `+comparable+`
+		GROUP BY h.state
+		ORDER BY array_position($3, h.state)
+	`, productID, state, pq.Array(conditions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve price statistics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st models.PriceStats
+		if err := rows.Scan(&st.State, &st.SaleCount, &st.MinPrice, &st.MaxPrice, &st.Average, &st.Median, &st.LastSoldAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price statistics: %w", err)
+		}
+		history.Conditions = append(history.Conditions, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve price statistics: %w", err)
+	}
+
+	sales, err := s.db.Query(`
+		SELECT h.id, COALESCE(h.listing_id, 0), h.state, h.price, h.sold_at
+		FROM price_history h, products p
+		WHERE p.id = $1 AND ($2 = '' OR h.state = $2) AND `+comparable+`
+		ORDER BY h.sold_at DESC, h.id DESC
+		LIMIT $3
+	`, productID, state, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+	defer sales.Close()
+
+	for sales.Next() {
+		var sale models.PriceSale
+		if err := sales.Scan(&sale.ID, &sale.ListingID, &sale.State, &sale.Price, &sale.SoldAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sale: %w", err)
+		}
+		history.Sales = append(history.Sales, sale)
+	}
+	if err := sales.Err(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+	return &history, nil
+}
+
+// SuggestPrice propose une fourchette de prix pour un produit dans un état
+// donné : du premier au troisième quartile des ventes comparables récentes
+func (s *Service) SuggestPrice(productID int, state string) (*models.PriceSuggestion, error) {
+	if !validStates[state] {
+		return nil, ErrInvalidState
+	}
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	suggestion := models.PriceSuggestion{
+		ProductID:  productID,
+		State:      state,
+		WindowDays: int(s.cfg.PriceWindow / (24 * time.Hour)),
+	}
+	var low, median, high sql.NullInt32
+	err := s.db.QueryRow(`
+		SELECT COUNT(*),
+		       ROUND(percentile_cont(0.25) WITHIN GROUP (ORDER BY h.price))::int,
+		       ROUND(percentile_cont(0.5) WITHIN GROUP (ORDER BY h.price))::int,
+		       ROUND(percentile_cont(0.75) WITHIN GROUP (ORDER BY h.price))::int
+		FROM price_history h, products p
+		WHERE p.id = $1 AND h.state = $2 AND h.sold_at >= NOW() - make_interval(secs => $3) AND `+comparable+`
+	`, productID, state, s.cfg.PriceWindow.Seconds()).Scan(&suggestion.SampleSize, &low, &median, &high)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute suggested price: %w", err)
+	}
+
+	// Trop peu de ventes pour une fourchette fiable
+	if suggestion.SampleSize < s.cfg.PriceMinSales || !median.Valid {
+		return &suggestion, nil
+	}
+	suggestion.Available = true
+	suggestion.Low = int(low.Int32)
+	suggestion.Median = int(median.Int32)
+	suggestion.High = int(high.Int32)
+	return &suggestion, nil
+}
@@ -0,0 +1,21 @@
+package pricing
+
+const (
+	// MaxHistorySales est le nombre maximal de ventes renvoyées par l'historique
+	MaxHistorySales = 100
+
+	// defaultHistorySales est le nombre de ventes renvoyées par défaut
+	defaultHistorySales = 20
+)
+
+// conditions liste les états des annonces, du meilleur au moins bon
+var conditions = []string{"new", "like_new", "good", "fair", "poor"}
+
+// validStates reprend les états acceptés pour les annonces
+var validStates = map[string]bool{
+	"new":      true,
+	"like_new": true,
+	"good":     true,
+	"fair":     true,
+	"poor":     true,
+}
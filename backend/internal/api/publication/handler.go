@@ -0,0 +1,99 @@
+package publication
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListScheduled liste les publications programmées de l'utilisateur connecté
+func (h *Handler) ListScheduled(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	publications, err := h.service.ListScheduled(userID)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to retrieve scheduled publications", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, publications, "Scheduled publications retrieved")
+}
+
+// SchedulePublication programme la publication d'une piste ou d'une ressource
+func (h *Handler) SchedulePublication(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	objectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid content ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SchedulePublication(userID, c.Param("type"), objectID, req.PublishAt); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, gin.H{"publish_at": req.PublishAt}, "Publication scheduled successfully")
+}
+
+// CancelPublication annule une publication programmée
+func (h *Handler) CancelPublication(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	objectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid content ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CancelPublication(userID, c.Param("type"), objectID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Publication cancelled successfully")
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotOwner):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrUnknownObjectType), errors.Is(err, ErrPublishAtInPast), errors.Is(err, ErrNotScheduled):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		response.ErrorJSON(c.Writer, "Failed to update publication", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,19 @@
+package publication
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	publications := router.Group("/publications")
+
+	// Routes protégées
+	protected := publications.Group("")
+	protected.Use(middleware.JWTAuthMiddleware(jwtSecret))
+	{
+		protected.GET("", handler.ListScheduled)
+		protected.PUT("/:type/:id", handler.SchedulePublication)
+		protected.DELETE("/:type/:id", handler.CancelPublication)
+	}
+}
@@ -0,0 +1,199 @@
+package publication
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/api/activity"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrUnknownObjectType = errors.New("unknown object type")
+	ErrNotFound          = errors.New("content not found")
+	ErrNotOwner          = errors.New("not authorized to schedule this content")
+	ErrPublishAtInPast   = errors.New("publish_at must be in the future")
+	ErrNotScheduled      = errors.New("no publication scheduled for this content")
+)
+
+var targets = map[string]target{
+	ObjectTrack:          {table: "tracks", activityType: activity.TypeTrackPublished},
+	ObjectSharedResource: {table: "shared_ressources", activityType: activity.TypeResourcePublished},
+}
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// checkOwner vérifie que l'objet existe et appartient à l'utilisateur
+func (s *Service) checkOwner(t target, objectID, userID int) (sql.NullTime, error) {
+	var ownerID int
+	var publishAt sql.NullTime
+	err := s.db.QueryRow(
+		"SELECT uploader_id, publish_at FROM "+t.table+" WHERE id = $1", objectID,
+	).Scan(&ownerID, &publishAt)
+	if err == sql.ErrNoRows {
+		return publishAt, ErrNotFound
+	}
+	if err != nil {
+		return publishAt, fmt.Errorf("failed to get content: %w", err)
+	}
+	if ownerID != userID {
+		return publishAt, ErrNotOwner
+	}
+	return publishAt, nil
+}
+
+// SchedulePublication rend le contenu privé jusqu'à publishAt
+func (s *Service) SchedulePublication(userID int, objectType string, objectID int, publishAt time.Time) error {
+	t, ok := targets[objectType]
+	if !ok {
+		return ErrUnknownObjectType
+	}
+	if !publishAt.After(time.Now()) {
+		return ErrPublishAtInPast
+	}
+	if _, err := s.checkOwner(t, objectID, userID); err != nil {
+		return err
+	}
+
+	// publish_at est un TIMESTAMP sans fuseau : la date est enregistrée en UTC
+	_, err := s.db.Exec(
+		"UPDATE "+t.table+" SET is_public = false, publish_at = $1 WHERE id = $2",
+		publishAt.UTC(), objectID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule publication: %w", err)
+	}
+	return nil
+}
+
+// CancelPublication annule une publication programmée, le contenu reste privé
+func (s *Service) CancelPublication(userID int, objectType string, objectID int) error {
+	t, ok := targets[objectType]
+	if !ok {
+		return ErrUnknownObjectType
+	}
+	publishAt, err := s.checkOwner(t, objectID, userID)
+	if err != nil {
+		return err
+	}
+	if !publishAt.Valid {
+		return ErrNotScheduled
+	}
+
+	_, err = s.db.Exec("UPDATE "+t.table+" SET publish_at = NULL WHERE id = $1", objectID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel publication: %w", err)
+	}
+	return nil
+}
+
+// ListScheduled retourne les publications à venir d'un utilisateur
+func (s *Service) ListScheduled(userID int) ([]ScheduledPublication, error) {
+	rows, err := s.db.Query(`
+		SELECT 'track', id, title, publish_at
+		FROM tracks
+		WHERE uploader_id = $1 AND publish_at IS NOT NULL
+		UNION ALL
+		SELECT 'shared_resource', id, title, publish_at
+		FROM shared_ressources
+		WHERE uploader_id = $1 AND publish_at IS NOT NULL
+		ORDER BY publish_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve scheduled publications: %w", err)
+	}
+	defer rows.Close()
+
+	publications := []ScheduledPublication{}
+	for rows.Next() {
+		var p ScheduledPublication
+		if err := rows.Scan(&p.ObjectType, &p.ObjectID, &p.Title, &p.PublishAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled publication: %w", err)
+		}
+		publications = append(publications, p)
+	}
+	return publications, rows.Err()
+}
+
+// PublishDue publie les contenus dont la date de publication est passée
+func (s *Service) PublishDue() (int, error) {
+	published := 0
+	for objectType, t := range targets {
+		n, err := s.publishDueFor(objectType, t)
+		if err != nil {
+			return published, err
+		}
+		published += n
+	}
+	return published, nil
+}
+
+func (s *Service) publishDueFor(objectType string, t target) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE ` + t.table + `
+		SET is_public = true, publish_at = NULL
+		WHERE publish_at IS NOT NULL AND publish_at <= NOW()
+		RETURNING id, uploader_id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish %s: %w", t.table, err)
+	}
+
+	type publishedRow struct{ id, uploaderID int }
+	var publishedRows []publishedRow
+	for rows.Next() {
+		var r publishedRow
+		if err := rows.Scan(&r.id, &r.uploaderID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan published %s: %w", t.table, err)
+		}
+		publishedRows = append(publishedRows, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range publishedRows {
+		if err := activity.Record(tx, r.uploaderID, t.activityType, objectType, r.id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit publication: %w", err)
+	}
+	return len(publishedRows), nil
+}
+
+// RunScheduler publie périodiquement les contenus arrivés à échéance
+func (s *Service) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		n, err := s.PublishDue()
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Publication scheduler: %v", err))
+			continue
+		}
+		if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Publication scheduler: %d contenu(s) publié(s)", n))
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package publication
+
+import (
+	"time"
+)
+
+// Types d'objets publiables
+const (
+	ObjectTrack          = "track"
+	ObjectSharedResource = "shared_resource"
+)
+
+// ScheduledPublication represents an upcoming publication owned by a user
+type ScheduledPublication struct {
+	ObjectType string    `json:"object_type"`
+	ObjectID   int       `json:"object_id"`
+	Title      string    `json:"title"`
+	PublishAt  time.Time `json:"publish_at"`
+}
+
+// ScheduleRequest represents a request to schedule a publication
+type ScheduleRequest struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"`
+}
+
+// target décrit la table associée à un type d'objet publiable
+type target struct {
+	table        string
+	activityType string
+}
@@ -0,0 +1,170 @@
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
+	"github.com/okinrev/veza-web-app/internal/api/track"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, shared_resources.ErrResourceNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotPending):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, quota.ErrQuotaExceeded), errors.Is(err, track.ErrAudioTooLarge),
+		errors.Is(err, shared_resources.ErrFileTooLarge):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, security.ErrContentMismatch):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrInvalidTarget),
+		errors.Is(err, shared_resources.ErrNotOwner), errors.Is(err, shared_resources.ErrUnknownResourceType),
+		errors.Is(err, shared_resources.ErrUnsupportedFile), errors.Is(err, shared_resources.ErrInvalidArchive),
+		errors.Is(err, shared_resources.ErrArchiveTooLarge), errors.Is(err, shared_resources.ErrUnsafeArchivePath),
+		errors.Is(err, shared_resources.ErrInvalidMIDI), errors.Is(err, shared_resources.ErrInvalidSample),
+		errors.Is(err, track.ErrUnsupportedAudio):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+func fileID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid quarantined file ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// ListFiles liste les fichiers en quarantaine, par défaut ceux en attente d'examen
+func (h *Handler) ListFiles(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	files, total, err := h.service.ListFiles(c.DefaultQuery("status", security.QuarantinePending), page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve quarantined files")
+		return
+	}
+
+	response.PaginatedJSON(c.Writer, files, &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}, "Quarantined files retrieved successfully")
+}
+
+// GetFile retourne le détail d'un fichier en quarantaine
+func (h *Handler) GetFile(c *gin.Context) {
+	id, ok := fileID(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.service.GetFile(id)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve quarantined file")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, file, "Quarantined file retrieved successfully")
+}
+
+// DownloadFile permet à un administrateur de récupérer le fichier pour l'examiner
+func (h *Handler) DownloadFile(c *gin.Context) {
+	id, ok := fileID(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.service.GetFile(id)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve quarantined file")
+		return
+	}
+	path, err := h.service.FilePath(file)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve quarantined file")
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		response.ErrorJSON(c.Writer, "File not found", http.StatusNotFound)
+		return
+	}
+
+	// Jamais servi en ligne : le contenu est potentiellement malveillant
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.FileAttachment(path, file.OriginalFilename)
+}
+
+// ReleaseFile libère un fichier et reprend la création soumise par l'utilisateur
+func (h *Handler) ReleaseFile(c *gin.Context) {
+	id, ok := fileID(c)
+	if !ok {
+		return
+	}
+	adminID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.service.ReleaseFile(id, adminID)
+	if err != nil {
+		writeError(c, err, "Failed to release quarantined file")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, result, "Quarantined file released successfully")
+}
+
+// DeleteFile supprime définitivement un fichier en quarantaine
+func (h *Handler) DeleteFile(c *gin.Context) {
+	id, ok := fileID(c)
+	if !ok {
+		return
+	}
+	adminID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteFile(id, adminID); err != nil {
+		writeError(c, err, "Failed to delete quarantined file")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Quarantined file deleted successfully")
+}
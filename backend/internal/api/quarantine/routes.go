@@ -0,0 +1,20 @@
+package quarantine
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	// Examen des fichiers suspects, réservé aux administrateurs
+	quarantine := router.Group("/admin/quarantine")
+	quarantine.Use(middleware.JWTAuthMiddleware(jwtSecret))
+	quarantine.Use(middleware.AdminMiddleware())
+	{
+		quarantine.GET("", handler.ListFiles)
+		quarantine.GET("/:id", handler.GetFile)
+		quarantine.GET("/:id/download", handler.DownloadFile)
+		quarantine.POST("/:id/release", handler.ReleaseFile)
+		quarantine.DELETE("/:id", handler.DeleteFile)
+	}
+}
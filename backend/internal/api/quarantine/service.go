@@ -0,0 +1,262 @@
+package quarantine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
+	"github.com/okinrev/veza-web-app/internal/api/track"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrNotFound      = errors.New("quarantined file not found")
+	ErrNotPending    = errors.New("quarantined file has already been reviewed")
+	ErrInvalidStatus = errors.New("invalid status")
+	ErrInvalidTarget = errors.New("unknown submission target")
+)
+
+type Service struct {
+	db        *database.DB
+	inspector *security.Inspector
+	resources *shared_resources.Service
+	tracks    *track.Service
+}
+
+func NewService(db *database.DB, inspector *security.Inspector, resources *shared_resources.Service, tracks *track.Service) *Service {
+	return &Service{
+		db:        db,
+		inspector: inspector,
+		resources: resources,
+		tracks:    tracks,
+	}
+}
+
+const selectQuarantined = `
+	SELECT q.id, q.user_id, COALESCE(u.username, ''), q.target, q.original_filename, q.stored_filename,
+	       q.detected_kind, q.reason, q.metadata, q.status, q.reviewed_by, q.reviewed_at, q.created_at
+	FROM quarantined_files q
+	LEFT JOIN users u ON u.id = q.user_id
+`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuarantined(row scanner) (models.QuarantinedFile, error) {
+	var f models.QuarantinedFile
+	var metadata []byte
+	err := row.Scan(
+		&f.ID, &f.UserID, &f.Username, &f.Target, &f.OriginalFilename, &f.StoredFilename,
+		&f.DetectedKind, &f.Reason, &metadata, &f.Status, &f.ReviewedBy, &f.ReviewedAt, &f.CreatedAt,
+	)
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(metadata, &f.Metadata); err != nil {
+		return f, fmt.Errorf("failed to decode submission metadata: %w", err)
+	}
+	return f, nil
+}
+
+// ListFiles liste les fichiers en quarantaine ayant le statut donné
+func (s *Service) ListFiles(status string, page, limit int) ([]models.QuarantinedFile, int, error) {
+	if !statuses[status] {
+		return nil, 0, ErrInvalidStatus
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM quarantined_files WHERE status = $1", status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count quarantined files: %w", err)
+	}
+
+	rows, err := s.db.Query(selectQuarantined+`
+		WHERE q.status = $1
+		ORDER BY q.created_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve quarantined files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.QuarantinedFile{}
+	for rows.Next() {
+		f, err := scanQuarantined(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan quarantined file: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, total, rows.Err()
+}
+
+// GetFile retourne un fichier en quarantaine
+func (s *Service) GetFile(id int) (*models.QuarantinedFile, error) {
+	f, err := scanQuarantined(s.db.QueryRow(selectQuarantined+" WHERE q.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined file: %w", err)
+	}
+	return &f, nil
+}
+
+// FilePath retourne le chemin d'un fichier en attente d'examen
+func (s *Service) FilePath(f *models.QuarantinedFile) (string, error) {
+	if f.Status != security.QuarantinePending {
+		return "", ErrNotPending
+	}
+	return s.inspector.QuarantinePath(f.StoredFilename), nil
+}
+
+// claim réserve un fichier en attente pour un examen, afin que deux
+// administrateurs ne le traitent pas en même temps
+func (s *Service) claim(id, adminID int, status string) (*models.QuarantinedFile, error) {
+	f, err := s.GetFile(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE quarantined_files
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3 AND status = $4
+	`, status, adminID, id, security.QuarantinePending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update quarantined file: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrNotPending
+	}
+	return f, nil
+}
+
+// unclaim remet un fichier en attente après l'échec de sa libération
+func (s *Service) unclaim(id int) {
+	_, err := s.db.Exec(`
+		UPDATE quarantined_files
+		SET status = $1, reviewed_by = NULL, reviewed_at = NULL
+		WHERE id = $2
+	`, security.QuarantinePending, id)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to reset quarantined file %d: %v", id, err))
+	}
+}
+
+// ReleaseFile libère un fichier après examen : la création interrompue est
+// reprise avec les informations soumises par l'utilisateur
+func (s *Service) ReleaseFile(id, adminID int) (*ReleaseResult, error) {
+	f, err := s.claim(id, adminID, security.QuarantineReleased)
+	if err != nil {
+		return nil, err
+	}
+
+	// La création consomme une copie : l'original reste en quarantaine si elle échoue
+	src := s.inspector.QuarantinePath(f.StoredFilename)
+	copyPath := src + ".release"
+	if err := utils.CopyFile(src, copyPath); err != nil {
+		s.unclaim(id)
+		return nil, err
+	}
+
+	resultID, err := s.handOff(f, copyPath)
+	if err != nil {
+		os.Remove(copyPath)
+		s.unclaim(id)
+		return nil, err
+	}
+
+	os.Remove(src)
+	utils.LogInfo(fmt.Sprintf("Quarantined file %d released by admin %d", id, adminID))
+	return &ReleaseResult{Target: f.Target, ResultID: resultID}, nil
+}
+
+// handOff reprend la création correspondant à la cible de la soumission
+func (s *Service) handOff(f *models.QuarantinedFile, path string) (int, error) {
+	meta := f.Metadata
+	tags := []string{}
+	for _, tag := range strings.Split(meta["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	isPublic := meta["is_public"] != "false"
+
+	switch f.Target {
+	case security.TargetTrack:
+		t, err := s.tracks.CreateTrackFromFile(track.CreateTrackRequest{
+			Title:      meta["title"],
+			Artist:     meta["artist"],
+			Tags:       tags,
+			IsPublic:   isPublic,
+			UploaderID: f.UserID,
+			Reviewed:   true,
+		}, path, f.OriginalFilename)
+		if err != nil {
+			return 0, err
+		}
+		return t.ID, nil
+
+	case security.TargetSharedResource:
+		req := shared_resources.CreateSharedResourceRequest{
+			Title:        meta["title"],
+			Type:         meta["type"],
+			Description:  meta["description"],
+			Tags:         tags,
+			IsPublic:     isPublic,
+			UploaderID:   f.UserID,
+			ReleaseNotes: meta["release_notes"],
+			Reviewed:     true,
+		}
+		// Une publication programmée déjà échue devient immédiate
+		if publishAt, err := time.Parse(time.RFC3339, meta["publish_at"]); err == nil && publishAt.After(time.Now()) {
+			req.PublishAt = &publishAt
+		}
+		r, err := s.resources.CreateResourceFromFile(req, path, f.OriginalFilename)
+		if err != nil {
+			return 0, err
+		}
+		return r.ID, nil
+
+	case security.TargetSharedResourceVersion:
+		resourceID, err := strconv.Atoi(meta["resource_id"])
+		if err != nil {
+			return 0, ErrInvalidTarget
+		}
+		v, err := s.resources.CreateVersionFromFile(shared_resources.CreateVersionRequest{
+			ResourceID:   resourceID,
+			UserID:       f.UserID,
+			ReleaseNotes: meta["release_notes"],
+			Reviewed:     true,
+		}, path, f.OriginalFilename)
+		if err != nil {
+			return 0, err
+		}
+		return v.ResourceID, nil
+	}
+	return 0, ErrInvalidTarget
+}
+
+// DeleteFile supprime définitivement un fichier en quarantaine
+func (s *Service) DeleteFile(id, adminID int) error {
+	f, err := s.claim(id, adminID, security.QuarantineDeleted)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.inspector.QuarantinePath(f.StoredFilename)); err != nil && !os.IsNotExist(err) {
+		utils.LogError(fmt.Sprintf("Failed to delete quarantined file %d: %v", id, err))
+	}
+	utils.LogInfo(fmt.Sprintf("Quarantined file %d deleted by admin %d", id, adminID))
+	return nil
+}
@@ -0,0 +1,16 @@
+package quarantine
+
+import "github.com/okinrev/veza-web-app/internal/security"
+
+// statuses liste les statuts acceptés par le filtre de la liste
+var statuses = map[string]bool{
+	security.QuarantinePending:  true,
+	security.QuarantineReleased: true,
+	security.QuarantineDeleted:  true,
+}
+
+// ReleaseResult represents the content created when a quarantined file is released
+type ReleaseResult struct {
+	Target   string `json:"target"`
+	ResultID int    `json:"result_id"`
+}
@@ -1,40 +1,66 @@
 package api
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/okinrev/veza-web-app/internal/blob"
 	"github.com/okinrev/veza-web-app/internal/config"
 	"github.com/okinrev/veza-web-app/internal/database"
 	"github.com/okinrev/veza-web-app/internal/middleware"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/websocket"
 
+	"github.com/okinrev/veza-web-app/internal/api/activity"
 	"github.com/okinrev/veza-web-app/internal/api/admin"
 	"github.com/okinrev/veza-web-app/internal/api/auth"
 	"github.com/okinrev/veza-web-app/internal/api/chat"
+	"github.com/okinrev/veza-web-app/internal/api/collection"
+	"github.com/okinrev/veza-web-app/internal/api/conversation"
 	"github.com/okinrev/veza-web-app/internal/api/listing"
 	"github.com/okinrev/veza-web-app/internal/api/message"
+	"github.com/okinrev/veza-web-app/internal/api/notification"
 	"github.com/okinrev/veza-web-app/internal/api/offer"
+	"github.com/okinrev/veza-web-app/internal/api/pricing"
+	"github.com/okinrev/veza-web-app/internal/api/publication"
+	"github.com/okinrev/veza-web-app/internal/api/quarantine"
 	"github.com/okinrev/veza-web-app/internal/api/room"
+	"github.com/okinrev/veza-web-app/internal/api/savedsearch"
 	"github.com/okinrev/veza-web-app/internal/api/search"
 	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
 	"github.com/okinrev/veza-web-app/internal/api/tag"
 	"github.com/okinrev/veza-web-app/internal/api/track"
+	"github.com/okinrev/veza-web-app/internal/api/upload"
 	"github.com/okinrev/veza-web-app/internal/api/user"
 )
 
 // APIRouter gère la configuration des routes de l'API
 type APIRouter struct {
-	db     *database.DB
-	config *config.Config
-	engine *gin.Engine
+	db        *database.DB
+	config    *config.Config
+	engine    *gin.Engine
+	inspector *security.Inspector
+	quotas    *quota.Manager
+	store     *storage.Store
+	blobs     *blob.Store
+	chat      *websocket.ChatManager
 }
 
 // NewAPIRouter crée une nouvelle instance de APIRouter
-func NewAPIRouter(db *database.DB, cfg *config.Config) *APIRouter {
+func NewAPIRouter(db *database.DB, cfg *config.Config, store *storage.Store, chat *websocket.ChatManager) *APIRouter {
+	scanner := security.NewScanner(cfg.Security.Scanner, cfg.Security.ClamdNetwork, cfg.Security.ClamdAddress, cfg.Security.ScanTimeout)
 	return &APIRouter{
-		db:     db,
-		config: cfg,
+		db:        db,
+		config:    cfg,
+		inspector: security.NewInspector(db, scanner, cfg.Security.QuarantineDir),
+		quotas:    quota.NewManager(db, cfg.Quotas.ByRole),
+		store:     store,
+		blobs:     blob.NewStore(db, store),
+		chat:      chat,
 	}
 }
 
@@ -59,12 +85,27 @@ func (r *APIRouter) Setup(router *gin.Engine) {
 		r.setupTrackRoutes(v1)
 		r.setupListingRoutes(v1)
 		r.setupOfferRoutes(v1)
+		r.setupSavedSearchRoutes(v1)
+		r.setupPricingRoutes(v1)
+		r.setupConversationRoutes(v1)
+		r.setupNotificationRoutes(v1)
 		r.setupMessageRoutes(v1)
 		r.setupRoomRoutes(v1)
 		r.setupSearchRoutes(v1)
 		r.setupTagRoutes(v1)
 		r.setupSharedResourcesRoutes(v1)
 		r.setupChatRoutes(v1)
+		r.setupActivityRoutes(v1)
+		r.setupPublicationRoutes(v1)
+		r.setupCollectionRoutes(v1)
+		r.setupUploadRoutes(v1)
+		r.setupQuarantineRoutes(v1)
+		r.setupFileRoutes(v1)
+	}
+
+	// Effacement des fichiers qui ne sont plus référencés
+	if r.config.Jobs.BlobGCInterval > 0 {
+		go r.blobs.RunCollector(r.config.Jobs.BlobGCInterval, r.config.Jobs.BlobGCGrace)
 	}
 }
 
@@ -76,33 +117,80 @@ func (r *APIRouter) setupAuthRoutes(router *gin.RouterGroup) {
 }
 
 func (r *APIRouter) setupUserRoutes(router *gin.RouterGroup) {
-	userService := user.NewService(r.db)
+	userService := user.NewService(r.db, r.quotas, r.blobs)
 	userHandler := user.NewHandler(userService)
 	user.SetupRoutes(router, userHandler, r.config.JWT.Secret)
 }
 
 func (r *APIRouter) setupAdminRoutes(router *gin.RouterGroup) {
-	adminService := admin.NewService(r.db)
+	adminService := admin.NewService(r.db, r.quotas)
 	adminHandler := admin.NewHandler(adminService)
 	admin.SetupRoutes(router, adminHandler, r.config.JWT.Secret)
 }
 
 func (r *APIRouter) setupTrackRoutes(router *gin.RouterGroup) {
-	trackService := track.NewService(r.db, r.config.JWT.Secret)
+	trackService := track.NewService(r.db, r.config.JWT.Secret, r.inspector, r.quotas, r.blobs)
 	trackHandler := track.NewHandler(trackService)
 	track.SetupRoutes(router, trackHandler, r.config.JWT.Secret)
+
+	// Taille des fichiers audio antérieurs au suivi des quotas de stockage
+	go trackService.BackfillSizes()
 }
 
 func (r *APIRouter) setupListingRoutes(router *gin.RouterGroup) {
-	listingService := listing.NewService(r.db)
+	listingService := listing.NewService(r.db, r.config.Marketplace, r.quotas, r.blobs)
 	listingHandler := listing.NewHandler(listingService)
 	listing.SetupRoutes(router, listingHandler, r.config.JWT.Secret)
+
+	// Expiration des annonces arrivées en fin de vie et rappels avant échéance
+	if r.config.Jobs.ListingExpiryInterval > 0 {
+		go listingService.RunExpiry(r.config.Jobs.ListingExpiryInterval)
+	}
 }
 
 func (r *APIRouter) setupOfferRoutes(router *gin.RouterGroup) {
-	offerService := offer.NewService(r.db)
+	offerService := offer.NewService(r.db, r.config.Marketplace, r.quotas, r.blobs)
 	offerHandler := offer.NewHandler(offerService)
 	offer.SetupRoutes(router, offerHandler, r.config.JWT.Secret)
+
+	// Expiration des offres sans réponse et rappels avant échéance
+	if r.config.Jobs.OfferExpiryInterval > 0 {
+		go offerService.RunExpiry(r.config.Jobs.OfferExpiryInterval)
+	}
+}
+
+func (r *APIRouter) setupSavedSearchRoutes(router *gin.RouterGroup) {
+	savedSearchService := savedsearch.NewService(r.db)
+	savedSearchHandler := savedsearch.NewHandler(savedSearchService)
+	savedsearch.SetupRoutes(router, savedSearchHandler, r.config.JWT.Secret)
+
+	// Récapitulatifs quotidiens des recherches enregistrées
+	if r.config.Jobs.SavedSearchDigestInterval > 0 {
+		go savedSearchService.RunDigests(r.config.Jobs.SavedSearchDigestInterval)
+	}
+}
+
+func (r *APIRouter) setupPricingRoutes(router *gin.RouterGroup) {
+	pricingService := pricing.NewService(r.db, r.config.Marketplace)
+	pricingHandler := pricing.NewHandler(pricingService)
+	pricing.SetupRoutes(router, pricingHandler)
+}
+
+func (r *APIRouter) setupConversationRoutes(router *gin.RouterGroup) {
+	// Sans gestionnaire de chat, les messages restent consultables mais ne sont pas poussés en direct
+	var hub conversation.Hub
+	if r.chat != nil {
+		hub = r.chat
+	}
+	conversationService := conversation.NewService(r.db, hub)
+	conversationHandler := conversation.NewHandler(conversationService)
+	conversation.SetupRoutes(router, conversationHandler, r.config.JWT.Secret)
+}
+
+func (r *APIRouter) setupNotificationRoutes(router *gin.RouterGroup) {
+	notificationService := notification.NewService(r.db)
+	notificationHandler := notification.NewHandler(notificationService)
+	notification.SetupRoutes(router, notificationHandler, r.config.JWT.Secret)
 }
 
 func (r *APIRouter) setupMessageRoutes(router *gin.RouterGroup) {
@@ -130,9 +218,12 @@ func (r *APIRouter) setupTagRoutes(router *gin.RouterGroup) {
 }
 
 func (r *APIRouter) setupSharedResourcesRoutes(router *gin.RouterGroup) {
-	sharedResourcesService := shared_resources.NewService(r.db)
+	sharedResourcesService := shared_resources.NewService(r.db, r.inspector, r.quotas, r.blobs)
 	sharedResourcesHandler := shared_resources.NewHandler(sharedResourcesService)
 	shared_resources.SetupRoutes(router, sharedResourcesHandler, r.config.JWT.Secret)
+
+	// Taille des versions antérieures au suivi des quotas de stockage
+	go sharedResourcesService.BackfillSizes()
 }
 
 func (r *APIRouter) setupChatRoutes(router *gin.RouterGroup) {
@@ -140,8 +231,71 @@ func (r *APIRouter) setupChatRoutes(router *gin.RouterGroup) {
 	chat.RegisterRoutes(r.engine, chatHandler, r.config.JWT.Secret)
 }
 
+func (r *APIRouter) setupActivityRoutes(router *gin.RouterGroup) {
+	activityService := activity.NewService(r.db)
+	activityHandler := activity.NewHandler(activityService)
+	activity.SetupRoutes(router, activityHandler)
+}
+
+func (r *APIRouter) setupPublicationRoutes(router *gin.RouterGroup) {
+	publicationService := publication.NewService(r.db)
+	publicationHandler := publication.NewHandler(publicationService)
+	publication.SetupRoutes(router, publicationHandler, r.config.JWT.Secret)
+
+	// Publication automatique des contenus programmés
+	if r.config.Jobs.PublicationInterval > 0 {
+		go publicationService.RunScheduler(r.config.Jobs.PublicationInterval)
+	}
+}
+
+func (r *APIRouter) setupCollectionRoutes(router *gin.RouterGroup) {
+	collectionService := collection.NewService(r.db, r.blobs)
+	collectionHandler := collection.NewHandler(collectionService)
+	collection.SetupRoutes(router, collectionHandler, r.config.JWT.Secret)
+}
+
+func (r *APIRouter) setupUploadRoutes(router *gin.RouterGroup) {
+	uploadService := upload.NewService(
+		r.db,
+		shared_resources.NewService(r.db, r.inspector, r.quotas, r.blobs),
+		track.NewService(r.db, r.config.JWT.Secret, r.inspector, r.quotas, r.blobs),
+		r.quotas,
+		r.config.Uploads,
+	)
+	uploadHandler := upload.NewHandler(uploadService)
+	upload.SetupRoutes(router, uploadHandler, r.config.JWT.Secret)
+
+	// Suppression des uploads abandonnés
+	if r.config.Jobs.UploadCleanupInterval > 0 {
+		go uploadService.RunCleanup(r.config.Jobs.UploadCleanupInterval)
+	}
+}
+
+func (r *APIRouter) setupQuarantineRoutes(router *gin.RouterGroup) {
+	quarantineService := quarantine.NewService(
+		r.db,
+		r.inspector,
+		shared_resources.NewService(r.db, r.inspector, r.quotas, r.blobs),
+		track.NewService(r.db, r.config.JWT.Secret, r.inspector, r.quotas, r.blobs),
+	)
+	quarantineHandler := quarantine.NewHandler(quarantineService)
+	quarantine.SetupRoutes(router, quarantineHandler, r.config.JWT.Secret)
+}
+
+// setupFileRoutes sert les URLs présignées du stockage local ; un service
+// compatible S3 sert lui-même les siennes
+func (r *APIRouter) setupFileRoutes(router *gin.RouterGroup) {
+	local, ok := r.store.Backend.(*storage.Local)
+	if !ok {
+		return
+	}
+	router.GET("/files/*key", func(c *gin.Context) {
+		local.ServeSigned(c.Writer, c.Request, strings.TrimPrefix(c.Param("key"), "/"))
+	})
+}
+
 // SetupRoutes configure toutes les routes API (pour la compatibilité)
-func SetupRoutes(router *gin.Engine, db *database.DB, cfg *config.Config) {
-	apiRouter := NewAPIRouter(db, cfg)
+func SetupRoutes(router *gin.Engine, db *database.DB, cfg *config.Config, store *storage.Store, chat *websocket.ChatManager) {
+	apiRouter := NewAPIRouter(db, cfg, store, chat)
 	apiRouter.Setup(router)
 }
@@ -0,0 +1,176 @@
+package savedsearch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// writeError traduit les erreurs du service en réponses HTTP
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrSearchNotFound), errors.Is(err, ErrCategoryNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTooManySearches):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNoCriteria), errors.Is(err, ErrInvalidState), errors.Is(err, ErrInvalidFrequency),
+		errors.Is(err, ErrEmptySearchName):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// searchParams lit l'utilisateur connecté et l'identifiant de la recherche
+func searchParams(c *gin.Context) (int, int, bool) {
+	searchID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid saved search ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	return searchID, userID, true
+}
+
+// ListSearches liste les recherches enregistrées de l'utilisateur
+func (h *Handler) ListSearches(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	searches, err := h.service.ListSearches(userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve saved searches")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, searches, "Saved searches retrieved successfully")
+}
+
+// CreateSearch enregistre une recherche d'annonces
+func (h *Handler) CreateSearch(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.service.CreateSearch(userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to save search")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, search, "Search saved successfully")
+}
+
+// GetSearch récupère une recherche enregistrée
+func (h *Handler) GetSearch(c *gin.Context) {
+	searchID, userID, ok := searchParams(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.service.GetSearch(searchID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve saved search")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, search, "Saved search retrieved successfully")
+}
+
+// UpdateSearch remplace les critères d'une recherche enregistrée
+func (h *Handler) UpdateSearch(c *gin.Context) {
+	searchID, userID, ok := searchParams(c)
+	if !ok {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.service.UpdateSearch(searchID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to update saved search")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, search, "Saved search updated successfully")
+}
+
+// DeleteSearch supprime une recherche enregistrée
+func (h *Handler) DeleteSearch(c *gin.Context) {
+	searchID, userID, ok := searchParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSearch(searchID, userID); err != nil {
+		writeError(c, err, "Failed to delete saved search")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Saved search deleted successfully")
+}
+
+// ListMatches liste les annonces trouvées par une recherche enregistrée
+func (h *Handler) ListMatches(c *gin.Context) {
+	searchID, userID, ok := searchParams(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	matches, total, err := h.service.ListMatches(searchID, userID, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve saved search matches")
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+	response.PaginatedJSON(c.Writer, matches, meta, "Matches retrieved successfully")
+}
@@ -0,0 +1,21 @@
+package savedsearch
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	searches := router.Group("/saved-searches")
+
+	// Routes protégées
+	searches.Use(middleware.JWTAuthMiddleware(jwtSecret))
+	{
+		searches.GET("", handler.ListSearches)
+		searches.POST("", handler.CreateSearch)
+		searches.GET("/:id", handler.GetSearch)
+		searches.PUT("/:id", handler.UpdateSearch)
+		searches.DELETE("/:id", handler.DeleteSearch)
+		searches.GET("/:id/matches", handler.ListMatches)
+	}
+}
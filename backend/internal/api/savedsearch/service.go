@@ -0,0 +1,376 @@
+package savedsearch
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/okinrev/veza-web-app/internal/api/notification"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrSearchNotFound   = errors.New("saved search not found")
+	ErrTooManySearches  = fmt.Errorf("you cannot save more than %d searches", MaxSearches)
+	ErrNoCriteria       = errors.New("a saved search needs at least one criterion")
+	ErrInvalidState     = errors.New("state must be one of new, like_new, good, fair, poor")
+	ErrInvalidFrequency = errors.New("frequency must be immediate or daily")
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrEmptySearchName  = errors.New("name cannot be empty")
+)
+
+// matchCondition indique si l'annonce l (produit p) correspond à la recherche s
+const matchCondition = `
+	s.user_id <> l.user_id
+	AND (s.brand = '' OR LOWER(p.brand) = LOWER(s.brand))
+	AND (s.model = '' OR POSITION(LOWER(s.model) IN LOWER(COALESCE(p.model, ''))) > 0)
+	AND (s.category_id IS NULL OR p.category_id = s.category_id)
+	AND (s.max_price IS NULL OR (l.price IS NOT NULL AND l.price <= s.max_price))
+	AND (s.state = '' OR l.state = s.state)
+`
+
+const selectSearch = `
+	SELECT s.id, s.user_id, s.name, s.brand, s.model, s.category_id, s.max_price, s.state,
+	       s.frequency, s.last_digest_at,
+	       (SELECT COUNT(*) FROM saved_search_matches m WHERE m.search_id = s.id AND m.notified_at IS NULL),
+	       s.created_at, COALESCE(s.updated_at, s.created_at)
+	FROM saved_searches s
+`
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSearch(row rowScanner) (*models.SavedSearch, error) {
+	var s models.SavedSearch
+	err := row.Scan(
+		&s.ID, &s.UserID, &s.Name, &s.Brand, &s.Model, &s.CategoryID, &s.MaxPrice, &s.State,
+		&s.Frequency, &s.LastDigestAt, &s.PendingCount, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// MatchListing enregistre, dans la transaction de création d'une annonce, les
+// recherches auxquelles elle correspond et notifie celles en alerte immédiate
+func MatchListing(tx *sql.Tx, listingID int) error {
+	rows, err := tx.Query(`
+		WITH matched AS (
+			INSERT INTO saved_search_matches (search_id, listing_id, notified_at)
+			SELECT s.id, l.id, CASE WHEN s.frequency = $2 THEN NOW() END
+			FROM saved_searches s, listings l
+			JOIN products p ON p.id = l.product_id
+			WHERE l.id = $1 AND `+matchCondition+`
+			ON CONFLICT DO NOTHING
+			RETURNING search_id, notified_at
+		)
+		SELECT s.user_id, s.name, p.name
+		FROM matched m
+		JOIN saved_searches s ON s.id = m.search_id
+		JOIN listings l ON l.id = $1
+		JOIN products p ON p.id = l.product_id
+		WHERE m.notified_at IS NOT NULL
+	`, listingID, FrequencyImmediate)
+	if err != nil {
+		return fmt.Errorf("failed to match saved searches: %w", err)
+	}
+
+	type alert struct {
+		userID          int
+		search, product string
+	}
+	alerts := []alert{}
+	for rows.Next() {
+		var a alert
+		if err := rows.Scan(&a.userID, &a.search, &a.product); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan saved search match: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to match saved searches: %w", err)
+	}
+
+	for _, a := range alerts {
+		message := fmt.Sprintf("Nouvelle annonce pour votre recherche « %s » : %s", a.search, a.product)
+		if err := notification.Notify(tx, a.userID, notification.TypeListingMatch, notification.ObjectListing, listingID, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate normalise et contrôle les critères d'une recherche
+func (s *Service) validate(req *SavedSearchRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Brand = strings.TrimSpace(req.Brand)
+	req.Model = strings.TrimSpace(req.Model)
+	if req.Name == "" {
+		return ErrEmptySearchName
+	}
+	if req.State != "" && !validStates[req.State] {
+		return ErrInvalidState
+	}
+	if req.Frequency == "" {
+		req.Frequency = FrequencyImmediate
+	}
+	if !validFrequencies[req.Frequency] {
+		return ErrInvalidFrequency
+	}
+	if req.Brand == "" && req.Model == "" && req.CategoryID == nil && req.MaxPrice == nil && req.State == "" {
+		return ErrNoCriteria
+	}
+
+	if req.CategoryID != nil {
+		var exists bool
+		err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)", *req.CategoryID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check category: %w", err)
+		}
+		if !exists {
+			return ErrCategoryNotFound
+		}
+	}
+	return nil
+}
+
+// CreateSearch enregistre une recherche de l'utilisateur
+func (s *Service) CreateSearch(userID int, req SavedSearchRequest) (*models.SavedSearch, error) {
+	if err := s.validate(&req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Verrou sur l'utilisateur pour que la limite tienne face aux créations concurrentes
+	if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM saved_searches WHERE user_id = $1", userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	if count >= MaxSearches {
+		return nil, ErrTooManySearches
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO saved_searches (user_id, name, brand, model, category_id, max_price, state, frequency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, userID, req.Name, req.Brand, req.Model, req.CategoryID, req.MaxPrice, req.State, req.Frequency).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit saved search: %w", err)
+	}
+	return s.GetSearch(id, userID)
+}
+
+// GetSearch retourne une recherche de l'utilisateur
+func (s *Service) GetSearch(searchID, userID int) (*models.SavedSearch, error) {
+	search, err := scanSearch(s.db.QueryRow(selectSearch+" WHERE s.id = $1 AND s.user_id = $2", searchID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSearchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return search, nil
+}
+
+// ListSearches retourne les recherches de l'utilisateur, les plus récentes d'abord
+func (s *Service) ListSearches(userID int) ([]models.SavedSearch, error) {
+	rows, err := s.db.Query(selectSearch+" WHERE s.user_id = $1 ORDER BY s.created_at DESC, s.id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, *search)
+	}
+	return searches, rows.Err()
+}
+
+// UpdateSearch remplace les critères et la fréquence d'une recherche ; les
+// annonces déjà trouvées restent associées à la recherche
+func (s *Service) UpdateSearch(searchID, userID int, req SavedSearchRequest) (*models.SavedSearch, error) {
+	if err := s.validate(&req); err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE saved_searches
+		SET name = $1, brand = $2, model = $3, category_id = $4, max_price = $5, state = $6,
+		    frequency = $7, updated_at = NOW()
+		WHERE id = $8 AND user_id = $9
+	`, req.Name, req.Brand, req.Model, req.CategoryID, req.MaxPrice, req.State, req.Frequency, searchID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrSearchNotFound
+	}
+	return s.GetSearch(searchID, userID)
+}
+
+// DeleteSearch supprime une recherche de l'utilisateur
+func (s *Service) DeleteSearch(searchID, userID int) error {
+	result, err := s.db.Exec("DELETE FROM saved_searches WHERE id = $1 AND user_id = $2", searchID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSearchNotFound
+	}
+	return nil
+}
+
+// ListMatches retourne les annonces trouvées par une recherche, les plus récentes d'abord
+func (s *Service) ListMatches(searchID, userID, page, limit int) ([]models.SavedSearchMatch, int, error) {
+	if _, err := s.GetSearch(searchID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM saved_search_matches WHERE search_id = $1", searchID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count saved search matches: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT l.id, p.name, COALESCE(p.brand, ''), COALESCE(p.model, ''), l.state, l.price, l.status,
+		       m.notified_at, m.created_at
+		FROM saved_search_matches m
+		JOIN listings l ON l.id = m.listing_id
+		JOIN products p ON p.id = l.product_id
+		WHERE m.search_id = $1
+		ORDER BY m.created_at DESC, l.id DESC
+		LIMIT $2 OFFSET $3
+	`, searchID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve saved search matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []models.SavedSearchMatch{}
+	for rows.Next() {
+		var m models.SavedSearchMatch
+		if err := rows.Scan(&m.ListingID, &m.ProductName, &m.Brand, &m.Model, &m.State, &m.Price, &m.Status,
+			&m.NotifiedAt, &m.MatchedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan saved search match: %w", err)
+		}
+		matches = append(matches, m)
+	}
+	return matches, total, rows.Err()
+}
+
+// SendDigests envoie le récapitulatif des recherches quotidiennes ayant de
+// nouvelles annonces, au plus une fois par période
+func (s *Service) SendDigests() (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		WITH sent AS (
+			UPDATE saved_search_matches m SET notified_at = NOW()
+			FROM saved_searches s
+			WHERE s.id = m.search_id AND s.frequency = $1 AND m.notified_at IS NULL
+			  AND (s.last_digest_at IS NULL OR s.last_digest_at <= NOW() - make_interval(secs => $2))
+			RETURNING m.search_id
+		)
+		SELECT s.id, s.user_id, s.name, COUNT(*)
+		FROM sent
+		JOIN saved_searches s ON s.id = sent.search_id
+		GROUP BY s.id, s.user_id, s.name
+	`, FrequencyDaily, digestPeriod.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect saved search digests: %w", err)
+	}
+
+	type digest struct {
+		searchID, userID, count int
+		name                    string
+	}
+	digests := []digest{}
+	for rows.Next() {
+		var d digest
+		if err := rows.Scan(&d.searchID, &d.userID, &d.name, &d.count); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan saved search digest: %w", err)
+		}
+		digests = append(digests, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to collect saved search digests: %w", err)
+	}
+
+	ids := make([]int64, 0, len(digests))
+	for _, d := range digests {
+		message := fmt.Sprintf("%d nouvelle(s) annonce(s) pour votre recherche « %s »", d.count, d.name)
+		if err := notification.Notify(tx, d.userID, notification.TypeSearchDigest, notification.ObjectSavedSearch, d.searchID, message); err != nil {
+			return 0, err
+		}
+		ids = append(ids, int64(d.searchID))
+	}
+	if _, err := tx.Exec("UPDATE saved_searches SET last_digest_at = NOW() WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to update saved searches: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit saved search digests: %w", err)
+	}
+	return len(digests), nil
+}
+
+// RunDigests envoie périodiquement les récapitulatifs quotidiens
+func (s *Service) RunDigests(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		n, err := s.SendDigests()
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Saved search digests: %v", err))
+			continue
+		}
+		if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Saved search digests: %d récapitulatif(s) envoyé(s)", n))
+		}
+	}
+}
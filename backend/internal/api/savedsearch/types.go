@@ -0,0 +1,41 @@
+package savedsearch
+
+import "time"
+
+const (
+	// Fréquences d'alerte d'une recherche enregistrée
+	FrequencyImmediate = "immediate"
+	FrequencyDaily     = "daily"
+
+	// MaxSearches est le nombre maximal de recherches enregistrées par utilisateur
+	MaxSearches = 20
+
+	// digestPeriod sépare deux récapitulatifs d'une même recherche
+	digestPeriod = 24 * time.Hour
+)
+
+// validStates reprend les états acceptés pour les annonces
+var validStates = map[string]bool{
+	"new":      true,
+	"like_new": true,
+	"good":     true,
+	"fair":     true,
+	"poor":     true,
+}
+
+var validFrequencies = map[string]bool{
+	FrequencyImmediate: true,
+	FrequencyDaily:     true,
+}
+
+// SavedSearchRequest represents the criteria of a saved search. Empty criteria
+// match every listing; at least one criterion is required.
+type SavedSearchRequest struct {
+	Name       string `json:"name" binding:"required,max=100"`
+	Brand      string `json:"brand" binding:"max=100"`
+	Model      string `json:"model" binding:"max=100"`
+	CategoryID *int   `json:"category_id"`
+	MaxPrice   *int   `json:"max_price" binding:"omitempty,min=0"`
+	State      string `json:"state"`
+	Frequency  string `json:"frequency"` // immediate (par défaut) ou daily
+}
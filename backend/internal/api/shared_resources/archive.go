@@ -0,0 +1,219 @@
+package shared_resources
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// Limites appliquées aux archives pour rejeter les zip bombs
+	maxArchiveEntries          = 10000
+	maxArchiveUncompressedSize = 1 << 30 // 1GB
+	maxArchiveCompressionRatio = 100
+)
+
+var (
+	ErrInvalidArchive      = errors.New("invalid zip archive")
+	ErrArchiveTooLarge     = errors.New("zip archive expands beyond the allowed size")
+	ErrUnsafeArchivePath   = errors.New("zip archive contains an unsafe path")
+	ErrNotAnArchive        = errors.New("resource is not a zip archive")
+	ErrEntryNotFound       = errors.New("archive entry not found")
+	ErrEntryNotPreviewable = errors.New("archive entry is not an audio file")
+)
+
+// previewableExtensions liste les extensions audio pouvant être écoutées directement
+var previewableExtensions = map[string]string{
+	".wav":  "audio/wav",
+	".mp3":  "audio/mpeg",
+	".aiff": "audio/aiff",
+	".aif":  "audio/aiff",
+	".flac": "audio/flac",
+	".ogg":  "audio/ogg",
+}
+
+// ArchiveEntryInfo décrit un fichier contenu dans une archive
+type ArchiveEntryInfo struct {
+	Path           string
+	Size           int64
+	CompressedSize int64
+}
+
+// ArchiveNode représente un nœud de l'arborescence d'une archive
+type ArchiveNode struct {
+	Name     string         `json:"name"`
+	Path     string         `json:"path"`
+	IsDir    bool           `json:"is_dir"`
+	Size     int64          `json:"size"`
+	Children []*ArchiveNode `json:"children,omitempty"`
+}
+
+func isArchive(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".zip")
+}
+
+// cleanEntryPath normalise le chemin d'une entrée et rejette les traversées
+func cleanEntryPath(name string) (string, error) {
+	if name == "" || strings.Contains(name, "\\") || strings.ContainsRune(name, 0) {
+		return "", ErrUnsafeArchivePath
+	}
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", ErrUnsafeArchivePath
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", ErrUnsafeArchivePath
+		}
+	}
+	return path.Clean(name), nil
+}
+
+// indexArchive lit le répertoire central d'une archive et vérifie ses limites
+func indexArchive(r io.ReaderAt, size int64) ([]ArchiveEntryInfo, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if len(zr.File) > maxArchiveEntries {
+		return nil, ErrArchiveTooLarge
+	}
+
+	var total uint64
+	seen := make(map[string]bool, len(zr.File))
+	entries := make([]ArchiveEntryInfo, 0, len(zr.File))
+	for _, f := range zr.File {
+		entryPath, err := cleanEntryPath(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() || f.Mode()&fs.ModeSymlink != 0 {
+			// Les répertoires sont reconstruits depuis les chemins, les liens symboliques sont ignorés
+			continue
+		}
+
+		total += f.UncompressedSize64
+		if total > maxArchiveUncompressedSize {
+			return nil, ErrArchiveTooLarge
+		}
+		if f.CompressedSize64 == 0 && f.UncompressedSize64 > 0 {
+			return nil, ErrArchiveTooLarge
+		}
+		if f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > maxArchiveCompressionRatio {
+			return nil, ErrArchiveTooLarge
+		}
+		if seen[entryPath] {
+			continue
+		}
+		seen[entryPath] = true
+
+		entries = append(entries, ArchiveEntryInfo{
+			Path:           entryPath,
+			Size:           int64(f.UncompressedSize64),
+			CompressedSize: int64(f.CompressedSize64),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// indexArchiveFile indexe une archive reçue, avant son stockage
+func indexArchiveFile(p string) ([]ArchiveEntryInfo, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	return indexArchive(f, info.Size())
+}
+
+// buildArchiveTree construit l'arborescence à partir des chemins à plat
+func buildArchiveTree(entries []ArchiveEntryInfo) *ArchiveNode {
+	root := &ArchiveNode{Name: "", Path: "", IsDir: true}
+	dirs := map[string]*ArchiveNode{"": root}
+
+	var dirFor func(p string) *ArchiveNode
+	dirFor = func(p string) *ArchiveNode {
+		if node, ok := dirs[p]; ok {
+			return node
+		}
+		parent := dirFor(parentPath(p))
+		node := &ArchiveNode{Name: path.Base(p), Path: p, IsDir: true}
+		parent.Children = append(parent.Children, node)
+		dirs[p] = node
+		return node
+	}
+
+	for _, e := range entries {
+		parent := dirFor(parentPath(e.Path))
+		parent.Children = append(parent.Children, &ArchiveNode{
+			Name: path.Base(e.Path),
+			Path: e.Path,
+			Size: e.Size,
+		})
+	}
+
+	var sumSizes func(n *ArchiveNode) int64
+	sumSizes = func(n *ArchiveNode) int64 {
+		if !n.IsDir {
+			return n.Size
+		}
+		n.Size = 0
+		for _, child := range n.Children {
+			n.Size += sumSizes(child)
+		}
+		return n.Size
+	}
+	sumSizes(root)
+
+	return root
+}
+
+func parentPath(p string) string {
+	dir := path.Dir(p)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// openArchiveEntry ouvre une entrée de l'archive stockée en lecture. L'archive
+// est lue par accès aléatoire : seul le répertoire central et l'entrée sont transférés.
+func (s *Service) openArchiveEntry(filename, entryPath string) (io.ReadCloser, io.Closer, int64, error) {
+	obj, err := s.openFile(filename)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	zr, err := zip.NewReader(obj, obj.Info().Size)
+	if err != nil {
+		obj.Close()
+		return nil, nil, 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	for _, f := range zr.File {
+		cleaned, err := cleanEntryPath(f.Name)
+		if err != nil || cleaned != entryPath || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			obj.Close()
+			return nil, nil, 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		return rc, obj, int64(f.UncompressedSize64), nil
+	}
+
+	obj.Close()
+	return nil, nil, 0, ErrEntryNotFound
+}
@@ -0,0 +1,205 @@
+package shared_resources
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestCleanEntryPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "drums/kick.wav", want: "drums/kick.wav"},
+		{name: "drums//kick.wav", want: "drums/kick.wav"},
+		{name: "./drums/kick.wav", want: "drums/kick.wav"},
+		{name: "drums/", want: "drums"},
+		{name: "..kick.wav", want: "..kick.wav"},
+		{name: "", wantErr: true},
+		{name: "../kick.wav", wantErr: true},
+		{name: "drums/../../kick.wav", wantErr: true},
+		{name: "drums/..", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: "C:/Windows/kick.wav", wantErr: true},
+		{name: "drums\\kick.wav", wantErr: true},
+		{name: "kick\x00.wav", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanEntryPath(tt.name)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsafeArchivePath) {
+					t.Errorf("cleanEntryPath(%q) = %q, %v, want ErrUnsafeArchivePath", tt.name, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("cleanEntryPath(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// zipEntry décrit une entrée d'archive de test ; raw déclare des tailles sans
+// écrire le contenu correspondant, le répertoire central suffisant à l'index
+type zipEntry struct {
+	name           string
+	content        string
+	mode           fs.FileMode
+	raw            bool
+	compressedSize uint64
+	size           uint64
+}
+
+func buildZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+		if e.raw {
+			header.CompressedSize64, header.UncompressedSize64 = e.compressedSize, e.size
+			if _, err := zw.CreateRaw(header); err != nil {
+				t.Fatalf("CreateRaw %s: %v", e.name, err)
+			}
+			continue
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("CreateHeader %s: %v", e.name, err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatalf("Write %s: %v", e.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestIndexArchive(t *testing.T) {
+	manyEntries := make([]zipEntry, maxArchiveEntries+1)
+	for i := range manyEntries {
+		manyEntries[i] = zipEntry{name: fmt.Sprintf("e%05d.txt", i)}
+	}
+
+	tests := []struct {
+		name      string
+		entries   []zipEntry
+		want      []string
+		wantErr   error
+		wantSizes map[string]int64
+	}{
+		{
+			name: "sorted files",
+			entries: []zipEntry{
+				{name: "snares/snare.wav", content: "snare"},
+				{name: "kicks/kick.wav", content: "kick!"},
+				{name: "readme.txt", content: "hello"},
+			},
+			want:      []string{"kicks/kick.wav", "readme.txt", "snares/snare.wav"},
+			wantSizes: map[string]int64{"readme.txt": 5},
+		},
+		{
+			name: "directories and symlinks skipped",
+			entries: []zipEntry{
+				{name: "kicks/", mode: fs.ModeDir | 0755},
+				{name: "kicks/kick.wav", content: "kick"},
+				{name: "kicks/link.wav", content: "kick.wav", mode: fs.ModeSymlink | 0777},
+			},
+			want: []string{"kicks/kick.wav"},
+		},
+		{
+			name: "duplicates kept once",
+			entries: []zipEntry{
+				{name: "kick.wav", content: "one"},
+				{name: "./kick.wav", content: "two"},
+			},
+			want: []string{"kick.wav"},
+		},
+		{
+			name:    "path traversal",
+			entries: []zipEntry{{name: "kick.wav", content: "kick"}, {name: "../../.bashrc", content: "x"}},
+			wantErr: ErrUnsafeArchivePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: "/tmp/kick.wav", content: "kick"}},
+			wantErr: ErrUnsafeArchivePath,
+		},
+		{
+			name:    "too many entries",
+			entries: manyEntries,
+			wantErr: ErrArchiveTooLarge,
+		},
+		{
+			name:    "compression ratio",
+			entries: []zipEntry{{name: "zeros.bin", content: strings.Repeat("\x00", 1<<20)}},
+			wantErr: ErrArchiveTooLarge,
+		},
+		{
+			name:    "stored size without compressed data",
+			entries: []zipEntry{{name: "bomb.bin", raw: true, compressedSize: 0, size: 1 << 20}},
+			wantErr: ErrArchiveTooLarge,
+		},
+		{
+			name: "total uncompressed size",
+			entries: []zipEntry{
+				{name: "a.bin", raw: true, compressedSize: 10 << 20, size: 600 << 20},
+				{name: "b.bin", raw: true, compressedSize: 10 << 20, size: 600 << 20},
+			},
+			wantErr: ErrArchiveTooLarge,
+		},
+		{
+			name: "just under the limits",
+			entries: []zipEntry{
+				{name: "a.bin", raw: true, compressedSize: 10 << 20, size: 512 << 20},
+				{name: "b.bin", raw: true, compressedSize: 10 << 20, size: 512 << 20},
+			},
+			want: []string{"a.bin", "b.bin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildZip(t, tt.entries)
+			entries, err := indexArchive(bytes.NewReader(data), int64(len(data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("indexArchive error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("indexArchive: %v", err)
+			}
+
+			got := make([]string, len(entries))
+			for i, e := range entries {
+				got[i] = e.Path
+				if size, ok := tt.wantSizes[e.Path]; ok && e.Size != size {
+					t.Errorf("%s: size %d, want %d", e.Path, e.Size, size)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexArchiveInvalid(t *testing.T) {
+	data := []byte("PK\x03\x04 not really a zip archive")
+	if _, err := indexArchive(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("indexArchive error = %v, want ErrInvalidArchive", err)
+	}
+}
@@ -1,11 +1,21 @@
 package shared_resources
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
 	"github.com/okinrev/veza-web-app/internal/utils/response"
 )
 
@@ -18,6 +28,45 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
+// parseTags découpe une liste de tags séparés par des virgules
+func parseTags(raw string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrResourceNotFound), errors.Is(err, ErrEntryNotFound), errors.Is(err, ErrVersionNotFound),
+		errors.Is(err, ErrReviewNotFound), errors.Is(err, storage.ErrNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotOwner), errors.Is(err, ErrSelfReview):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAlreadyReviewed):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrFileTooLarge), errors.Is(err, quota.ErrQuotaExceeded):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, security.ErrQuarantined):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusAccepted)
+	case errors.Is(err, ErrUnsupportedFile), errors.Is(err, ErrEntryNotPreviewable), errors.Is(err, security.ErrContentMismatch):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrInvalidArchive), errors.Is(err, ErrArchiveTooLarge), errors.Is(err, ErrUnsafeArchivePath),
+		errors.Is(err, ErrInvalidMIDI), errors.Is(err, ErrInvalidSample):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, ErrUnknownResourceType), errors.Is(err, ErrNothingToUpdate), errors.Is(err, ErrNotAnArchive),
+		errors.Is(err, ErrEmptyTitle):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) UploadSharedResource(c *gin.Context) {
 	userID, exists := common.GetUserIDFromContext(c)
 	if !exists {
@@ -25,15 +74,31 @@ func (h *Handler) UploadSharedResource(c *gin.Context) {
 		return
 	}
 
-	title := c.PostForm("title")
-	resourceType := c.PostForm("type")
-	description := c.PostForm("description")
+	req := CreateSharedResourceRequest{
+		Title:       strings.TrimSpace(c.PostForm("title")),
+		Type:        c.PostForm("type"),
+		Description: strings.TrimSpace(c.PostForm("description")),
+		Tags:        parseTags(c.PostForm("tags")),
+		IsPublic:    c.DefaultPostForm("is_public", "true") != "false",
+		UploaderID:  userID,
+
+		ReleaseNotes: strings.TrimSpace(c.PostForm("release_notes")),
+	}
 
-	if title == "" {
+	if req.Title == "" {
 		response.ErrorJSON(c.Writer, "Title is required", http.StatusBadRequest)
 		return
 	}
 
+	if raw := c.PostForm("publish_at"); raw != "" {
+		publishAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil || !publishAt.After(time.Now()) {
+			response.ErrorJSON(c.Writer, "publish_at must be a future RFC3339 date", http.StatusBadRequest)
+			return
+		}
+		req.PublishAt = &publishAt
+	}
+
 	file, fileHeader, err := c.Request.FormFile("file")
 	if err != nil {
 		response.ErrorJSON(c.Writer, "File is required", http.StatusBadRequest)
@@ -41,61 +106,509 @@ func (h *Handler) UploadSharedResource(c *gin.Context) {
 	}
 	defer file.Close()
 
-	resource := map[string]interface{}{
-		"id":          1,
-		"title":       title,
-		"type":        resourceType,
-		"description": description,
-		"filename":    fileHeader.Filename,
-		"uploader_id": userID,
-		"is_public":   true,
-		"uploaded_at": "2025-01-01T00:00:00Z",
+	if err := ValidateResourceFile(req.Type, fileHeader.Filename, fileHeader.Size); err != nil {
+		writeError(c, err, "Failed to upload resource")
+		return
+	}
+	if err := h.service.quotas.Check(userID, fileHeader.Size); err != nil {
+		writeError(c, err, "Failed to upload resource")
+		return
+	}
+
+	tmpPath, _, err := storage.Spool(file, fileHeader.Filename)
+	if err != nil {
+		writeError(c, err, "Failed to store file")
+		return
 	}
+	defer os.Remove(tmpPath)
 
-	response.SuccessJSON(c.Writer, resource, "Resource uploaded successfully")
+	resource, err := h.service.CreateResourceFromFile(req, tmpPath, fileHeader.Filename)
+	if err != nil {
+		writeError(c, err, "Failed to upload resource")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, ToResponse(resource), "Resource uploaded successfully")
 }
 
 func (h *Handler) ListSharedResources(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	showPrivate := c.Query("show_private") == "true"
+	if showPrivate && userID == 0 {
+		response.ErrorJSON(c.Writer, "Authentication required to list private resources", http.StatusUnauthorized)
+		return
+	}
 
-	resources := []map[string]interface{}{
-		{
-			"id":       1,
-			"title":    "Sample Resource",
-			"type":     "sample",
-			"filename": "sample.zip",
-		},
+	resources, total, err := h.service.ListResources(page, limit, showPrivate, userID, c.Query("sort"))
+	if err != nil {
+		writeError(c, err, "Failed to retrieve resources")
+		return
 	}
 
 	meta := &response.Meta{
 		Page:       page,
 		PerPage:    limit,
-		Total:      len(resources),
-		TotalPages: 1,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
 	}
 
 	response.PaginatedJSON(c.Writer, resources, meta, "Resources retrieved successfully")
 }
 
 func (h *Handler) SearchSharedResources(c *gin.Context) {
-	_ = c.Query("q")
-	// TODO: Implement search
-	results := []map[string]interface{}{}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	minTempo, _ := strconv.ParseFloat(c.Query("min_tempo"), 64)
+	maxTempo, _ := strconv.ParseFloat(c.Query("max_tempo"), 64)
+	minBars, _ := strconv.ParseFloat(c.Query("min_bars"), 64)
+	maxBars, _ := strconv.ParseFloat(c.Query("max_bars"), 64)
+	sampleRate, _ := strconv.Atoi(c.Query("sample_rate"))
+	bitDepth, _ := strconv.Atoi(c.Query("bit_depth"))
+	channels, _ := strconv.Atoi(c.Query("channels"))
+	minDuration, _ := strconv.ParseFloat(c.Query("min_duration"), 64)
+	maxDuration, _ := strconv.ParseFloat(c.Query("max_duration"), 64)
+
+	filter := SearchFilter{
+		Query:         strings.TrimSpace(c.Query("q")),
+		Type:          c.Query("type"),
+		Tag:           c.Query("tag"),
+		Uploader:      c.Query("uploader"),
+		Sort:          c.Query("sort"),
+		Limit:         limit,
+		MinTempo:      minTempo,
+		MaxTempo:      maxTempo,
+		Key:           c.Query("key"),
+		TimeSignature: c.Query("time_signature"),
+		MinBars:       minBars,
+		MaxBars:       maxBars,
+		SampleRate:    sampleRate,
+		BitDepth:      bitDepth,
+		Channels:      channels,
+		MinDuration:   minDuration,
+		MaxDuration:   maxDuration,
+	}
+	if raw := c.Query("looped"); raw != "" {
+		looped, err := strconv.ParseBool(raw)
+		if err != nil {
+			response.ErrorJSON(c.Writer, "looped must be a boolean", http.StatusBadRequest)
+			return
+		}
+		filter.Looped = &looped
+	}
+
+	results, err := h.service.SearchResources(filter)
+	if err != nil {
+		writeError(c, err, "Failed to search resources")
+		return
+	}
+
 	response.SuccessJSON(c.Writer, results, "Search completed")
 }
 
+// GetResourceTypes liste les types de ressources et leurs contraintes
+func (h *Handler) GetResourceTypes(c *gin.Context) {
+	response.SuccessJSON(c.Writer, ResourceTypes(), "Resource types retrieved")
+}
+
+// GetSharedResource récupère une ressource
+func (h *Handler) GetSharedResource(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	resource, err := h.service.GetResource(resourceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve resource")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, ToResponse(resource), "Resource retrieved successfully")
+}
+
 func (h *Handler) UpdateSharedResource(c *gin.Context) {
-	// TODO: Implement update
-	response.SuccessJSON(c.Writer, nil, "Resource updated successfully")
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateSharedResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.service.UpdateResource(resourceID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to update resource")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, ToResponse(resource), "Resource updated successfully")
 }
 
 func (h *Handler) DeleteSharedResource(c *gin.Context) {
-	// TODO: Implement delete
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	filenames, err := h.service.DeleteResource(resourceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to delete resource")
+		return
+	}
+
+	for _, filename := range filenames {
+		h.service.removeFile(filename)
+	}
+
 	response.SuccessJSON(c.Writer, nil, "Resource deleted successfully")
 }
 
 func (h *Handler) ServeSharedFile(c *gin.Context) {
-	_ = c.Param("filename")
-	response.ErrorJSON(c.Writer, "File serving not implemented yet", http.StatusNotImplemented)
+	h.serveFile(c, c.Param("filename"))
+}
+
+// DownloadSharedResource télécharge la version courante d'une ressource,
+// ou la version demandée par le paramètre version
+func (h *Handler) DownloadSharedResource(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	version := 0
+	if raw := c.Query("version"); raw != "" {
+		version, err = strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			response.ErrorJSON(c.Writer, "Invalid version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	filename, err := h.service.GetVersionFile(resourceID, userID, version)
+	if err != nil {
+		writeError(c, err, "Failed to download resource")
+		return
+	}
+
+	h.serveFile(c, filename)
+}
+
+// serveFile envoie un fichier stocké après avoir comptabilisé le téléchargement
+func (h *Handler) serveFile(c *gin.Context, filename string) {
+	key, err := storageKey(filename)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid filename", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.service.store.Stat(key); err != nil {
+		response.ErrorJSON(c.Writer, "File not found", http.StatusNotFound)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	resource, err := h.service.RegisterDownload(filename, userID)
+	if err != nil {
+		writeError(c, err, "Failed to serve file")
+		return
+	}
+
+	if err := h.service.store.Serve(c.Writer, c.Request, key, storage.ServeOptions{Filename: resource.Filename}); err != nil {
+		writeError(c, err, "Failed to serve file")
+	}
+}
+
+// ListResourceVersions retourne l'historique des versions d'une ressource
+func (h *Handler) ListResourceVersions(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	versions, err := h.service.ListVersions(resourceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve resource versions")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, versions, "Resource versions retrieved successfully")
+}
+
+// CreateResourceVersion publie une nouvelle version du fichier d'une ressource
+func (h *Handler) CreateResourceVersion(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	resource, err := h.service.GetResource(resourceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to create resource version")
+		return
+	}
+	if resource.UploaderID != userID {
+		writeError(c, ErrNotOwner, "Failed to create resource version")
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.ErrorJSON(c.Writer, "File is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if err := ValidateResourceFile(resource.Type, fileHeader.Filename, fileHeader.Size); err != nil {
+		writeError(c, err, "Failed to create resource version")
+		return
+	}
+	if err := h.service.quotas.Check(userID, fileHeader.Size); err != nil {
+		writeError(c, err, "Failed to create resource version")
+		return
+	}
+
+	req := CreateVersionRequest{
+		ResourceID:   resourceID,
+		UserID:       userID,
+		ReleaseNotes: strings.TrimSpace(c.PostForm("release_notes")),
+	}
+
+	tmpPath, _, err := storage.Spool(file, fileHeader.Filename)
+	if err != nil {
+		writeError(c, err, "Failed to store file")
+		return
+	}
+	defer os.Remove(tmpPath)
+
+	version, err := h.service.CreateVersionFromFile(req, tmpPath, fileHeader.Filename)
+	if err != nil {
+		writeError(c, err, "Failed to create resource version")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, version, "Resource version created successfully")
+}
+
+// GetArchiveContents retourne l'arborescence d'une archive zip avec les tailles
+func (h *Handler) GetArchiveContents(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	entries, err := h.service.ListArchiveEntries(resourceID, userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve archive contents")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, buildArchiveTree(entries), "Archive contents retrieved")
+}
+
+// DownloadArchiveEntry télécharge un seul fichier d'une archive
+func (h *Handler) DownloadArchiveEntry(c *gin.Context) {
+	h.serveArchiveEntry(c, false)
+}
+
+// PreviewArchiveEntry diffuse un fichier audio d'une archive pour une écoute
+func (h *Handler) PreviewArchiveEntry(c *gin.Context) {
+	h.serveArchiveEntry(c, true)
+}
+
+func (h *Handler) serveArchiveEntry(c *gin.Context, preview bool) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	entryPath, err := cleanEntryPath(c.Query("path"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid entry path", http.StatusBadRequest)
+		return
+	}
+
+	contentType := "application/octet-stream"
+	if preview {
+		var ok bool
+		contentType, ok = previewableExtensions[strings.ToLower(filepath.Ext(entryPath))]
+		if !ok {
+			writeError(c, ErrEntryNotPreviewable, "Failed to preview entry")
+			return
+		}
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	filename, err := h.service.GetArchiveEntry(resourceID, userID, entryPath)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve archive entry")
+		return
+	}
+
+	rc, archive, size, err := h.service.openArchiveEntry(filename, entryPath)
+	if err != nil {
+		writeError(c, err, "Failed to open archive entry")
+		return
+	}
+	defer archive.Close()
+	defer rc.Close()
+
+	disposition := "attachment"
+	if preview {
+		disposition = "inline"
+	}
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf("%s; filename=%q", disposition, filepath.Base(entryPath)),
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, rc, extraHeaders)
+}
+
+// ListResourceReviews retourne les avis paginés d'une ressource
+func (h *Handler) ListResourceReviews(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	userID, _ := common.GetUserIDFromContext(c)
+	reviews, total, err := h.service.ListReviews(resourceID, userID, page, limit)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve reviews")
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+
+	response.PaginatedJSON(c.Writer, reviews, meta, "Reviews retrieved successfully")
+}
+
+// bindReview lit l'identifiant de la ressource, l'utilisateur et l'avis envoyé
+func bindReview(c *gin.Context) (resourceID, userID int, req ReviewRequest, ok bool) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return 0, 0, req, false
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return 0, 0, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorJSON(c.Writer, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return 0, 0, req, false
+	}
+	return resourceID, userID, req, true
+}
+
+// CreateResourceReview note une ressource, une seule fois par utilisateur
+func (h *Handler) CreateResourceReview(c *gin.Context) {
+	resourceID, userID, req, ok := bindReview(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.service.CreateReview(resourceID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to create review")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, review, "Review created successfully")
+}
+
+// UpdateResourceReview modifie l'avis de l'utilisateur connecté
+func (h *Handler) UpdateResourceReview(c *gin.Context) {
+	resourceID, userID, req, ok := bindReview(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.service.UpdateReview(resourceID, userID, req)
+	if err != nil {
+		writeError(c, err, "Failed to update review")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, review, "Review updated successfully")
+}
+
+// DeleteResourceReview supprime l'avis de l'utilisateur connecté
+func (h *Handler) DeleteResourceReview(c *gin.Context) {
+	resourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid resource ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteReview(resourceID, userID); err != nil {
+		writeError(c, err, "Failed to delete review")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, nil, "Review deleted successfully")
 }
@@ -0,0 +1,62 @@
+package shared_resources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+var ErrInvalidMIDI = errors.New("invalid MIDI file")
+
+func isMIDI(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".mid" || ext == ".midi"
+}
+
+// analyzeMIDIFile extrait tempo, signature, tonalité et tessiture d'un fichier MIDI reçu
+func analyzeMIDIFile(p string) (*models.MIDIInfo, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MIDI file: %w", err)
+	}
+	defer f.Close()
+
+	parsed, err := media.ParseMIDI(f)
+	if err != nil {
+		if errors.Is(err, media.ErrInvalidMIDI) {
+			return nil, ErrInvalidMIDI
+		}
+		return nil, fmt.Errorf("failed to read MIDI file: %w", err)
+	}
+
+	info := &models.MIDIInfo{
+		TempoBPM:      parsed.TempoBPM,
+		TimeSignature: parsed.TimeSignature,
+		KeySignature:  parsed.KeySignature,
+		LengthBars:    parsed.LengthBars,
+		TrackCount:    parsed.TrackCount,
+		ChannelCount:  parsed.ChannelCount,
+	}
+	if parsed.LowestNote >= 0 {
+		lowest, highest := parsed.LowestNote, parsed.HighestNote
+		info.LowestNote = &lowest
+		info.HighestNote = &highest
+	}
+	fillNoteNames(info)
+	return info, nil
+}
+
+// fillNoteNames renseigne les noms des notes extrêmes
+func fillNoteNames(info *models.MIDIInfo) {
+	if info.LowestNote != nil {
+		info.LowestNoteName = media.NoteName(*info.LowestNote)
+	}
+	if info.HighestNote != nil {
+		info.HighestNoteName = media.NoteName(*info.HighestNote)
+	}
+}
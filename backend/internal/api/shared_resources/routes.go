@@ -8,10 +8,22 @@ import (
 func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
 	resources := router.Group("/shared-resources")
 	{
-		// Routes publiques
-		resources.GET("", handler.ListSharedResources)
-		resources.GET("/search", handler.SearchSharedResources)
-		resources.GET("/:filename", handler.ServeSharedFile)
+		// Routes publiques (l'authentification optionnelle donne accès aux ressources privées du propriétaire)
+		public := resources.Group("")
+		public.Use(middleware.OptionalJWTAuthMiddleware(jwtSecret))
+		{
+			public.GET("", handler.ListSharedResources)
+			public.GET("/search", handler.SearchSharedResources)
+			public.GET("/types", handler.GetResourceTypes)
+			public.GET("/files/:filename", handler.ServeSharedFile)
+			public.GET("/:id", handler.GetSharedResource)
+			public.GET("/:id/download", handler.DownloadSharedResource)
+			public.GET("/:id/versions", handler.ListResourceVersions)
+			public.GET("/:id/reviews", handler.ListResourceReviews)
+			public.GET("/:id/contents", handler.GetArchiveContents)
+			public.GET("/:id/contents/download", handler.DownloadArchiveEntry)
+			public.GET("/:id/contents/preview", handler.PreviewArchiveEntry)
+		}
 
 		// Routes protégées
 		protected := resources.Group("")
@@ -19,6 +31,10 @@ func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
 		{
 			protected.POST("", handler.UploadSharedResource)
 			protected.PUT("/:id", handler.UpdateSharedResource)
+			protected.POST("/:id/versions", handler.CreateResourceVersion)
+			protected.POST("/:id/reviews", handler.CreateResourceReview)
+			protected.PUT("/:id/reviews", handler.UpdateResourceReview)
+			protected.DELETE("/:id/reviews", handler.DeleteResourceReview)
 			protected.DELETE("/:id", handler.DeleteSharedResource)
 		}
 	}
@@ -0,0 +1,61 @@
+package shared_resources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+var ErrInvalidSample = errors.New("invalid WAV/AIFF file")
+
+func isParsableSample(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".wav", ".aif", ".aiff":
+		return true
+	}
+	return false
+}
+
+// analyzeSampleFile extrait les métadonnées d'un sample reçu. Le tempo et
+// la tonalité sont devinés depuis le nom d'origine du fichier, les autres
+// informations ne sont lues que pour les fichiers WAV et AIFF.
+func analyzeSampleFile(p, filename, originalName string) (*models.SampleInfo, error) {
+	info := &models.SampleInfo{}
+	info.BPM, info.Key = media.GuessTempoAndKey(filepath.Base(originalName))
+
+	if isParsableSample(filename) {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open sample file: %w", err)
+		}
+		defer f.Close()
+
+		parsed, err := media.ParseSample(f)
+		if err != nil {
+			if errors.Is(err, media.ErrInvalidSample) {
+				return nil, ErrInvalidSample
+			}
+			return nil, fmt.Errorf("failed to read sample file: %w", err)
+		}
+
+		info.Format = parsed.Format
+		info.SampleRate = parsed.SampleRate
+		info.BitDepth = parsed.BitDepth
+		info.Channels = parsed.Channels
+		info.DurationSeconds = parsed.DurationSeconds
+		if parsed.LoopStart >= 0 {
+			start, end := parsed.LoopStart, parsed.LoopEnd
+			info.LoopStart, info.LoopEnd = &start, &end
+		}
+	}
+
+	if info.Format == "" && info.BPM == 0 && info.Key == "" {
+		return nil, nil
+	}
+	return info, nil
+}
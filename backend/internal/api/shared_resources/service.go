@@ -1,16 +1,1107 @@
 package shared_resources
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/okinrev/veza-web-app/internal/blob"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/utils/media"
+)
+
+var (
+	ErrResourceNotFound    = errors.New("resource not found")
+	ErrNotOwner            = errors.New("not authorized to modify this resource")
+	ErrUnknownResourceType = errors.New("unknown resource type")
+	ErrUnsupportedFile     = errors.New("unsupported file type for this resource type")
+	ErrFileTooLarge        = errors.New("file exceeds the maximum size for this resource type")
+	ErrNothingToUpdate     = errors.New("no fields to update")
+	ErrEmptyTitle          = errors.New("title cannot be empty")
+	ErrVersionNotFound     = errors.New("resource version not found")
+	ErrSelfReview          = errors.New("you cannot review your own resource")
+	ErrAlreadyReviewed     = errors.New("you have already reviewed this resource")
+	ErrReviewNotFound      = errors.New("review not found")
 )
 
+// selectResource contient les colonnes lues pour une ressource et son auteur
+const selectResource = `
+	SELECT r.id, r.title, r.description, r.filename, r.url, r.type, r.tags,
+	       r.uploader_id, r.is_public, r.download_count, r.current_version,
+	       r.rating_average, r.rating_count, r.publish_at,
+	       r.uploaded_at, r.updated_at, u.username, u.avatar,
+	       m.tempo_bpm, m.time_signature, m.key_signature, m.length_bars,
+	       m.track_count, m.channel_count, m.lowest_note, m.highest_note,
+	       s.shared_ressource_id IS NOT NULL, s.format, s.sample_rate, s.bit_depth, s.channels,
+	       s.duration_seconds, s.loop_start, s.loop_end, s.bpm, s.musical_key
+	FROM shared_ressources r
+	JOIN users u ON u.id = r.uploader_id
+	LEFT JOIN shared_ressource_midi m ON m.shared_ressource_id = r.id
+	LEFT JOIN shared_ressource_samples s ON s.shared_ressource_id = r.id
+`
+
 type Service struct {
-	db *database.DB
+	db        *database.DB
+	inspector *security.Inspector
+	quotas    *quota.Manager
+	store     *blob.Store
+}
+
+func NewService(db *database.DB, inspector *security.Inspector, quotas *quota.Manager, store *blob.Store) *Service {
+	return &Service{db: db, inspector: inspector, quotas: quotas, store: store}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanResource(row rowScanner) (*models.SharedResourceWithUploader, error) {
+	var r models.SharedResourceWithUploader
+	var tempo, lengthBars sql.NullFloat64
+	var timeSig, keySig sql.NullString
+	var trackCount, channelCount, lowest, highest sql.NullInt32
+	var hasSample bool
+	var format, musicalKey sql.NullString
+	var sampleRate, bitDepth, channels sql.NullInt32
+	var duration, bpm sql.NullFloat64
+	var loopStart, loopEnd sql.NullInt64
+	err := row.Scan(
+		&r.ID, &r.Title, &r.Description, &r.Filename, &r.URL, &r.Type, &r.Tags,
+		&r.UploaderID, &r.IsPublic, &r.DownloadCount, &r.Version,
+		&r.RatingAverage, &r.RatingCount, &r.PublishAt,
+		&r.UploadedAt, &r.UpdatedAt, &r.UploaderUsername, &r.UploaderAvatar,
+		&tempo, &timeSig, &keySig, &lengthBars,
+		&trackCount, &channelCount, &lowest, &highest,
+		&hasSample, &format, &sampleRate, &bitDepth, &channels,
+		&duration, &loopStart, &loopEnd, &bpm, &musicalKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tempo.Valid {
+		r.MIDI = &models.MIDIInfo{
+			TempoBPM:      tempo.Float64,
+			TimeSignature: timeSig.String,
+			KeySignature:  keySig.String,
+			LengthBars:    lengthBars.Float64,
+			TrackCount:    int(trackCount.Int32),
+			ChannelCount:  int(channelCount.Int32),
+		}
+		if lowest.Valid && highest.Valid {
+			l, h := int(lowest.Int32), int(highest.Int32)
+			r.MIDI.LowestNote, r.MIDI.HighestNote = &l, &h
+		}
+		fillNoteNames(r.MIDI)
+	}
+
+	if hasSample {
+		r.Sample = &models.SampleInfo{
+			Format:          format.String,
+			SampleRate:      int(sampleRate.Int32),
+			BitDepth:        int(bitDepth.Int32),
+			Channels:        int(channels.Int32),
+			DurationSeconds: duration.Float64,
+			BPM:             bpm.Float64,
+			Key:             musicalKey.String,
+		}
+		if loopStart.Valid && loopEnd.Valid {
+			start, end := loopStart.Int64, loopEnd.Int64
+			r.Sample.LoopStart, r.Sample.LoopEnd = &start, &end
+		}
+	}
+	return &r, nil
+}
+
+// ToResponse convertit une ressource en réponse API
+func ToResponse(r *models.SharedResourceWithUploader) models.SharedResourceResponse {
+	tags := []string(r.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return models.SharedResourceResponse{
+		ID:               r.ID,
+		Title:            r.Title,
+		Description:      r.Description,
+		Filename:         r.Filename,
+		URL:              r.URL,
+		Type:             r.Type,
+		Tags:             tags,
+		UploaderID:       r.UploaderID,
+		UploaderUsername: r.UploaderUsername,
+		IsPublic:         r.IsPublic,
+		DownloadCount:    r.DownloadCount,
+		Version:          r.Version,
+		RatingAverage:    r.RatingAverage,
+		RatingCount:      r.RatingCount,
+		UploadedAt:       r.UploadedAt,
+		UpdatedAt:        r.UpdatedAt,
+		DownloadURL:      r.URL,
+		MIDI:             r.MIDI,
+		Sample:           r.Sample,
+	}
+}
+
+// ValidateResourceFile vérifie l'extension et la taille selon le type de ressource
+func ValidateResourceFile(resourceType, filename string, size int64) error {
+	rt, ok := resourceTypes[resourceType]
+	if !ok {
+		return ErrUnknownResourceType
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	validExt := false
+	for _, allowed := range rt.Extensions {
+		if ext == allowed {
+			validExt = true
+			break
+		}
+	}
+	if !validExt {
+		return ErrUnsupportedFile
+	}
+
+	if size > rt.MaxSize || size > MaxResourceSize {
+		return ErrFileTooLarge
+	}
+	return nil
+}
+
+// ResourceTypes retourne les types de ressources supportés
+func ResourceTypes() []ResourceType {
+	types := make([]ResourceType, 0, len(resourceTypes))
+	for _, name := range []string{"sample", "preset", "plugin", "template", "midi", "document"} {
+		types = append(types, resourceTypes[name])
+	}
+	return types
+}
+
+// allowedKinds retourne les contenus acceptés pour un fichier : ceux du type
+// de ressource qui correspondent aussi à l'extension du fichier
+func allowedKinds(resourceType, filename string) []string {
+	kinds := []string{}
+	for _, kind := range extensionKinds[strings.ToLower(filepath.Ext(filename))] {
+		for _, allowed := range resourceTypes[resourceType].ContentKinds {
+			if kind == allowed {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return kinds
+}
+
+// inspectFile contrôle le contenu d'un fichier reçu et le soumet au scanner.
+// En cas d'erreur l'appelant supprime le fichier, sauf s'il a été mis en quarantaine.
+func (s *Service) inspectFile(resourceType, filename, path string, sub security.Submission) error {
+	return s.inspector.Inspect(path, allowedKinds(resourceType, filename), sub)
+}
+
+// analyzeUpload indexe les archives et analyse les fichiers MIDI et les samples
+// reçus, avant leur stockage
+func analyzeUpload(resourceType, filename, path, originalName string) (FileAnalysis, error) {
+	var analysis FileAnalysis
+	var err error
+
+	switch {
+	case isArchive(filename):
+		analysis.ArchiveEntries, err = indexArchiveFile(path)
+	case isMIDI(filename):
+		analysis.MIDI, err = analyzeMIDIFile(path)
+	case resourceType == "sample":
+		analysis.Sample, err = analyzeSampleFile(path, filename, originalName)
+	}
+	return analysis, err
+}
+
+// submission décrit une création de ressource pour une éventuelle libération de quarantaine
+func (req CreateSharedResourceRequest) submission(originalName string) security.Submission {
+	meta := map[string]string{
+		"title":         req.Title,
+		"type":          req.Type,
+		"description":   req.Description,
+		"tags":          strings.Join(req.Tags, ","),
+		"is_public":     strconv.FormatBool(req.IsPublic),
+		"release_notes": req.ReleaseNotes,
+	}
+	if req.PublishAt != nil {
+		meta["publish_at"] = req.PublishAt.Format(time.RFC3339)
+	}
+	return security.Submission{
+		UserID:   req.UploaderID,
+		Target:   security.TargetSharedResource,
+		Filename: originalName,
+		Metadata: meta,
+		Reviewed: req.Reviewed,
+	}
+}
+
+// submission décrit une nouvelle version pour une éventuelle libération de quarantaine
+func (req CreateVersionRequest) submission(originalName string) security.Submission {
+	return security.Submission{
+		UserID:   req.UserID,
+		Target:   security.TargetSharedResourceVersion,
+		Filename: originalName,
+		Metadata: map[string]string{
+			"resource_id":   strconv.Itoa(req.ResourceID),
+			"release_notes": req.ReleaseNotes,
+		},
+		Reviewed: req.Reviewed,
+	}
+}
+
+// CreateResource enregistre une ressource dont le fichier est déjà stocké,
+// ainsi que l'index de son contenu s'il s'agit d'une archive
+func (s *Service) CreateResource(req CreateSharedResourceRequest, filename string) (*models.SharedResourceWithUploader, error) {
+	var description sql.NullString
+	if req.Description != "" {
+		description = sql.NullString{String: req.Description, Valid: true}
+	}
+
+	isPublic := req.IsPublic
+	var publishAt sql.NullTime
+	if req.PublishAt != nil {
+		isPublic = false
+		publishAt = sql.NullTime{Time: req.PublishAt.UTC(), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.quotas.Reserve(tx, req.UploaderID, req.Size); err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO shared_ressources (title, description, filename, url, type, tags, uploader_id, is_public, publish_at, uploaded_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		RETURNING id
+	`, req.Title, description, filename, fileURLPrefix+filename, req.Type,
+		pq.Array(req.Tags), req.UploaderID, isPublic, publishAt).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO shared_ressource_versions (shared_ressource_id, version_number, filename, size, release_notes)
+		VALUES ($1, 1, $2, $3, $4)
+	`, id, filename, req.Size, req.ReleaseNotes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource version: %w", err)
+	}
+
+	if err := storeAnalysis(tx, id, req.FileAnalysis, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit resource: %w", err)
+	}
+
+	return s.GetResource(id, req.UploaderID)
+}
+
+// storeAnalysis enregistre l'analyse du fichier courant d'une ressource,
+// en remplaçant celle de la version précédente si replace est vrai
+func storeAnalysis(tx *sql.Tx, id int, a FileAnalysis, replace bool) error {
+	if replace {
+		for _, table := range []string{"shared_ressource_archive_entries", "shared_ressource_midi", "shared_ressource_samples"} {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE shared_ressource_id = $1", id); err != nil {
+				return fmt.Errorf("failed to clear previous analysis: %w", err)
+			}
+		}
+	}
+
+	for _, e := range a.ArchiveEntries {
+		_, err := tx.Exec(`
+			INSERT INTO shared_ressource_archive_entries (shared_ressource_id, path, size, compressed_size)
+			VALUES ($1, $2, $3, $4)
+		`, id, e.Path, e.Size, e.CompressedSize)
+		if err != nil {
+			return fmt.Errorf("failed to index archive entry: %w", err)
+		}
+	}
+
+	if m := a.MIDI; m != nil {
+		_, err := tx.Exec(`
+			INSERT INTO shared_ressource_midi (shared_ressource_id, tempo_bpm, time_signature, key_signature,
+				length_bars, track_count, channel_count, lowest_note, highest_note)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)
+		`, id, m.TempoBPM, m.TimeSignature, m.KeySignature, m.LengthBars,
+			m.TrackCount, m.ChannelCount, m.LowestNote, m.HighestNote)
+		if err != nil {
+			return fmt.Errorf("failed to store MIDI analysis: %w", err)
+		}
+	}
+
+	if sm := a.Sample; sm != nil {
+		_, err := tx.Exec(`
+			INSERT INTO shared_ressource_samples (shared_ressource_id, format, sample_rate, bit_depth, channels,
+				duration_seconds, loop_start, loop_end, bpm, musical_key)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, 0),
+				NULLIF($6, 0), $7, $8, NULLIF($9, 0), NULLIF($10, ''))
+		`, id, sm.Format, sm.SampleRate, sm.BitDepth, sm.Channels,
+			sm.DurationSeconds, sm.LoopStart, sm.LoopEnd, sm.BPM, sm.Key)
+		if err != nil {
+			return fmt.Errorf("failed to store sample metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetResource récupère une ressource visible par l'utilisateur
+func (s *Service) GetResource(resourceID, userID int) (*models.SharedResourceWithUploader, error) {
+	r, err := scanResource(s.db.QueryRow(
+		selectResource+" WHERE r.id = $1 AND (r.is_public = true OR r.uploader_id = $2)",
+		resourceID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	return r, nil
+}
+
+// ListResources retourne les ressources publiques, ou celles de l'utilisateur si showPrivate
+func (s *Service) ListResources(page, limit int, showPrivate bool, userID int, sort string) ([]models.SharedResourceResponse, int, error) {
+	offset := (page - 1) * limit
+
+	whereClause := " WHERE r.is_public = true"
+	args := []interface{}{}
+	if showPrivate && userID > 0 {
+		whereClause = " WHERE r.uploader_id = $1"
+		args = append(args, userID)
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM shared_ressources r"+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count resources: %w", err)
+	}
+
+	query := selectResource + whereClause + orderClause(sort, SortRecent) +
+		" LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, limit, offset)
+
+	resources, err := s.queryResources(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resources, total, nil
+}
+
+// SearchResources recherche parmi les ressources publiques
+func (s *Service) SearchResources(filter SearchFilter) ([]models.SharedResourceResponse, error) {
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	conditions := []string{"r.is_public = true"}
+	args := []interface{}{}
+	argCount := 1
+
+	if filter.Query != "" {
+		conditions = append(conditions, "(r.title ILIKE $"+strconv.Itoa(argCount)+" OR r.description ILIKE $"+strconv.Itoa(argCount)+")")
+		args = append(args, "%"+filter.Query+"%")
+		argCount++
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "r.type = $"+strconv.Itoa(argCount))
+		args = append(args, filter.Type)
+		argCount++
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, "$"+strconv.Itoa(argCount)+" = ANY(r.tags)")
+		args = append(args, filter.Tag)
+		argCount++
+	}
+	if filter.Uploader != "" {
+		conditions = append(conditions, "LOWER(u.username) = LOWER($"+strconv.Itoa(argCount)+")")
+		args = append(args, filter.Uploader)
+		argCount++
+	}
+
+	if filter.MinTempo > 0 {
+		conditions = append(conditions, "COALESCE(m.tempo_bpm, s.bpm) >= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MinTempo)
+		argCount++
+	}
+	if filter.MaxTempo > 0 {
+		conditions = append(conditions, "COALESCE(m.tempo_bpm, s.bpm) <= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MaxTempo)
+		argCount++
+	}
+	if filter.Key != "" {
+		// Les tonalités sont enregistrées en dièses : Ebm est cherchée comme D#m
+		key := media.NormalizeKey(filter.Key)
+		if key == "" {
+			key = filter.Key
+		}
+		conditions = append(conditions, "COALESCE(m.key_signature, s.musical_key) = $"+strconv.Itoa(argCount))
+		args = append(args, key)
+		argCount++
+	}
+	if filter.TimeSignature != "" {
+		conditions = append(conditions, "m.time_signature = $"+strconv.Itoa(argCount))
+		args = append(args, filter.TimeSignature)
+		argCount++
+	}
+	if filter.MinBars > 0 {
+		conditions = append(conditions, "m.length_bars >= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MinBars)
+		argCount++
+	}
+	if filter.MaxBars > 0 {
+		conditions = append(conditions, "m.length_bars <= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MaxBars)
+		argCount++
+	}
+
+	if filter.SampleRate > 0 {
+		conditions = append(conditions, "s.sample_rate = $"+strconv.Itoa(argCount))
+		args = append(args, filter.SampleRate)
+		argCount++
+	}
+	if filter.BitDepth > 0 {
+		conditions = append(conditions, "s.bit_depth = $"+strconv.Itoa(argCount))
+		args = append(args, filter.BitDepth)
+		argCount++
+	}
+	if filter.Channels > 0 {
+		conditions = append(conditions, "s.channels = $"+strconv.Itoa(argCount))
+		args = append(args, filter.Channels)
+		argCount++
+	}
+	if filter.MinDuration > 0 {
+		conditions = append(conditions, "s.duration_seconds >= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MinDuration)
+		argCount++
+	}
+	if filter.MaxDuration > 0 {
+		conditions = append(conditions, "s.duration_seconds <= $"+strconv.Itoa(argCount))
+		args = append(args, filter.MaxDuration)
+		argCount++
+	}
+	if filter.Looped != nil {
+		if *filter.Looped {
+			conditions = append(conditions, "s.loop_start IS NOT NULL")
+		} else {
+			conditions = append(conditions, "s.shared_ressource_id IS NOT NULL AND s.loop_start IS NULL")
+		}
+	}
+
+	query := selectResource + " WHERE " + strings.Join(conditions, " AND ") +
+		orderClause(filter.Sort, SortDownloads) + " LIMIT $" + strconv.Itoa(argCount)
+	args = append(args, filter.Limit)
+
+	return s.queryResources(query, args...)
+}
+
+func (s *Service) queryResources(query string, args ...interface{}) ([]models.SharedResourceResponse, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve resources: %w", err)
+	}
+	defer rows.Close()
+
+	resources := []models.SharedResourceResponse{}
+	for rows.Next() {
+		r, err := scanResource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan resource: %w", err)
+		}
+		resources = append(resources, ToResponse(r))
+	}
+	return resources, rows.Err()
+}
+
+// checkOwner vérifie que la ressource existe et appartient à l'utilisateur
+func (s *Service) checkOwner(resourceID, userID int) (string, error) {
+	var ownerID int
+	var filename string
+	err := s.db.QueryRow("SELECT uploader_id, filename FROM shared_ressources WHERE id = $1", resourceID).Scan(&ownerID, &filename)
+	if err == sql.ErrNoRows {
+		return "", ErrResourceNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get resource: %w", err)
+	}
+	if ownerID != userID {
+		return "", ErrNotOwner
+	}
+	return filename, nil
+}
+
+// UpdateResource met à jour les métadonnées d'une ressource (propriétaire uniquement)
+func (s *Service) UpdateResource(resourceID, userID int, req UpdateSharedResourceRequest) (*models.SharedResourceWithUploader, error) {
+	filename, err := s.checkOwner(resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, ErrEmptyTitle
+		}
+		setParts = append(setParts, "title = $"+strconv.Itoa(argCount))
+		args = append(args, title)
+		argCount++
+	}
+	if req.Description != nil {
+		setParts = append(setParts, "description = $"+strconv.Itoa(argCount))
+		args = append(args, strings.TrimSpace(*req.Description))
+		argCount++
+	}
+	if req.Type != nil {
+		if err := ValidateResourceFile(*req.Type, filename, 0); err != nil {
+			return nil, err
+		}
+		setParts = append(setParts, "type = $"+strconv.Itoa(argCount))
+		args = append(args, *req.Type)
+		argCount++
+	}
+	if req.Tags != nil {
+		setParts = append(setParts, "tags = $"+strconv.Itoa(argCount))
+		args = append(args, pq.Array(*req.Tags))
+		argCount++
+	}
+	if req.IsPublic != nil {
+		// Une visibilité choisie explicitement annule la publication programmée
+		setParts = append(setParts, "is_public = $"+strconv.Itoa(argCount), "publish_at = NULL")
+		args = append(args, *req.IsPublic)
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	setParts = append(setParts, "updated_at = NOW()")
+	args = append(args, resourceID)
+
+	query := "UPDATE shared_ressources SET " + strings.Join(setParts, ", ") + " WHERE id = $" + strconv.Itoa(argCount)
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update resource: %w", err)
+	}
+
+	return s.GetResource(resourceID, userID)
+}
+
+// DeleteResource supprime une ressource (propriétaire uniquement) et retourne
+// les fichiers de toutes ses versions à effacer
+func (s *Service) DeleteResource(resourceID, userID int) ([]string, error) {
+	if _, err := s.checkOwner(resourceID, userID); err != nil {
+		return nil, err
+	}
+
+	var filenames pq.StringArray
+	err := s.db.QueryRow(`
+		SELECT COALESCE(array_agg(filename), '{}') FROM shared_ressource_versions WHERE shared_ressource_id = $1
+	`, resourceID).Scan(&filenames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource versions: %w", err)
+	}
+
+	var current string
+	err = s.db.QueryRow("DELETE FROM shared_ressources WHERE id = $1 RETURNING filename", resourceID).Scan(&current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete resource: %w", err)
+	}
+
+	for _, f := range filenames {
+		if f == current {
+			return filenames, nil
+		}
+	}
+	return append(filenames, current), nil
+}
+
+// RegisterDownload vérifie l'accès au fichier et incrémente les compteurs de
+// téléchargements de la ressource et de la version correspondante
+func (s *Service) RegisterDownload(filename string, userID int) (*models.SharedResource, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var resourceID int
+	err = tx.QueryRow(`
+		UPDATE shared_ressource_versions v
+		SET download_count = v.download_count + 1
+		FROM shared_ressources r
+		WHERE v.shared_ressource_id = r.id AND v.filename = $1
+		  AND (r.is_public = true OR r.uploader_id = $2)
+		RETURNING r.id
+	`, filename, userID).Scan(&resourceID)
+	if err == sql.ErrNoRows {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to register download: %w", err)
+	}
+
+	var r models.SharedResource
+	err = tx.QueryRow(`
+		UPDATE shared_ressources
+		SET download_count = download_count + 1
+		WHERE id = $1
+		RETURNING id, title, filename, type, uploader_id, is_public, download_count, current_version
+	`, resourceID).Scan(&r.ID, &r.Title, &r.Filename, &r.Type, &r.UploaderID, &r.IsPublic, &r.DownloadCount, &r.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register download: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit download: %w", err)
+	}
+	return &r, nil
+}
+
+// ListArchiveEntries retourne l'index d'une archive visible par l'utilisateur
+func (s *Service) ListArchiveEntries(resourceID, userID int) ([]ArchiveEntryInfo, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isArchive(resource.Filename) {
+		return nil, ErrNotAnArchive
+	}
+
+	rows, err := s.db.Query(`
+		SELECT path, size, compressed_size
+		FROM shared_ressource_archive_entries
+		WHERE shared_ressource_id = $1
+		ORDER BY path
+	`, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve archive entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ArchiveEntryInfo{}
+	for rows.Next() {
+		var e ArchiveEntryInfo
+		if err := rows.Scan(&e.Path, &e.Size, &e.CompressedSize); err != nil {
+			return nil, fmt.Errorf("failed to scan archive entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
 }
 
-func NewService(db *database.DB) *Service {
-	return &Service{db: db}
+// GetArchiveEntry vérifie qu'une entrée indexée existe et retourne le fichier de l'archive
+func (s *Service) GetArchiveEntry(resourceID, userID int, entryPath string) (string, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !isArchive(resource.Filename) {
+		return "", ErrNotAnArchive
+	}
+
+	var exists bool
+	err = s.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM shared_ressource_archive_entries
+			WHERE shared_ressource_id = $1 AND path = $2
+		)
+	`, resourceID, entryPath).Scan(&exists)
+	if err != nil {
+		return "", fmt.Errorf("failed to get archive entry: %w", err)
+	}
+	if !exists {
+		return "", ErrEntryNotFound
+	}
+	return resource.Filename, nil
+}
+
+// CreateVersion publie un nouveau fichier pour une ressource (propriétaire uniquement).
+// La nouvelle version devient la version courante de la ressource.
+func (s *Service) CreateVersion(req CreateVersionRequest, filename string) (*models.SharedResourceVersion, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Le verrou sur la ressource sérialise la numérotation des versions
+	var ownerID, current int
+	err = tx.QueryRow(
+		"SELECT uploader_id, current_version FROM shared_ressources WHERE id = $1 FOR UPDATE",
+		req.ResourceID,
+	).Scan(&ownerID, &current)
+	if err == sql.ErrNoRows {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	if ownerID != req.UserID {
+		return nil, ErrNotOwner
+	}
+	if err := s.quotas.Reserve(tx, req.UserID, req.Size); err != nil {
+		return nil, err
+	}
+
+	v := models.SharedResourceVersion{
+		ResourceID:   req.ResourceID,
+		Filename:     filename,
+		URL:          fileURLPrefix + filename,
+		Size:         req.Size,
+		ReleaseNotes: req.ReleaseNotes,
+		IsLatest:     true,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO shared_ressource_versions (shared_ressource_id, version_number, filename, size, release_notes)
+		SELECT $1, COALESCE(MAX(version_number), 0) + 1, $2, $3, $4
+		FROM shared_ressource_versions WHERE shared_ressource_id = $1
+		RETURNING id, version_number, created_at
+	`, req.ResourceID, filename, req.Size, req.ReleaseNotes).Scan(&v.ID, &v.VersionNumber, &v.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE shared_ressources
+		SET filename = $1, url = $2, current_version = $3, updated_at = NOW()
+		WHERE id = $4
+	`, filename, v.URL, v.VersionNumber, req.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update resource: %w", err)
+	}
+
+	if err := storeAnalysis(tx, req.ResourceID, req.FileAnalysis, true); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit resource version: %w", err)
+	}
+	return &v, nil
+}
+
+// ListVersions retourne l'historique des versions d'une ressource visible, de la plus récente à la plus ancienne
+func (s *Service) ListVersions(resourceID, userID int) ([]models.SharedResourceVersion, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, shared_ressource_id, version_number, filename, size, release_notes, download_count, created_at
+		FROM shared_ressource_versions
+		WHERE shared_ressource_id = $1
+		ORDER BY version_number DESC
+	`, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve resource versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.SharedResourceVersion{}
+	for rows.Next() {
+		var v models.SharedResourceVersion
+		if err := rows.Scan(&v.ID, &v.ResourceID, &v.VersionNumber, &v.Filename, &v.Size,
+			&v.ReleaseNotes, &v.DownloadCount, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan resource version: %w", err)
+		}
+		v.URL = fileURLPrefix + v.Filename
+		v.IsLatest = v.VersionNumber == resource.Version
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
 }
 
-// TODO: Implement methods based on doc_shared_resources_handler.md
+// GetVersionFile retourne le fichier d'une version d'une ressource visible,
+// ou celui de la version courante si version vaut 0
+func (s *Service) GetVersionFile(resourceID, userID, version int) (string, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return "", err
+	}
+	if version == 0 {
+		return resource.Filename, nil
+	}
+
+	var filename string
+	err = s.db.QueryRow(`
+		SELECT filename FROM shared_ressource_versions
+		WHERE shared_ressource_id = $1 AND version_number = $2
+	`, resourceID, version).Scan(&filename)
+	if err == sql.ErrNoRows {
+		return "", ErrVersionNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get resource version: %w", err)
+	}
+	return filename, nil
+}
+
+// refreshRating recalcule la note moyenne et le nombre d'avis d'une ressource
+func refreshRating(tx *sql.Tx, resourceID int) error {
+	_, err := tx.Exec(`
+		UPDATE shared_ressources SET
+			rating_average = COALESCE((SELECT AVG(rating) FROM shared_ressource_reviews WHERE shared_ressource_id = $1), 0),
+			rating_count = (SELECT COUNT(*) FROM shared_ressource_reviews WHERE shared_ressource_id = $1)
+		WHERE id = $1
+	`, resourceID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh resource rating: %w", err)
+	}
+	return nil
+}
+
+// CreateReview ajoute l'avis d'un utilisateur sur une ressource visible qui n'est pas la sienne
+func (s *Service) CreateReview(resourceID, userID int, req ReviewRequest) (*models.SharedResourceReview, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if resource.UploaderID == userID {
+		return nil, ErrSelfReview
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	var reviewID int
+	err = tx.QueryRow(`
+		INSERT INTO shared_ressource_reviews (shared_ressource_id, user_id, rating, comment)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (shared_ressource_id, user_id) DO NOTHING
+		RETURNING id
+	`, resourceID, userID, req.Rating, strings.TrimSpace(req.Comment)).Scan(&reviewID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAlreadyReviewed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	if err := refreshRating(tx, resourceID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return s.getReview(resourceID, userID)
+}
+
+// UpdateReview modifie l'avis de l'utilisateur sur une ressource
+func (s *Service) UpdateReview(resourceID, userID int, req ReviewRequest) (*models.SharedResourceReview, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE shared_ressource_reviews SET rating = $1, comment = $2, updated_at = NOW()
+		WHERE shared_ressource_id = $3 AND user_id = $4
+	`, req.Rating, strings.TrimSpace(req.Comment), resourceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update review: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrReviewNotFound
+	}
+
+	if err := refreshRating(tx, resourceID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return s.getReview(resourceID, userID)
+}
+
+// DeleteReview supprime l'avis de l'utilisateur sur une ressource
+func (s *Service) DeleteReview(resourceID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"DELETE FROM shared_ressource_reviews WHERE shared_ressource_id = $1 AND user_id = $2",
+		resourceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete review: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrReviewNotFound
+	}
+
+	if err := refreshRating(tx, resourceID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit review deletion: %w", err)
+	}
+	return nil
+}
+
+const selectReview = `
+	SELECT rv.id, rv.shared_ressource_id, rv.user_id, u.username, COALESCE(u.avatar, ''),
+	       rv.rating, rv.comment, rv.created_at, rv.updated_at
+	FROM shared_ressource_reviews rv
+	JOIN users u ON u.id = rv.user_id
+`
+
+func scanReview(row rowScanner) (*models.SharedResourceReview, error) {
+	var rv models.SharedResourceReview
+	err := row.Scan(&rv.ID, &rv.ResourceID, &rv.UserID, &rv.Username, &rv.Avatar,
+		&rv.Rating, &rv.Comment, &rv.CreatedAt, &rv.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (s *Service) getReview(resourceID, userID int) (*models.SharedResourceReview, error) {
+	rv, err := scanReview(s.db.QueryRow(
+		selectReview+" WHERE rv.shared_ressource_id = $1 AND rv.user_id = $2",
+		resourceID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	return rv, nil
+}
+
+// ListReviews retourne les avis d'une ressource visible, du plus récent au plus ancien
+func (s *Service) ListReviews(resourceID, userID, page, limit int) ([]models.SharedResourceReview, int, error) {
+	resource, err := s.GetResource(resourceID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(
+		selectReview+" WHERE rv.shared_ressource_id = $1 ORDER BY rv.created_at DESC LIMIT $2 OFFSET $3",
+		resourceID, limit, (page-1)*limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.SharedResourceReview{}
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, *rv)
+	}
+	return reviews, resource.RatingCount, rows.Err()
+}
+
+// validateReceivedFile contrôle l'extension et la taille réelle d'un fichier déjà reçu
+func validateReceivedFile(resourceType, srcPath, originalName string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat received file: %w", err)
+	}
+	return ValidateResourceFile(resourceType, originalName, info.Size())
+}
+
+// CreateResourceFromFile contrôle et analyse un fichier déjà reçu (formulaire ou
+// upload reprenable), le copie dans le stockage puis crée la ressource. Le fichier
+// source est supprimé si la ressource est créée.
+func (s *Service) CreateResourceFromFile(req CreateSharedResourceRequest, srcPath, originalName string) (*models.SharedResourceWithUploader, error) {
+	if err := validateReceivedFile(req.Type, srcPath, originalName); err != nil {
+		return nil, err
+	}
+
+	filename := storedFilename(req.UploaderID, originalName)
+	var err error
+	req.FileAnalysis, err = s.prepareFile(req.Type, filename, srcPath, originalName, req.submission(originalName))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Size, err = s.storeFile(filename, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := s.CreateResource(req, filename)
+	if err != nil {
+		s.removeFile(filename)
+		return nil, err
+	}
+	os.Remove(srcPath)
+	return resource, nil
+}
+
+// CreateVersionFromFile contrôle un fichier déjà reçu, le copie dans le stockage
+// et en fait la nouvelle version de la ressource
+func (s *Service) CreateVersionFromFile(req CreateVersionRequest, srcPath, originalName string) (*models.SharedResourceVersion, error) {
+	resource, err := s.GetResource(req.ResourceID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if resource.UploaderID != req.UserID {
+		return nil, ErrNotOwner
+	}
+	if err := validateReceivedFile(resource.Type, srcPath, originalName); err != nil {
+		return nil, err
+	}
+
+	filename := storedFilename(req.UserID, originalName)
+	req.FileAnalysis, err = s.prepareFile(resource.Type, filename, srcPath, originalName, req.submission(originalName))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Size, err = s.storeFile(filename, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.CreateVersion(req, filename)
+	if err != nil {
+		s.removeFile(filename)
+		return nil, err
+	}
+	os.Remove(srcPath)
+	return version, nil
+}
+
+// prepareFile inspecte puis analyse un fichier reçu avant son stockage
+func (s *Service) prepareFile(resourceType, filename, path, originalName string, sub security.Submission) (FileAnalysis, error) {
+	if err := s.inspectFile(resourceType, filename, path, sub); err != nil {
+		return FileAnalysis{}, err
+	}
+	return analyzeUpload(resourceType, filename, path, originalName)
+}
@@ -0,0 +1,109 @@
+package shared_resources
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// storedFilename génère un nom de fichier unique et sans danger pour le stockage
+func storedFilename(userID int, original string) string {
+	base := filepath.Base(original)
+	base = unsafeFilenameChars.ReplaceAllString(base, "_")
+	base = strings.Trim(base, "._")
+	if base == "" {
+		base = "resource"
+	}
+	return fmt.Sprintf("%d_%d_%s", userID, time.Now().UnixNano(), base)
+}
+
+// storageKey retourne la clé de stockage du fichier d'une ressource
+func storageKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceShared, filename)
+}
+
+// storeFile copie un fichier reçu dans le stockage des ressources et retourne sa taille
+func (s *Service) storeFile(filename, path string) (int64, error) {
+	key, err := storageKey(filename)
+	if err != nil {
+		return 0, err
+	}
+	size, err := s.store.PutFile(key, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store file: %w", err)
+	}
+	return size, nil
+}
+
+// removeFile supprime un fichier du stockage des ressources
+func (s *Service) removeFile(filename string) {
+	if key, err := storageKey(filename); err == nil {
+		s.store.Remove(key)
+	}
+}
+
+// openFile ouvre un fichier stocké en lecture
+func (s *Service) openFile(filename string) (storage.Object, error) {
+	key, err := storageKey(filename)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Open(key)
+}
+
+// StorageKey retourne la clé de stockage d'une ressource partagée pour les autres modules
+func StorageKey(filename string) (string, error) {
+	return storageKey(filename)
+}
+
+// BackfillSizes renseigne la taille des versions créées avant le suivi de
+// l'espace de stockage, dont la version 1 des ressources antérieures
+func (s *Service) BackfillSizes() {
+	rows, err := s.db.Query("SELECT id, filename FROM shared_ressource_versions WHERE size = 0")
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Shared resource size backfill: %v", err))
+		return
+	}
+	type pending struct {
+		id       int
+		filename string
+	}
+	var versions []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.filename); err != nil {
+			rows.Close()
+			utils.LogError(fmt.Sprintf("Shared resource size backfill: %v", err))
+			return
+		}
+		versions = append(versions, p)
+	}
+	rows.Close()
+
+	updated := 0
+	for _, p := range versions {
+		key, err := storageKey(p.filename)
+		if err != nil {
+			continue
+		}
+		info, err := s.store.Stat(key)
+		if err != nil || info.Size == 0 {
+			continue
+		}
+		if _, err := s.db.Exec("UPDATE shared_ressource_versions SET size = $1 WHERE id = $2", info.Size, p.id); err != nil {
+			utils.LogError(fmt.Sprintf("Shared resource size backfill: %v", err))
+			return
+		}
+		updated++
+	}
+	if updated > 0 {
+		utils.LogInfo(fmt.Sprintf("Shared resource size backfill: %d version(s) mise(s) à jour", updated))
+	}
+}
@@ -0,0 +1,171 @@
+package shared_resources
+
+import (
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/security"
+)
+
+const (
+	// MaxResourceSize est la taille maximale absolue d'une ressource
+	MaxResourceSize = 50 << 20 // 50MB
+
+	// fileURLPrefix est le préfixe des URLs de téléchargement
+	fileURLPrefix = "/api/v1/shared-resources/files/"
+)
+
+// ResourceType décrit les contraintes d'un type de ressource
+type ResourceType struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Extensions  []string `json:"extensions"`
+	MaxSize     int64    `json:"max_size"`
+
+	// ContentKinds est la liste des contenus acceptés, détectés par leurs premiers octets
+	ContentKinds []string `json:"content_kinds"`
+}
+
+var resourceTypes = map[string]ResourceType{
+	"sample": {Name: "sample", Description: "Audio samples", Extensions: []string{".wav", ".mp3", ".aif", ".aiff", ".flac", ".zip"}, MaxSize: 50 << 20,
+		ContentKinds: []string{security.KindWAV, security.KindMP3, security.KindAIFF, security.KindFLAC, security.KindZIP}},
+	"preset": {Name: "preset", Description: "Synth and effect presets", Extensions: []string{".fxp", ".vstpreset", ".h2p", ".adg", ".zip"}, MaxSize: 5 << 20,
+		ContentKinds: []string{security.KindFXP, security.KindVSTPreset, security.KindText, security.KindGzip, security.KindZIP}},
+	"plugin": {Name: "plugin", Description: "Audio plugins", Extensions: []string{".vst", ".vst3", ".dll", ".component"}, MaxSize: 50 << 20,
+		ContentKinds: []string{security.KindPE, security.KindMachO, security.KindZIP}},
+	"template": {Name: "template", Description: "DAW project templates", Extensions: []string{".als", ".logic", ".ptx", ".flp", ".zip"}, MaxSize: 50 << 20,
+		ContentKinds: []string{security.KindGzip, security.KindZIP, security.KindFLP, security.KindUnknown}},
+	"midi": {Name: "midi", Description: "MIDI files", Extensions: []string{".mid", ".midi"}, MaxSize: 1 << 20,
+		ContentKinds: []string{security.KindMIDI}},
+	"document": {Name: "document", Description: "Documents", Extensions: []string{".pdf", ".doc", ".docx", ".txt"}, MaxSize: 20 << 20,
+		ContentKinds: []string{security.KindPDF, security.KindOLE, security.KindZIP, security.KindText}},
+}
+
+// extensionKinds associe chaque extension aux contenus qui lui correspondent :
+// un fichier dont le contenu contredit l'extension est refusé
+var extensionKinds = map[string][]string{
+	".wav":       {security.KindWAV},
+	".mp3":       {security.KindMP3},
+	".aif":       {security.KindAIFF},
+	".aiff":      {security.KindAIFF},
+	".flac":      {security.KindFLAC},
+	".zip":       {security.KindZIP},
+	".fxp":       {security.KindFXP},
+	".vstpreset": {security.KindVSTPreset},
+	".h2p":       {security.KindText},
+	".adg":       {security.KindGzip},
+	".vst":       {security.KindMachO, security.KindZIP},
+	".vst3":      {security.KindPE, security.KindMachO, security.KindZIP},
+	".dll":       {security.KindPE},
+	".component": {security.KindMachO, security.KindZIP},
+	".als":       {security.KindGzip},
+	".logic":     {security.KindZIP, security.KindUnknown},
+	".ptx":       {security.KindUnknown},
+	".flp":       {security.KindFLP},
+	".mid":       {security.KindMIDI},
+	".midi":      {security.KindMIDI},
+	".pdf":       {security.KindPDF},
+	".doc":       {security.KindOLE},
+	".docx":      {security.KindZIP},
+	".txt":       {security.KindText},
+}
+
+// CreateSharedResourceRequest represents the metadata of an uploaded resource
+type CreateSharedResourceRequest struct {
+	Title       string
+	Type        string
+	Description string
+	Tags        []string
+	IsPublic    bool
+	PublishAt   *time.Time
+	UploaderID  int
+
+	// Notes de la version 1
+	ReleaseNotes string
+	Size         int64
+
+	// Reviewed indique un fichier libéré de quarantaine par un administrateur
+	Reviewed bool
+
+	FileAnalysis
+}
+
+// FileAnalysis contient l'analyse d'un fichier réalisée à l'upload
+type FileAnalysis struct {
+	ArchiveEntries []ArchiveEntryInfo
+	MIDI           *models.MIDIInfo
+	Sample         *models.SampleInfo
+}
+
+// CreateVersionRequest represents a new version of an existing resource
+type CreateVersionRequest struct {
+	ResourceID   int
+	UserID       int
+	ReleaseNotes string
+	Size         int64
+	Reviewed     bool
+
+	FileAnalysis
+}
+
+// UpdateSharedResourceRequest represents a request to update a resource
+type UpdateSharedResourceRequest struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Type        *string   `json:"type,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	IsPublic    *bool     `json:"is_public,omitempty"`
+}
+
+// ReviewRequest represents a rating with an optional review text
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+// Ordres de tri des listes de ressources
+const (
+	SortRecent    = "recent"
+	SortDownloads = "downloads"
+	SortRating    = "rating"
+)
+
+var sortClauses = map[string]string{
+	SortRecent:    " ORDER BY r.uploaded_at DESC",
+	SortDownloads: " ORDER BY r.download_count DESC, r.uploaded_at DESC",
+	SortRating:    " ORDER BY r.rating_average DESC, r.rating_count DESC, r.uploaded_at DESC",
+}
+
+// orderClause retourne la clause de tri demandée, ou celle par défaut
+func orderClause(sort, fallback string) string {
+	if clause, ok := sortClauses[sort]; ok {
+		return clause
+	}
+	return sortClauses[fallback]
+}
+
+// SearchFilter represents the filters of a resource search
+type SearchFilter struct {
+	Query    string
+	Type     string
+	Tag      string
+	Uploader string
+	Sort     string
+	Limit    int
+
+	// Filtres MIDI (tempo et tonalité s'appliquent aussi aux samples)
+	MinTempo      float64
+	MaxTempo      float64
+	Key           string
+	TimeSignature string
+	MinBars       float64
+	MaxBars       float64
+
+	// Filtres samples
+	SampleRate  int
+	BitDepth    int
+	Channels    int
+	MinDuration float64
+	MaxDuration float64
+	Looped      *bool
+}
@@ -2,10 +2,18 @@
 package track
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"github.com/okinrev/veza-web-app/internal/utils/response"  // ADD THIS
     "github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,11 +35,15 @@ func (h *Handler) AddTrackWithUpload(c *gin.Context) {
 	}
 
 	// Récupérer les données du formulaire
-	title := c.PostForm("title")
-	artist := c.PostForm("artist")
-	tags := c.PostForm("tags")
+	req := CreateTrackRequest{
+		Title:      strings.TrimSpace(c.PostForm("title")),
+		Artist:     strings.TrimSpace(c.PostForm("artist")),
+		Tags:       parseTags(c.PostForm("tags")),
+		IsPublic:   c.DefaultPostForm("is_public", "true") != "false",
+		UploaderID: userID,
+	}
 
-	if title == "" {
+	if req.Title == "" {
 		response.ErrorJSON(c.Writer, "Title is required", http.StatusBadRequest)
 		return
 	}
@@ -44,21 +56,62 @@ func (h *Handler) AddTrackWithUpload(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// TODO: Implémenter la logique de sauvegarde du fichier
-	// Pour l'instant, réponse de placeholder
-	track := map[string]interface{}{
-		"id":          1,
-		"title":       title,
-		"artist":      artist,
-		"tags":        tags,
-		"filename":    fileHeader.Filename,
-		"uploader_id": userID,
-		"created_at":  "2025-01-01T00:00:00Z",
+	if err := ValidateAudioFile(fileHeader.Filename, fileHeader.Size); err != nil {
+		status := http.StatusUnsupportedMediaType
+		if errors.Is(err, ErrAudioTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		response.ErrorJSON(c.Writer, err.Error(), status)
+		return
+	}
+	if err := h.service.quotas.Check(userID, fileHeader.Size); err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	tmpPath, _, err := storage.Spool(file, fileHeader.Filename)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to store audio file", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmpPath)
+
+	track, err := h.service.CreateTrackFromFile(req, tmpPath, fileHeader.Filename)
+	if err != nil {
+		writeUploadError(c, err)
+		return
 	}
 
 	response.SuccessJSON(c.Writer, track, "Track uploaded successfully")
 }
 
+// writeUploadError signale un fichier refusé ou mis en quarantaine et un
+// dépassement du quota de stockage, les autres erreurs étant des échecs d'enregistrement
+func writeUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, quota.ErrQuotaExceeded), errors.Is(err, ErrAudioTooLarge):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, security.ErrQuarantined):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusAccepted)
+	case errors.Is(err, security.ErrContentMismatch):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		utils.LogError(fmt.Sprintf("Failed to create track: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to create track", http.StatusInternalServerError)
+	}
+}
+
+// parseTags découpe une liste de tags séparés par des virgules
+func parseTags(raw string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 // ListTracks liste toutes les pistes
 func (h *Handler) ListTracks(c *gin.Context) {
 	// TODO: Implémenter la récupération depuis la base de données
@@ -148,9 +201,18 @@ func (h *Handler) DeleteTrack(c *gin.Context) {
 		return
 	}
 
-	// TODO: Vérifier propriétaire + suppression BDD + fichier
-	_ = trackID
-	_ = userID
+	if err := h.service.DeleteTrack(trackID, userID); err != nil {
+		switch {
+		case errors.Is(err, ErrTrackNotFound):
+			response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotOwner):
+			response.ErrorJSON(c.Writer, err.Error(), http.StatusForbidden)
+		default:
+			utils.LogError(fmt.Sprintf("Failed to delete track: %v", err))
+			response.ErrorJSON(c.Writer, "Failed to delete track", http.StatusInternalServerError)
+		}
+		return
+	}
 
 	response.SuccessJSON(c.Writer, nil, "Track deleted successfully")
 }
\ No newline at end of file
@@ -1,19 +1,261 @@
 package track
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/okinrev/veza-web-app/internal/blob"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+const (
+	// MaxAudioSize est la taille maximale d'un fichier audio envoyé en une seule requête
+	MaxAudioSize = 100 << 20 // 100MB
+)
+
+var (
+	ErrUnsupportedAudio = errors.New("unsupported audio format")
+	ErrAudioTooLarge    = errors.New("audio file exceeds the maximum size")
+	ErrTrackNotFound    = errors.New("track not found")
+	ErrNotOwner         = errors.New("not authorized to modify this track")
 )
 
+// audioExtensions associe chaque extension audio acceptée au contenu attendu
+var audioExtensions = map[string]string{
+	".mp3":  security.KindMP3,
+	".wav":  security.KindWAV,
+	".flac": security.KindFLAC,
+	".ogg":  security.KindOgg,
+	".aif":  security.KindAIFF,
+	".aiff": security.KindAIFF,
+	".m4a":  security.KindMP4,
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// CreateTrackRequest represents the metadata of an uploaded track
+type CreateTrackRequest struct {
+	Title      string
+	Artist     string
+	Tags       []string
+	IsPublic   bool
+	UploaderID int
+
+	// Reviewed indique un fichier libéré de quarantaine par un administrateur
+	Reviewed bool
+}
+
 type Service struct {
 	db        *database.DB
 	jwtSecret string
+	inspector *security.Inspector
+	quotas    *quota.Manager
+	store     *blob.Store
 }
 
-func NewService(db *database.DB, jwtSecret string) *Service {
+func NewService(db *database.DB, jwtSecret string, inspector *security.Inspector, quotas *quota.Manager, store *blob.Store) *Service {
 	return &Service{
 		db:        db,
 		jwtSecret: jwtSecret,
+		inspector: inspector,
+		quotas:    quotas,
+		store:     store,
+	}
+}
+
+// ValidateAudioFile vérifie l'extension du fichier audio et sa taille (ignorée si 0)
+func ValidateAudioFile(filename string, size int64) error {
+	if _, ok := audioExtensions[strings.ToLower(filepath.Ext(filename))]; !ok {
+		return ErrUnsupportedAudio
+	}
+	if size > MaxAudioSize {
+		return ErrAudioTooLarge
+	}
+	return nil
+}
+
+// storedFilename génère un nom de fichier unique et sans danger pour le stockage
+func storedFilename(userID int, original string) string {
+	base := unsafeFilenameChars.ReplaceAllString(filepath.Base(original), "_")
+	base = strings.Trim(base, "._")
+	if base == "" {
+		base = "track"
+	}
+	return fmt.Sprintf("%d_%d_%s", userID, time.Now().UnixNano(), base)
+}
+
+// inspectAudio vérifie que le contenu d'un fichier audio reçu correspond à son
+// extension et le soumet au scanner
+func (s *Service) inspectAudio(path string, req CreateTrackRequest, originalName string) error {
+	kind := audioExtensions[strings.ToLower(filepath.Ext(originalName))]
+	return s.inspector.Inspect(path, []string{kind}, security.Submission{
+		UserID:   req.UploaderID,
+		Target:   security.TargetTrack,
+		Filename: originalName,
+		Metadata: map[string]string{
+			"title":     req.Title,
+			"artist":    req.Artist,
+			"tags":      strings.Join(req.Tags, ","),
+			"is_public": strconv.FormatBool(req.IsPublic),
+		},
+		Reviewed: req.Reviewed,
+	})
+}
+
+// CreateTrack enregistre une piste dont le fichier est déjà stocké, dans la
+// limite du quota de stockage de l'utilisateur
+func (s *Service) CreateTrack(req CreateTrackRequest, filename string, size int64) (*models.Track, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.quotas.Reserve(tx, req.UploaderID, size); err != nil {
+		return nil, err
+	}
+
+	t := models.Track{
+		Title:      req.Title,
+		Artist:     req.Artist,
+		Filename:   filename,
+		Tags:       req.Tags,
+		IsPublic:   req.IsPublic,
+		UploaderID: req.UploaderID,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO tracks (title, artist, filename, file_size, tags, is_public, uploader_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, req.Title, req.Artist, filename, size, pq.Array(req.Tags), req.IsPublic, req.UploaderID).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create track: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit track: %w", err)
 	}
+	t.UpdatedAt = t.CreatedAt
+	return &t, nil
 }
 
-// TODO: Implement methods based on doc_track_handler.md
\ No newline at end of file
+// audioKey retourne la clé de stockage d'un fichier audio
+func audioKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceAudio, filepath.Base(filename))
+}
+
+// CreateTrackFromFile contrôle un fichier déjà reçu (formulaire ou upload
+// reprenable), le copie dans le stockage audio puis crée la piste. Le fichier
+// source est supprimé si la piste est créée.
+func (s *Service) CreateTrackFromFile(req CreateTrackRequest, srcPath, originalName string) (*models.Track, error) {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat audio file: %w", err)
+	}
+	if err := ValidateAudioFile(originalName, info.Size()); err != nil {
+		return nil, err
+	}
+	if err := s.inspectAudio(srcPath, req, originalName); err != nil {
+		return nil, err
+	}
+
+	filename := storedFilename(req.UploaderID, originalName)
+	key, err := audioKey(filename)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.store.PutFile(key, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store audio file: %w", err)
+	}
+
+	t, err := s.CreateTrack(req, filename, size)
+	if err != nil {
+		s.store.Remove(key)
+		return nil, err
+	}
+	os.Remove(srcPath)
+	return t, nil
+}
+
+// DeleteTrack supprime une piste (propriétaire uniquement) et libère son fichier
+func (s *Service) DeleteTrack(trackID, userID int) error {
+	var ownerID int
+	var filename string
+	err := s.db.QueryRow("SELECT uploader_id, filename FROM tracks WHERE id = $1", trackID).Scan(&ownerID, &filename)
+	if err == sql.ErrNoRows {
+		return ErrTrackNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+	if ownerID != userID {
+		return ErrNotOwner
+	}
+
+	if _, err := s.db.Exec("DELETE FROM tracks WHERE id = $1", trackID); err != nil {
+		return fmt.Errorf("failed to delete track: %w", err)
+	}
+
+	if key, err := audioKey(filename); err == nil {
+		s.store.Remove(key)
+	}
+	return nil
+}
+
+// BackfillSizes renseigne la taille des fichiers audio des pistes créées avant
+// le suivi de l'espace de stockage
+func (s *Service) BackfillSizes() {
+	rows, err := s.db.Query("SELECT id, filename FROM tracks WHERE file_size = 0")
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Track size backfill: %v", err))
+		return
+	}
+	type pending struct {
+		id       int
+		filename string
+	}
+	var tracks []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.filename); err != nil {
+			rows.Close()
+			utils.LogError(fmt.Sprintf("Track size backfill: %v", err))
+			return
+		}
+		tracks = append(tracks, p)
+	}
+	rows.Close()
+
+	updated := 0
+	for _, p := range tracks {
+		key, err := audioKey(p.filename)
+		if err != nil {
+			continue
+		}
+		info, err := s.store.Stat(key)
+		if err != nil || info.Size == 0 {
+			continue
+		}
+		if _, err := s.db.Exec("UPDATE tracks SET file_size = $1 WHERE id = $2", info.Size, p.id); err != nil {
+			utils.LogError(fmt.Sprintf("Track size backfill: %v", err))
+			return
+		}
+		updated++
+	}
+	if updated > 0 {
+		utils.LogInfo(fmt.Sprintf("Track size backfill: %d piste(s) mise(s) à jour", updated))
+	}
+}
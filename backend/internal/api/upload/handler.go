@@ -0,0 +1,201 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
+	"github.com/okinrev/veza-web-app/internal/api/track"
+	"github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/utils/response"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrUploadNotFound):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrUploadExpired):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusGone)
+	case errors.Is(err, ErrOffsetMismatch), errors.Is(err, ErrUploadCompleted):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrUploadLocked):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusLocked)
+	case errors.Is(err, ErrUploadTooLarge), errors.Is(err, quota.ErrQuotaExceeded),
+		errors.Is(err, track.ErrAudioTooLarge), errors.Is(err, shared_resources.ErrFileTooLarge):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, ErrChecksumMismatch):
+		response.ErrorJSON(c.Writer, err.Error(), StatusChecksumMismatch)
+	case errors.Is(err, ErrProcessingFailed):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, track.ErrUnsupportedAudio), errors.Is(err, shared_resources.ErrUnsupportedFile):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrInvalidLength), errors.Is(err, ErrInvalidMetadata), errors.Is(err, ErrInvalidTarget),
+		errors.Is(err, ErrMissingFilename), errors.Is(err, ErrUnsupportedChecksum),
+		errors.Is(err, shared_resources.ErrUnknownResourceType):
+		response.ErrorJSON(c.Writer, err.Error(), http.StatusBadRequest)
+	default:
+		utils.LogError(fmt.Sprintf("%s: %v", fallback, err))
+		response.ErrorJSON(c.Writer, fallback, http.StatusInternalServerError)
+	}
+}
+
+// TusResumable ajoute l'en-tête Tus-Resumable aux réponses et refuse les
+// clients parlant une autre version du protocole
+func TusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", TusVersion)
+
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != TusVersion {
+			c.Header("Tus-Version", TusVersion)
+			c.AbortWithStatus(http.StatusPreconditionFailed)
+			return
+		}
+		c.Next()
+	}
+}
+
+// setUploadHeaders renseigne l'état d'un upload dans les en-têtes tus
+func setUploadHeaders(c *gin.Context, u *models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// Options décrit les capacités du serveur tus
+func (h *Handler) Options(c *gin.Context) {
+	c.Header("Tus-Version", TusVersion)
+	c.Header("Tus-Extension", TusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.service.MaxSize(), 10))
+	c.Header("Tus-Checksum-Algorithm", TusChecksumAlgorithms)
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload réserve un nouvel upload (extension creation)
+func (h *Handler) CreateUpload(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidLength, "Failed to create upload")
+		return
+	}
+
+	u, err := h.service.CreateUpload(userID, length, c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		writeError(c, err, "Failed to create upload")
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+u.ID)
+	setUploadHeaders(c, u)
+	c.Status(http.StatusCreated)
+}
+
+// HeadUpload retourne l'offset courant pour reprendre un upload
+func (h *Handler) HeadUpload(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.service.GetUpload(c.Param("id"), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUploadNotFound):
+			c.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, ErrUploadExpired):
+			c.AbortWithStatus(http.StatusGone)
+		default:
+			utils.LogError(fmt.Sprintf("Failed to get upload: %v", err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	c.Header("Upload-Metadata", encodeMetadata(u.Metadata))
+	setUploadHeaders(c, u)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload écrit un morceau à l'offset courant
+func (h *Handler) PatchUpload(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		response.ErrorJSON(c.Writer, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		response.ErrorJSON(c.Writer, "Invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.service.WriteChunk(c.Param("id"), userID, offset, c.Request.Body, c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		writeError(c, err, "Failed to write upload chunk")
+		return
+	}
+
+	setUploadHeaders(c, u)
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateUpload abandonne un upload (extension termination)
+func (h *Handler) TerminateUpload(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.TerminateUpload(c.Param("id"), userID); err != nil {
+		writeError(c, err, "Failed to terminate upload")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUpload retourne l'état d'un upload et, une fois terminé, l'identifiant du contenu créé
+func (h *Handler) GetUpload(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.service.GetUpload(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err, "Failed to retrieve upload")
+		return
+	}
+
+	response.SuccessJSON(c.Writer, u, "Upload retrieved successfully")
+}
@@ -0,0 +1,32 @@
+package upload
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/okinrev/veza-web-app/internal/middleware"
+)
+
+func SetupRoutes(router *gin.RouterGroup, handler *Handler, jwtSecret string) {
+	uploads := router.Group("/uploads")
+	{
+		// Découverte des capacités du serveur tus
+		uploads.OPTIONS("", handler.Options)
+		uploads.OPTIONS("/:id", handler.Options)
+
+		// État d'un upload au format JSON de l'API
+		status := uploads.Group("")
+		status.Use(middleware.JWTAuthMiddleware(jwtSecret))
+		{
+			status.GET("/:id", handler.GetUpload)
+		}
+
+		// Protocole tus 1.0
+		tus := uploads.Group("")
+		tus.Use(TusResumable(), middleware.JWTAuthMiddleware(jwtSecret))
+		{
+			tus.POST("", handler.CreateUpload)
+			tus.HEAD("/:id", handler.HeadUpload)
+			tus.PATCH("/:id", handler.PatchUpload)
+			tus.DELETE("/:id", handler.TerminateUpload)
+		}
+	}
+}
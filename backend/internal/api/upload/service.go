@@ -0,0 +1,443 @@
+package upload
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/okinrev/veza-web-app/internal/api/shared_resources"
+	"github.com/okinrev/veza-web-app/internal/api/track"
+	"github.com/okinrev/veza-web-app/internal/config"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/security"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrUploadNotFound      = errors.New("upload not found")
+	ErrUploadExpired       = errors.New("upload has expired")
+	ErrUploadLocked        = errors.New("upload is being written by another request")
+	ErrUploadCompleted     = errors.New("upload is already complete")
+	ErrUploadTooLarge      = errors.New("upload exceeds the maximum size")
+	ErrInvalidLength       = errors.New("Upload-Length must be a positive integer")
+	ErrInvalidMetadata     = errors.New("invalid Upload-Metadata")
+	ErrInvalidTarget       = errors.New("metadata target must be track or shared_resource")
+	ErrMissingFilename     = errors.New("metadata filename is required")
+	ErrOffsetMismatch      = errors.New("Upload-Offset does not match the current offset")
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrProcessingFailed    = errors.New("upload received but could not be processed")
+)
+
+// validationErrors sont les refus de la cible dont le motif peut être renvoyé au client
+var validationErrors = []error{
+	track.ErrUnsupportedAudio,
+	track.ErrAudioTooLarge,
+	shared_resources.ErrUnknownResourceType,
+	shared_resources.ErrFileTooLarge,
+	shared_resources.ErrUnsupportedFile,
+	shared_resources.ErrInvalidArchive,
+	shared_resources.ErrArchiveTooLarge,
+	shared_resources.ErrUnsafeArchivePath,
+	shared_resources.ErrInvalidMIDI,
+	shared_resources.ErrInvalidSample,
+	security.ErrContentMismatch,
+	security.ErrQuarantined,
+	quota.ErrQuotaExceeded,
+}
+
+const selectUpload = `
+	SELECT id, user_id, target, filename, upload_length, upload_offset, metadata,
+	       expires_at, completed_at, result_id, error, created_at
+	FROM uploads
+`
+
+type Service struct {
+	db        *database.DB
+	resources *shared_resources.Service
+	tracks    *track.Service
+	quotas    *quota.Manager
+	cfg       config.UploadsConfig
+
+	// Un seul PATCH à la fois par upload
+	locks sync.Map
+}
+
+func NewService(db *database.DB, resources *shared_resources.Service, tracks *track.Service, quotas *quota.Manager, cfg config.UploadsConfig) *Service {
+	return &Service{
+		db:        db,
+		resources: resources,
+		tracks:    tracks,
+		quotas:    quotas,
+		cfg:       cfg,
+	}
+}
+
+// MaxSize retourne la taille maximale d'un upload annoncée par Tus-Max-Size
+func (s *Service) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+func (s *Service) filePath(id string) string {
+	return filepath.Join(s.cfg.Dir, id)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUpload(row rowScanner) (*models.Upload, error) {
+	var u models.Upload
+	var metadata []byte
+	err := row.Scan(&u.ID, &u.UserID, &u.Target, &u.Filename, &u.Length, &u.Offset, &metadata,
+		&u.ExpiresAt, &u.CompletedAt, &u.ResultID, &u.Error, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &u.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode upload metadata: %w", err)
+	}
+	return &u, nil
+}
+
+// validateTarget vérifie dès la création que le fichier annoncé, nom et taille,
+// sera accepté par sa cible
+func validateTarget(meta map[string]string, length int64) error {
+	filename := meta["filename"]
+	if filename == "" {
+		return ErrMissingFilename
+	}
+
+	switch meta["target"] {
+	case TargetTrack:
+		return track.ValidateAudioFile(filename, length)
+	case TargetSharedResource:
+		return shared_resources.ValidateResourceFile(meta["type"], filename, length)
+	default:
+		return ErrInvalidTarget
+	}
+}
+
+// CreateUpload réserve un upload de la taille annoncée et crée son fichier vide
+func (s *Service) CreateUpload(userID int, length int64, rawMetadata string) (*models.Upload, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+	if length > s.cfg.MaxSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	meta, err := parseMetadata(rawMetadata)
+	if err != nil {
+		return nil, ErrInvalidMetadata
+	}
+	if err := validateTarget(meta, length); err != nil {
+		return nil, err
+	}
+	// Refus immédiat plutôt qu'après l'envoi complet du fichier
+	if err := s.quotas.Check(userID, length); err != nil {
+		return nil, err
+	}
+
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload metadata: %w", err)
+	}
+
+	id := uuid.New().String()
+	if err := os.MkdirAll(s.cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	f, err := os.Create(s.filePath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	f.Close()
+
+	u, err := scanUpload(s.db.QueryRow(`
+		INSERT INTO uploads (id, user_id, target, filename, upload_length, metadata, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, user_id, target, filename, upload_length, upload_offset, metadata,
+		          expires_at, completed_at, result_id, error, created_at
+	`, id, userID, meta["target"], filepath.Base(meta["filename"]), length, metadata, time.Now().UTC().Add(s.cfg.Expiration)))
+	if err != nil {
+		os.Remove(s.filePath(id))
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	return u, nil
+}
+
+// GetUpload retourne un upload de l'utilisateur, s'il n'a pas expiré
+func (s *Service) GetUpload(id string, userID int) (*models.Upload, error) {
+	u, err := scanUpload(s.db.QueryRow(selectUpload+" WHERE id = $1 AND user_id = $2", id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	if !u.CompletedAt.Valid && time.Now().After(u.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	return u, nil
+}
+
+// newChecksum prépare la vérification d'un en-tête Upload-Checksum "algorithme empreinte_base64"
+func newChecksum(header string) (hash.Hash, []byte, error) {
+	parts := strings.Fields(header)
+	if len(parts) != 2 {
+		return nil, nil, ErrUnsupportedChecksum
+	}
+	expected, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, ErrUnsupportedChecksum
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "sha1":
+		return sha1.New(), expected, nil
+	case "sha256":
+		return sha256.New(), expected, nil
+	case "md5":
+		return md5.New(), expected, nil
+	default:
+		return nil, nil, ErrUnsupportedChecksum
+	}
+}
+
+// WriteChunk ajoute un morceau à partir de l'offset annoncé par le client. Un
+// morceau dont l'empreinte ne correspond pas est entièrement écarté. Quand le
+// dernier octet est reçu, le fichier est transmis à la création de piste ou de ressource.
+func (s *Service) WriteChunk(id string, userID int, offset int64, body io.Reader, checksum string) (*models.Upload, error) {
+	lock, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, ErrUploadLocked
+	}
+	defer mu.Unlock()
+
+	u, err := s.GetUpload(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CompletedAt.Valid || u.Error.Valid {
+		return nil, ErrUploadCompleted
+	}
+	if offset != u.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	var sum hash.Hash
+	var expected []byte
+	if checksum != "" {
+		if sum, expected, err = newChecksum(checksum); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(s.filePath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+
+	// Écarte d'éventuels octets écrits par une requête interrompue
+	if err := f.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("failed to prepare upload file: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to prepare upload file: %w", err)
+	}
+
+	src := io.LimitReader(body, u.Length-offset)
+	if sum != nil {
+		src = io.TeeReader(src, sum)
+	}
+	written, copyErr := io.Copy(f, src)
+
+	if sum != nil && (copyErr != nil || string(sum.Sum(nil)) != string(expected)) {
+		f.Truncate(offset)
+		if copyErr != nil {
+			return nil, fmt.Errorf("failed to write chunk: %w", copyErr)
+		}
+		return nil, ErrChecksumMismatch
+	}
+
+	u.Offset = offset + written
+	// expires_at est un TIMESTAMP sans fuseau, comparé à NOW() par le nettoyage :
+	// les dates sont enregistrées en UTC
+	u.ExpiresAt = time.Now().UTC().Add(s.cfg.Expiration)
+	_, err = s.db.Exec(
+		"UPDATE uploads SET upload_offset = $1, expires_at = $2 WHERE id = $3",
+		u.Offset, u.ExpiresAt, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update upload offset: %w", err)
+	}
+	if copyErr != nil {
+		return u, fmt.Errorf("failed to write chunk: %w", copyErr)
+	}
+
+	if u.Offset == u.Length {
+		if err := f.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close upload file: %w", err)
+		}
+		if err := s.complete(u); err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+// complete transmet le fichier reçu à la création de piste ou de ressource partagée
+func (s *Service) complete(u *models.Upload) error {
+	meta := u.Metadata
+	title := strings.TrimSpace(meta["title"])
+	if title == "" {
+		title = strings.TrimSuffix(u.Filename, filepath.Ext(u.Filename))
+	}
+	tags := []string{}
+	for _, tag := range strings.Split(meta["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	isPublic := meta["is_public"] != "false"
+
+	var resultID int
+	var err error
+	switch u.Target {
+	case TargetTrack:
+		var t *models.Track
+		t, err = s.tracks.CreateTrackFromFile(track.CreateTrackRequest{
+			Title:      title,
+			Artist:     strings.TrimSpace(meta["artist"]),
+			Tags:       tags,
+			IsPublic:   isPublic,
+			UploaderID: u.UserID,
+		}, s.filePath(u.ID), u.Filename)
+		if t != nil {
+			resultID = t.ID
+		}
+	case TargetSharedResource:
+		var r *models.SharedResourceWithUploader
+		r, err = s.resources.CreateResourceFromFile(shared_resources.CreateSharedResourceRequest{
+			Title:        title,
+			Type:         meta["type"],
+			Description:  strings.TrimSpace(meta["description"]),
+			Tags:         tags,
+			IsPublic:     isPublic,
+			UploaderID:   u.UserID,
+			ReleaseNotes: strings.TrimSpace(meta["release_notes"]),
+		}, s.filePath(u.ID), u.Filename)
+		if r != nil {
+			resultID = r.ID
+		}
+	default:
+		err = ErrInvalidTarget
+	}
+
+	if err != nil {
+		reason := processingReason(err)
+		os.Remove(s.filePath(u.ID))
+		if _, dbErr := s.db.Exec("UPDATE uploads SET error = $1 WHERE id = $2", reason, u.ID); dbErr != nil {
+			utils.LogError(fmt.Sprintf("Failed to record upload %s failure: %v", u.ID, dbErr))
+		}
+		if reason == ErrProcessingFailed.Error() {
+			utils.LogError(fmt.Sprintf("Failed to process upload %s: %v", u.ID, err))
+		}
+		u.Error = sql.NullString{String: reason, Valid: true}
+		return fmt.Errorf("%w: %s", ErrProcessingFailed, reason)
+	}
+
+	now := time.Now().UTC()
+	_, err = s.db.Exec("UPDATE uploads SET completed_at = $1, result_id = $2 WHERE id = $3", now, resultID, u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	u.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	u.ResultID = sql.NullInt32{Int32: int32(resultID), Valid: true}
+	return nil
+}
+
+// processingReason ne révèle au client que les erreurs de validation du fichier
+func processingReason(err error) string {
+	for _, known := range validationErrors {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return ErrProcessingFailed.Error()
+}
+
+// TerminateUpload abandonne un upload et supprime les données reçues
+func (s *Service) TerminateUpload(id string, userID int) error {
+	result, err := s.db.Exec("DELETE FROM uploads WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrUploadNotFound
+	}
+
+	if err := os.Remove(s.filePath(id)); err != nil && !os.IsNotExist(err) {
+		utils.LogError(fmt.Sprintf("Failed to remove upload file %s: %v", id, err))
+	}
+	s.locks.Delete(id)
+	return nil
+}
+
+// CleanupExpired supprime les uploads expirés et leurs fichiers
+func (s *Service) CleanupExpired() (int, error) {
+	rows, err := s.db.Query("DELETE FROM uploads WHERE expires_at < NOW() RETURNING id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired uploads: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return count, fmt.Errorf("failed to scan expired upload: %w", err)
+		}
+		if err := os.Remove(s.filePath(id)); err != nil && !os.IsNotExist(err) {
+			utils.LogError(fmt.Sprintf("Failed to remove upload file %s: %v", id, err))
+		}
+		s.locks.Delete(id)
+		count++
+	}
+	return count, rows.Err()
+}
+
+// RunCleanup supprime périodiquement les uploads abandonnés
+func (s *Service) RunCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		n, err := s.CleanupExpired()
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Upload cleanup: %v", err))
+			continue
+		}
+		if n > 0 {
+			utils.LogInfo(fmt.Sprintf("Upload cleanup: %d upload(s) expiré(s) supprimé(s)", n))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package upload
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// TusVersion est la version du protocole tus implémentée
+	TusVersion = "1.0.0"
+
+	// TusExtensions liste les extensions tus supportées
+	TusExtensions = "creation,expiration,checksum,termination"
+
+	// TusChecksumAlgorithms liste les algorithmes acceptés dans Upload-Checksum
+	TusChecksumAlgorithms = "sha1,sha256,md5"
+
+	// Cibles d'un upload terminé
+	TargetTrack          = "track"
+	TargetSharedResource = "shared_resource"
+
+	// StatusChecksumMismatch est le code HTTP défini par l'extension checksum
+	StatusChecksumMismatch = 460
+)
+
+// parseMetadata décode l'en-tête Upload-Metadata : paires "clé valeur_base64" séparées par des virgules
+func parseMetadata(raw string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return meta, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		parts := strings.Fields(pair)
+		switch len(parts) {
+		case 1:
+			meta[parts[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, errors.New("invalid Upload-Metadata encoding")
+			}
+			meta[parts[0]] = string(value)
+		default:
+			return nil, errors.New("invalid Upload-Metadata pair")
+		}
+	}
+	return meta, nil
+}
+
+// encodeMetadata reconstruit l'en-tête Upload-Metadata
+func encodeMetadata(meta map[string]string) string {
+	pairs := make([]string, 0, len(meta))
+	for key, value := range meta {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
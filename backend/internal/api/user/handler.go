@@ -2,10 +2,17 @@
 package user
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 	"github.com/okinrev/veza-web-app/internal/utils/response"  // ADD THIS
     "github.com/okinrev/veza-web-app/internal/common"
+	"github.com/okinrev/veza-web-app/internal/quota"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -185,6 +192,15 @@ func (h *Handler) GetUserAvatar(c *gin.Context) {
 		return
 	}
 
+	// Avatar envoyé sur le serveur
+	if filename, err := h.service.GetAvatarFile(userID); err == nil && filename != "" {
+		if key, err := avatarKey(filename); err == nil {
+			if err := h.service.store.Serve(c.Writer, c.Request, key, storage.ServeOptions{Inline: true}); err == nil {
+				return
+			}
+		}
+	}
+
 	// ✅ Correct way to handle sql.NullString
 	if !user.Avatar.Valid || user.Avatar.String == "" {
 		response.ErrorJSON(c.Writer, "No avatar found", http.StatusNotFound)
@@ -194,3 +210,165 @@ func (h *Handler) GetUserAvatar(c *gin.Context) {
 	// Rediriger vers l'URL de l'avatar ou servir le fichier
 	c.Redirect(http.StatusFound, user.Avatar.String)
 }
+
+// GetPublicProfile retourne le profil public d'un utilisateur et sa réputation
+func (h *Handler) GetPublicProfile(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.service.GetPublicProfile(userID)
+	if errors.Is(err, ErrUserNotFound) {
+		response.ErrorJSON(c.Writer, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to get user profile: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to retrieve user profile", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, profile, "User profile retrieved successfully")
+}
+
+// GetUserRatings liste les évaluations reçues par un utilisateur après ses échanges
+func (h *Handler) GetUserRatings(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ratings, total, err := h.service.ListRatings(userID, page, limit)
+	if errors.Is(err, ErrUserNotFound) {
+		response.ErrorJSON(c.Writer, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to retrieve user ratings: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to retrieve user ratings", http.StatusInternalServerError)
+		return
+	}
+
+	meta := &response.Meta{
+		Page:       page,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+	response.PaginatedJSON(c.Writer, ratings, meta, "User ratings retrieved successfully")
+}
+
+// UploadAvatar remplace l'avatar de l'utilisateur connecté par une image envoyée
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("avatar")
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Avatar image is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > avatarMaxSize {
+		response.ErrorJSON(c.Writer, "Avatar image exceeds 2MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Le type est déterminé par le contenu et non par l'extension déclarée
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	ext, ok := avatarTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		response.ErrorJSON(c.Writer, "Avatar must be a JPEG, PNG or WebP image", http.StatusUnsupportedMediaType)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		response.ErrorJSON(c.Writer, "Failed to read avatar image", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("user_%d_%d%s", userID, time.Now().UnixNano(), ext)
+	key, err := avatarKey(filename)
+	if err != nil {
+		response.ErrorJSON(c.Writer, "Failed to store avatar image", http.StatusInternalServerError)
+		return
+	}
+	size, err := h.service.store.Put(key, io.LimitReader(file, avatarMaxSize))
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to store avatar file: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to store avatar image", http.StatusInternalServerError)
+		return
+	}
+
+	previous, err := h.service.SetAvatar(userID, filename, size)
+	if err != nil {
+		h.service.store.Remove(key)
+		if errors.Is(err, quota.ErrQuotaExceeded) {
+			response.ErrorJSON(c.Writer, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		utils.LogError(fmt.Sprintf("Failed to update avatar: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to update avatar", http.StatusInternalServerError)
+		return
+	}
+	h.service.removeAvatar(previous)
+
+	response.SuccessJSON(c.Writer, gin.H{"avatar": fmt.Sprintf(avatarURLFmt, userID)}, "Avatar updated successfully")
+}
+
+// DeleteAvatar supprime l'avatar de l'utilisateur connecté
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	previous, err := h.service.DeleteAvatar(userID)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to delete avatar: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to delete avatar", http.StatusInternalServerError)
+		return
+	}
+	h.service.removeAvatar(previous)
+
+	response.SuccessJSON(c.Writer, nil, "Avatar deleted successfully")
+}
+
+// GetMyStorage retourne l'espace de stockage utilisé par l'utilisateur connecté et son quota
+func (h *Handler) GetMyStorage(c *gin.Context) {
+	userID, exists := common.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorJSON(c.Writer, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.service.GetStorageUsage(userID)
+	if err != nil {
+		if errors.Is(err, quota.ErrUserNotFound) {
+			response.ErrorJSON(c.Writer, "User not found", http.StatusNotFound)
+			return
+		}
+		utils.LogError(fmt.Sprintf("Failed to retrieve storage usage: %v", err))
+		response.ErrorJSON(c.Writer, "Failed to retrieve storage usage", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessJSON(c.Writer, usage, "Storage usage retrieved successfully")
+}
@@ -39,6 +39,12 @@ func (rg *RouteGroup) registerPublicRoutes(router *gin.RouterGroup) {
 	
 	// GET /api/v1/users/:id/avatar - Avatar d'un utilisateur
 	router.GET("/:id/avatar", rg.handler.GetUserAvatar)
+
+	// GET /api/v1/users/:id/profile - Profil public et réputation sur la marketplace
+	router.GET("/:id/profile", rg.handler.GetPublicProfile)
+
+	// GET /api/v1/users/:id/ratings - Évaluations reçues après des échanges
+	router.GET("/:id/ratings", rg.handler.GetUserRatings)
 }
 
 // registerProtectedRoutes enregistre les routes protégées
@@ -55,6 +61,15 @@ func (rg *RouteGroup) registerProtectedRoutes(router *gin.RouterGroup) {
 		// PUT /api/v1/users/me/password - Changement de mot de passe
 		protected.PUT("/me/password", rg.handler.ChangePassword)
 		
+		// POST /api/v1/users/me/avatar - Envoi d'un avatar
+		protected.POST("/me/avatar", rg.handler.UploadAvatar)
+		
+		// DELETE /api/v1/users/me/avatar - Suppression de l'avatar
+		protected.DELETE("/me/avatar", rg.handler.DeleteAvatar)
+		
+		// GET /api/v1/users/me/storage - Espace de stockage utilisé et quota
+		protected.GET("/me/storage", rg.handler.GetMyStorage)
+		
 		// GET /api/v1/users/except-me - Liste des utilisateurs sauf l'utilisateur connecté
 		protected.GET("/except-me", rg.handler.GetUsersExceptMe)
 		
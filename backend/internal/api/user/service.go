@@ -3,22 +3,40 @@ package user
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/okinrev/veza-web-app/internal/utils"
+	"github.com/okinrev/veza-web-app/internal/blob"
 	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/quota"
 )
 
 // Service handles user business logic
 type Service struct {
-	db *database.DB
+	db     *database.DB
+	quotas *quota.Manager
+	store  *blob.Store
 }
 
 // NewService creates a new user service
-func NewService(db *database.DB) *Service {
+func NewService(db *database.DB, quotas *quota.Manager, store *blob.Store) *Service {
 	return &Service{
-		db: db,
+		db:     db,
+		quotas: quotas,
+		store:  store,
+	}
+}
+
+// removeAvatar supprime un avatar remplacé
+func (s *Service) removeAvatar(filename string) {
+	if filename == "" {
+		return
+	}
+	if key, err := avatarKey(filename); err == nil {
+		s.store.Remove(key)
 	}
 }
 
@@ -206,8 +224,13 @@ func (s *Service) UpdateUser(userID int, req UpdateUserRequest) (*UserResponse,
 		argIndex++
 	}
 	
+	// Une URL d'avatar remplace l'avatar envoyé sur le serveur
+	var previousAvatar sql.NullString
 	if req.Avatar != nil {
-		setParts = append(setParts, fmt.Sprintf("avatar = $%d", argIndex))
+		if err := s.db.QueryRow("SELECT avatar_file FROM users WHERE id = $1", userID).Scan(&previousAvatar); err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get user avatar: %w", err)
+		}
+		setParts = append(setParts, fmt.Sprintf("avatar = $%d", argIndex), "avatar_file = NULL", "avatar_size = 0")
 		args = append(args, req.Avatar)
 		argIndex++
 	}
@@ -261,10 +284,139 @@ func (s *Service) UpdateUser(userID int, req UpdateUserRequest) (*UserResponse,
 		}
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	s.removeAvatar(previousAvatar.String)
 	
 	return &user, nil
 }
 
+// SetAvatar enregistre l'avatar envoyé, dans la limite du quota de stockage,
+// et retourne l'ancien fichier à effacer
+func (s *Service) SetAvatar(userID int, filename string, size int64) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	var previousSize int64
+	err = tx.QueryRow(
+		"SELECT avatar_file, avatar_size FROM users WHERE id = $1 AND is_active = true FOR UPDATE", userID,
+	).Scan(&previous, &previousSize)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user avatar: %w", err)
+	}
+
+	// L'ancien avatar est remplacé : seul l'écart de taille est décompté
+	if err := s.quotas.Reserve(tx, userID, size-previousSize); err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET avatar = $1, avatar_file = $2, avatar_size = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, fmt.Sprintf(avatarURLFmt, userID), filename, size, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to update user avatar: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit avatar: %w", err)
+	}
+	return previous.String, nil
+}
+
+// DeleteAvatar retire l'avatar de l'utilisateur et retourne le fichier à effacer
+func (s *Service) DeleteAvatar(userID int) (string, error) {
+	var previous sql.NullString
+	err := s.db.QueryRow(`
+		UPDATE users u
+		SET avatar = NULL, avatar_file = NULL, avatar_size = 0, updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT id, avatar_file FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.avatar_file
+	`, userID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to delete user avatar: %w", err)
+	}
+	return previous.String, nil
+}
+
+// GetAvatarFile retourne l'avatar envoyé sur le serveur par un utilisateur, s'il existe
+func (s *Service) GetAvatarFile(userID int) (string, error) {
+	var filename sql.NullString
+	err := s.db.QueryRow("SELECT avatar_file FROM users WHERE id = $1 AND is_active = true", userID).Scan(&filename)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get user avatar: %w", err)
+	}
+	return filename.String, nil
+}
+
+// ErrUserNotFound signale un utilisateur inexistant ou désactivé
+var ErrUserNotFound = errors.New("user not found")
+
+// GetPublicProfile retourne le profil public d'un utilisateur et sa réputation sur la marketplace
+func (s *Service) GetPublicProfile(userID int) (*PublicProfile, error) {
+	var p PublicProfile
+	err := s.db.QueryRow(`
+		SELECT id, username, bio, avatar, created_at, rating_average, rating_count, completed_trades
+		FROM users
+		WHERE id = $1 AND is_active = true
+	`, userID).Scan(
+		&p.ID, &p.Username, &p.Bio, &p.Avatar, &p.CreatedAt,
+		&p.Reputation.RatingAverage, &p.Reputation.RatingCount, &p.Reputation.CompletedTrades,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	return &p, nil
+}
+
+// ListRatings retourne les évaluations reçues par un utilisateur après ses échanges,
+// les plus récentes d'abord
+func (s *Service) ListRatings(userID, page, limit int) ([]models.TradeRating, int, error) {
+	profile, err := s.GetPublicProfile(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT r.id, COALESCE(r.offer_id, 0), r.rater_id, u.username, COALESCE(u.avatar, ''), r.rated_user_id,
+		       r.role, r.rating, r.comment, r.created_at
+		FROM trade_ratings r
+		JOIN users u ON u.id = r.rater_id
+		WHERE r.rated_user_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve user ratings: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := []models.TradeRating{}
+	for rows.Next() {
+		var r models.TradeRating
+		if err := rows.Scan(&r.ID, &r.OfferID, &r.RaterID, &r.RaterUsername, &r.RaterAvatar, &r.RatedUserID,
+			&r.Role, &r.Rating, &r.Comment, &r.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user rating: %w", err)
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, profile.Reputation.RatingCount, rows.Err()
+}
+
+// GetStorageUsage retourne l'espace de stockage utilisé par l'utilisateur et son quota
+func (s *Service) GetStorageUsage(userID int) (*models.StorageUsage, error) {
+	return s.quotas.Usage(userID)
+}
+
 // DeleteUser soft deletes a user (sets is_active to false)
 func (s *Service) DeleteUser(userID int) error {
 	query := `
@@ -2,9 +2,31 @@ package user
 
 import (
 	"database/sql"
+	"path/filepath"
 	"time"
+
+	"github.com/okinrev/veza-web-app/internal/models"
+	"github.com/okinrev/veza-web-app/internal/storage"
+)
+
+const (
+	// Avatars envoyés sur le serveur
+	avatarMaxSize = 2 << 20 // 2MB
+	avatarURLFmt  = "/api/v1/users/%d/avatar"
 )
 
+// avatarTypes associe les types MIME acceptés pour un avatar à leur extension
+var avatarTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// avatarKey retourne la clé de stockage d'un avatar envoyé
+func avatarKey(filename string) (string, error) {
+	return storage.Key(storage.NamespaceAvatars, filepath.Base(filename))
+}
+
 // User represents a user with password (for auth)
 type User struct {
 	ID          int            `db:"id" json:"id"`
@@ -40,6 +62,16 @@ type UserResponse struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
+// PublicProfile represents the public information and marketplace reputation of a user
+type PublicProfile struct {
+	ID         int               `json:"id"`
+	Username   string            `json:"username"`
+	Bio        sql.NullString    `json:"bio,omitempty"`
+	Avatar     sql.NullString    `json:"avatar,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Reputation models.Reputation `json:"reputation"`
+}
+
 // CreateUserRequest represents a request to create a new user
 type CreateUserRequest struct {
 	Username  string `json:"username" binding:"required,min=3,max=50"`
@@ -0,0 +1,229 @@
+// internal/blob/blob.go
+package blob
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+// Store stocke les fichiers des modules une seule fois par contenu. Les modules
+// continuent d'utiliser des clés logiques (audio/<fichier>, shared/<fichier>...),
+// chacune pointant vers un blob nommé par l'empreinte SHA-256 de son contenu ;
+// les blobs comptent leurs références et ne sont effacés que par Collect.
+//
+// Les fichiers stockés avant l'adressage par contenu restent lus sous leur clé
+// logique tant qu'aucune référence n'existe pour elle.
+type Store struct {
+	db    *database.DB
+	store *storage.Store
+}
+
+func NewStore(db *database.DB, store *storage.Store) *Store {
+	return &Store{db: db, store: store}
+}
+
+// blobKey retourne la clé de stockage d'un blob, répartie sur 256 préfixes
+func blobKey(hash string) string {
+	return storage.NamespaceBlobs + "/" + hash[:2] + "/" + hash
+}
+
+// hashFile calcule l'empreinte et la taille d'un fichier local
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// PutFile stocke un fichier local sous une clé logique et retourne sa taille.
+// Un contenu déjà stocké n'est pas envoyé une seconde fois.
+func (s *Store) PutFile(key, localPath string) (int64, error) {
+	hash, size, err := hashFile(localPath)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.link(key, hash, size)
+	if err != nil {
+		return 0, err
+	}
+
+	// Un blob existant dont l'objet a disparu est renvoyé plutôt que de
+	// laisser la nouvelle référence pointer dans le vide
+	upload := created
+	if !created {
+		if _, err := s.store.Stat(blobKey(hash)); errors.Is(err, storage.ErrNotFound) {
+			upload = true
+		}
+	}
+	if upload {
+		if _, err := s.store.PutFile(blobKey(hash), localPath); err != nil {
+			s.Remove(key)
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// Put stocke le contenu reçu sous une clé logique et retourne sa taille
+func (s *Store) Put(key string, r io.Reader) (int64, error) {
+	tmpPath, _, err := storage.Spool(r, path.Base(key))
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+	return s.PutFile(key, tmpPath)
+}
+
+// link enregistre la référence d'une clé vers un blob, créé s'il n'existe pas.
+// Le verrou posé par Collect sur un blob en cours d'effacement fait attendre
+// l'insertion, qui recrée alors le blob.
+func (s *Store) link(key, hash string, size int64) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var created bool
+	err = tx.QueryRow(`
+		INSERT INTO blobs (hash, size, ref_count) VALUES ($1, $2, 1)
+		ON CONFLICT (hash) DO UPDATE SET ref_count = GREATEST(blobs.ref_count, 0) + 1
+		RETURNING xmax = 0
+	`, hash, size).Scan(&created)
+	if err != nil {
+		return false, fmt.Errorf("failed to record blob: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO blob_refs (key, hash) VALUES ($1, $2)", key, hash); err != nil {
+		return false, fmt.Errorf("failed to record blob reference: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit blob reference: %w", err)
+	}
+	return created, nil
+}
+
+// unlink supprime la référence d'une clé et retourne false si la clé ne
+// désigne pas un blob (fichier stocké avant l'adressage par contenu)
+func (s *Store) unlink(key string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hash string
+	err = tx.QueryRow("DELETE FROM blob_refs WHERE key = $1 RETURNING hash", key).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete blob reference: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE blobs SET ref_count = ref_count - 1 WHERE hash = $1", hash); err != nil {
+		return false, fmt.Errorf("failed to release blob: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit blob release: %w", err)
+	}
+	return true, nil
+}
+
+// Remove libère le fichier d'une clé logique ; le blob n'est effacé par Collect
+// qu'une fois sa dernière référence supprimée. Un échec est seulement journalisé.
+func (s *Store) Remove(key string) {
+	unlinked, err := s.unlink(key)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("Failed to release stored file %s: %v", key, err))
+		return
+	}
+	if !unlinked {
+		s.store.Remove(key)
+	}
+}
+
+// resolve retourne la clé de stockage du contenu d'une clé logique
+func (s *Store) resolve(key string) (string, error) {
+	var hash string
+	err := s.db.QueryRow("SELECT hash FROM blob_refs WHERE key = $1", key).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return key, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve stored file: %w", err)
+	}
+	return blobKey(hash), nil
+}
+
+// object présente un blob sous sa clé logique, dont l'extension donne le type
+type object struct {
+	storage.Object
+	key string
+}
+
+func (o *object) Info() storage.ObjectInfo {
+	info := o.Object.Info()
+	info.Key = o.key
+	return info
+}
+
+// Open ouvre en lecture le fichier d'une clé logique
+func (s *Store) Open(key string) (storage.Object, error) {
+	storedKey, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.store.Open(storedKey)
+	if err != nil {
+		return nil, err
+	}
+	return &object{Object: obj, key: key}, nil
+}
+
+// Stat décrit le fichier d'une clé logique
+func (s *Store) Stat(key string) (storage.ObjectInfo, error) {
+	storedKey, err := s.resolve(key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info, err := s.store.Stat(storedKey)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info.Key = key
+	return info, nil
+}
+
+// Serve envoie le fichier d'une clé logique, avec le type déduit de son extension
+func (s *Store) Serve(w http.ResponseWriter, r *http.Request, key string, opts storage.ServeOptions) error {
+	storedKey, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	return s.store.Serve(w, r, storedKey, opts)
+}
@@ -0,0 +1,401 @@
+// internal/blob/gc.go
+package blob
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/okinrev/veza-web-app/internal/storage"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+// reference désigne les fichiers encore utilisés d'un espace de noms
+type reference struct {
+	namespace string
+	query     string // retourne les noms des fichiers référencés
+}
+
+// references liste les tables qui utilisent des fichiers stockés : un fichier
+// absent de toutes ces requêtes est orphelin. Une requête en échec interrompt
+// la collecte plutôt que de faire passer des fichiers utilisés pour orphelins.
+var references = []reference{
+	{storage.NamespaceAudio, "SELECT filename FROM tracks"},
+	{storage.NamespaceShared, "SELECT filename FROM shared_ressource_versions UNION SELECT filename FROM shared_ressources"},
+	{storage.NamespaceAvatars, "SELECT avatar_file FROM users WHERE avatar_file IS NOT NULL"},
+	{storage.NamespaceCollections, "SELECT cover_image FROM collections WHERE cover_image IS NOT NULL"},
+	{storage.NamespaceListings, "SELECT filename FROM listing_images UNION SELECT unnest(thumbnails) FROM listing_images"},
+	{storage.NamespaceDisputes, "SELECT filename FROM trade_dispute_evidence"},
+}
+
+// Types des éléments d'un rapport de collecte
+const (
+	ItemOrphanRef = "orphan_ref" // clé dont le contenu n'est plus référencé
+	ItemBlob      = "blob"       // blob sans référence
+	ItemStray     = "stray"      // objet de blob sans ligne correspondante
+	ItemLegacy    = "legacy"     // fichier stocké avant l'adressage par contenu
+)
+
+// ReportItem décrit un fichier libéré ou effacé par la collecte
+type ReportItem struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// Report résume une collecte ; en mode dry-run rien n'est modifié
+type Report struct {
+	DryRun         bool         `json:"dry_run"`
+	OrphanRefs     int          `json:"orphan_refs"`
+	RefCountsFixed int          `json:"ref_counts_fixed"`
+	Blobs          int          `json:"blobs"`
+	StrayObjects   int          `json:"stray_objects"`
+	LegacyFiles    int          `json:"legacy_files"`
+	Reclaimed      int64        `json:"reclaimed_bytes"`
+	Failures       int          `json:"failures"`
+	Items          []ReportItem `json:"items"`
+}
+
+func (r *Report) add(kind, key string, size int64, reclaimed bool) {
+	r.Items = append(r.Items, ReportItem{Kind: kind, Key: key, Size: size})
+	if reclaimed {
+		r.Reclaimed += size
+	}
+}
+
+// Summary retourne le résumé d'une ligne du rapport
+func (r *Report) Summary() string {
+	verb := "effacé(s)"
+	if r.DryRun {
+		verb = "à effacer"
+	}
+	return fmt.Sprintf("%d référence(s) orpheline(s), %d compteur(s) corrigé(s), %d blob(s), %d objet(s) isolé(s) et %d fichier(s) ancien(s) %s, %d octets libérés, %d échec(s)",
+		r.OrphanRefs, r.RefCountsFixed, r.Blobs, r.StrayObjects, r.LegacyFiles, verb, r.Reclaimed, r.Failures)
+}
+
+// referencedKeys retourne les clés logiques de tous les fichiers utilisés
+func (s *Store) referencedKeys() (map[string]bool, error) {
+	keys := map[string]bool{}
+	for _, ref := range references {
+		rows, err := s.db.Query(ref.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s references: %w", ref.namespace, err)
+		}
+		for rows.Next() {
+			var filename string
+			if err := rows.Scan(&filename); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s reference: %w", ref.namespace, err)
+			}
+			keys[ref.namespace+"/"+path.Base(filename)] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s references: %w", ref.namespace, err)
+		}
+	}
+	return keys, nil
+}
+
+// Collect libère les références des fichiers qui ne sont plus utilisés, puis
+// efface les blobs sans référence et les fichiers orphelins. Les références et
+// fichiers plus récents que grace sont épargnés : leur ligne peut être en cours
+// de création. En mode dry-run, le rapport liste ce qui serait effacé.
+func (s *Store) Collect(dryRun bool, grace time.Duration) (*Report, error) {
+	report := &Report{DryRun: dryRun, Items: []ReportItem{}}
+	cutoff := time.Now().Add(-grace)
+
+	referenced, err := s.referencedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	released, err := s.collectRefs(report, referenced, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fixRefCounts(report); err != nil {
+		return nil, err
+	}
+	if err := s.collectBlobs(report, released); err != nil {
+		return nil, err
+	}
+	if err := s.collectStrays(report, cutoff); err != nil {
+		return nil, err
+	}
+	if err := s.collectLegacy(report, referenced, cutoff); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// collectRefs supprime les références des clés qui ne sont plus utilisées et
+// retourne, en mode dry-run, le nombre de références qui seraient libérées par blob
+func (s *Store) collectRefs(report *Report, referenced map[string]bool, cutoff time.Time) (map[string]int, error) {
+	rows, err := s.db.Query(`
+		SELECT r.key, r.hash, b.size
+		FROM blob_refs r
+		JOIN blobs b ON b.hash = r.hash
+		WHERE r.created_at < $1
+	`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blob references: %w", err)
+	}
+
+	type orphan struct {
+		key, hash string
+		size      int64
+	}
+	var orphans []orphan
+	for rows.Next() {
+		var o orphan
+		if err := rows.Scan(&o.key, &o.hash, &o.size); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan blob reference: %w", err)
+		}
+		if !referenced[o.key] {
+			orphans = append(orphans, o)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blob references: %w", err)
+	}
+
+	released := map[string]int{}
+	for _, o := range orphans {
+		if report.DryRun {
+			released[o.hash]++
+		} else if _, err := s.unlink(o.key); err != nil {
+			utils.LogError(fmt.Sprintf("Blob GC: %s: %v", o.key, err))
+			report.Failures++
+			continue
+		}
+		report.OrphanRefs++
+		report.add(ItemOrphanRef, o.key, o.size, false)
+	}
+	return released, nil
+}
+
+// refCountQuery compte les références réelles de chaque blob
+const refCountQuery = "(SELECT COUNT(*) FROM blob_refs r WHERE r.hash = b.hash)"
+
+// fixRefCounts aligne les compteurs de références sur les références enregistrées
+func (s *Store) fixRefCounts(report *Report) error {
+	if report.DryRun {
+		err := s.db.QueryRow("SELECT COUNT(*) FROM blobs b WHERE b.ref_count <> " + refCountQuery).Scan(&report.RefCountsFixed)
+		if err != nil {
+			return fmt.Errorf("failed to check blob reference counts: %w", err)
+		}
+		return nil
+	}
+
+	res, err := s.db.Exec("UPDATE blobs b SET ref_count = " + refCountQuery + " WHERE b.ref_count <> " + refCountQuery)
+	if err != nil {
+		return fmt.Errorf("failed to fix blob reference counts: %w", err)
+	}
+	fixed, _ := res.RowsAffected()
+	report.RefCountsFixed = int(fixed)
+	return nil
+}
+
+// collectBlobs efface les blobs sans référence. En mode dry-run, les
+// références orphelines de l'étape précédente sont décomptées.
+func (s *Store) collectBlobs(report *Report, released map[string]int) error {
+	hashes := make([]string, 0, len(released))
+	for hash := range released {
+		hashes = append(hashes, hash)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT b.hash, b.size, `+refCountQuery+`
+		FROM blobs b
+		WHERE b.ref_count <= 0 OR `+refCountQuery+` = 0 OR b.hash = ANY($1)
+	`, pq.Array(hashes))
+	if err != nil {
+		return fmt.Errorf("failed to list unreferenced blobs: %w", err)
+	}
+
+	type candidate struct {
+		hash string
+		size int64
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		var refs int
+		if err := rows.Scan(&c.hash, &c.size, &refs); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan blob: %w", err)
+		}
+		if refs-released[c.hash] <= 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to list unreferenced blobs: %w", err)
+	}
+
+	for _, c := range candidates {
+		if !report.DryRun {
+			deleted, err := s.deleteBlob(c.hash)
+			if err != nil {
+				utils.LogError(fmt.Sprintf("Blob GC: %s: %v", c.hash, err))
+				report.Failures++
+				continue
+			}
+			if !deleted {
+				continue
+			}
+		}
+		report.Blobs++
+		report.add(ItemBlob, blobKey(c.hash), c.size, true)
+	}
+	return nil
+}
+
+// deleteBlob efface un blob toujours sans référence. Le verrou sur sa ligne fait
+// attendre un envoi concurrent du même contenu jusqu'à la fin de l'effacement ;
+// la ligne est supprimée avant l'objet pour que l'effacement soit annulé si une
+// référence subsiste.
+func (s *Store) deleteBlob(hash string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var referenced bool
+	err = tx.QueryRow("SELECT ref_count > 0 FROM blobs WHERE hash = $1 FOR UPDATE", hash).Scan(&referenced)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock blob: %w", err)
+	}
+	if !referenced {
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM blob_refs WHERE hash = $1)", hash).Scan(&referenced)
+		if err != nil {
+			return false, fmt.Errorf("failed to check blob references: %w", err)
+		}
+	}
+	if referenced {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM blobs WHERE hash = $1", hash); err != nil {
+		return false, fmt.Errorf("failed to delete blob: %w", err)
+	}
+	if err := s.store.Delete(blobKey(hash)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit blob deletion: %w", err)
+	}
+	return true, nil
+}
+
+// collectStrays efface les objets de blobs sans ligne correspondante, laissés
+// par un envoi interrompu
+func (s *Store) collectStrays(report *Report, cutoff time.Time) error {
+	known := map[string]bool{}
+	rows, err := s.db.Query("SELECT hash FROM blobs")
+	if err != nil {
+		return fmt.Errorf("failed to list blobs: %w", err)
+	}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan blob: %w", err)
+		}
+		known[hash] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	var strays []storage.ObjectInfo
+	err = s.store.List(storage.NamespaceBlobs+"/", func(info storage.ObjectInfo) error {
+		if !known[path.Base(info.Key)] && info.ModTime.Before(cutoff) {
+			strays = append(strays, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, info := range strays {
+		if s.deleteObject(report, info) {
+			report.StrayObjects++
+			report.add(ItemStray, info.Key, info.Size, true)
+		}
+	}
+	return nil
+}
+
+// collectLegacy efface les fichiers stockés avant l'adressage par contenu qui
+// ne sont plus référencés
+func (s *Store) collectLegacy(report *Report, referenced map[string]bool, cutoff time.Time) error {
+	for _, ref := range references {
+		var orphans []storage.ObjectInfo
+		err := s.store.List(ref.namespace+"/", func(info storage.ObjectInfo) error {
+			if !referenced[info.Key] && info.ModTime.Before(cutoff) {
+				orphans = append(orphans, info)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, info := range orphans {
+			if s.deleteObject(report, info) {
+				report.LegacyFiles++
+				report.add(ItemLegacy, info.Key, info.Size, true)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteObject efface un objet du stockage, sauf en mode dry-run
+func (s *Store) deleteObject(report *Report, info storage.ObjectInfo) bool {
+	if report.DryRun {
+		return true
+	}
+	if err := s.store.Delete(info.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		utils.LogError(fmt.Sprintf("Blob GC: %s: %v", info.Key, err))
+		report.Failures++
+		return false
+	}
+	return true
+}
+
+// RunCollector lance la collecte à intervalle régulier
+func (s *Store) RunCollector(interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		report, err := s.Collect(false, grace)
+		if err != nil {
+			utils.LogError(fmt.Sprintf("Blob GC: %v", err))
+			continue
+		}
+		if len(report.Items) > 0 || report.RefCountsFixed > 0 || report.Failures > 0 {
+			utils.LogInfo("Blob GC: " + report.Summary())
+		}
+	}
+}
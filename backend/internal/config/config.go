@@ -11,6 +11,13 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Jobs     JobsConfig
+	Uploads  UploadsConfig
+	Security SecurityConfig
+	Quotas   QuotasConfig
+	Storage  StorageConfig
+
+	Marketplace MarketplaceConfig
 }
 
 type ServerConfig struct {
@@ -40,6 +47,76 @@ type JWTConfig struct {
 	RefreshTime    time.Duration
 }
 
+type JobsConfig struct {
+	PublicationInterval   time.Duration
+	UploadCleanupInterval time.Duration
+
+	// BlobGCInterval à 0 désactive la collecte périodique (cmd/blob-gc reste disponible)
+	BlobGCInterval time.Duration
+	BlobGCGrace    time.Duration
+
+	OfferExpiryInterval       time.Duration
+	SavedSearchDigestInterval time.Duration
+	ListingExpiryInterval     time.Duration
+}
+
+type SecurityConfig struct {
+	Scanner       string // none, clamd
+	ClamdNetwork  string
+	ClamdAddress  string
+	ScanTimeout   time.Duration
+	QuarantineDir string
+}
+
+// QuotasConfig définit l'espace de stockage alloué par rôle, en octets (0 : illimité)
+type QuotasConfig struct {
+	ByRole map[string]int64
+}
+
+// StorageConfig choisit le pilote de stockage des fichiers : disque local ou
+// service compatible S3 (AWS, MinIO...)
+type StorageConfig struct {
+	Driver string // local, s3
+
+	LocalRoot string
+	// PublicURL préfixe les URLs présignées du stockage local (vide : URL relative)
+	PublicURL       string
+	SignedURLSecret string
+
+	// PresignTTL > 0 : les téléchargements sont redirigés vers une URL présignée
+	PresignTTL time.Duration
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// MarketplaceConfig règle la durée de validité des annonces et des offres et les
+// prix suggérés
+type MarketplaceConfig struct {
+	ListingTTL      time.Duration // durée de vie d'une annonce, prolongée à chaque renouvellement
+	ListingReminder time.Duration // délai avant expiration auquel le propriétaire est prévenu
+	BumpInterval    time.Duration // délai minimal entre deux remontées d'une annonce
+
+	OfferTTL    time.Duration // validité par défaut d'une offre
+	OfferMaxTTL time.Duration // validité maximale demandée par l'auteur d'une offre
+
+	// OfferReminder : délai avant expiration auquel la partie qui doit répondre est prévenue
+	OfferReminder time.Duration
+
+	PriceWindow   time.Duration // ancienneté maximale des ventes servant à suggérer un prix
+	PriceMinSales int           // nombre minimal de ventes comparables pour suggérer un prix
+}
+
+type UploadsConfig struct {
+	Dir        string
+	MaxSize    int64
+	Expiration time.Duration
+}
+
 func New() *Config {
 	// Récupérer DATABASE_URL depuis l'environnement
 	databaseURL := getEnv("DATABASE_URL", "")
@@ -80,6 +157,58 @@ func New() *Config {
 			ExpirationTime: getDurationEnv("JWT_EXPIRATION", 24*time.Hour),
 			RefreshTime:    getDurationEnv("JWT_REFRESH_TIME", 7*24*time.Hour),
 		},
+		Jobs: JobsConfig{
+			PublicationInterval:       getDurationEnv("PUBLICATION_INTERVAL", time.Minute),
+			UploadCleanupInterval:     getDurationEnv("UPLOAD_CLEANUP_INTERVAL", time.Hour),
+			BlobGCInterval:            getDurationEnv("BLOB_GC_INTERVAL", 24*time.Hour),
+			BlobGCGrace:               getDurationEnv("BLOB_GC_GRACE", time.Hour),
+			OfferExpiryInterval:       getDurationEnv("OFFER_EXPIRY_INTERVAL", 5*time.Minute),
+			SavedSearchDigestInterval: getDurationEnv("SAVED_SEARCH_DIGEST_INTERVAL", time.Hour),
+			ListingExpiryInterval:     getDurationEnv("LISTING_EXPIRY_INTERVAL", 15*time.Minute),
+		},
+		Uploads: UploadsConfig{
+			Dir:        getEnv("UPLOAD_DIR", "static/uploads"),
+			MaxSize:    int64(getIntEnv("UPLOAD_MAX_SIZE_MB", 4096)) << 20,
+			Expiration: getDurationEnv("UPLOAD_EXPIRATION", 24*time.Hour),
+		},
+		Security: SecurityConfig{
+			Scanner:       getEnv("FILE_SCANNER", "none"),
+			ClamdNetwork:  getEnv("CLAMD_NETWORK", "unix"),
+			ClamdAddress:  getEnv("CLAMD_ADDRESS", "/var/run/clamav/clamd.ctl"),
+			ScanTimeout:   getDurationEnv("FILE_SCAN_TIMEOUT", 2*time.Minute),
+			QuarantineDir: getEnv("QUARANTINE_DIR", "static/quarantine"),
+		},
+		Quotas: QuotasConfig{
+			ByRole: map[string]int64{
+				"user":        int64(getIntEnv("STORAGE_QUOTA_USER_MB", 2048)) << 20,
+				"moderator":   int64(getIntEnv("STORAGE_QUOTA_MODERATOR_MB", 10240)) << 20,
+				"admin":       int64(getIntEnv("STORAGE_QUOTA_ADMIN_MB", 0)) << 20,
+				"super_admin": int64(getIntEnv("STORAGE_QUOTA_ADMIN_MB", 0)) << 20,
+			},
+		},
+		Storage: StorageConfig{
+			Driver:          getEnv("STORAGE_DRIVER", "local"),
+			LocalRoot:       getEnv("STORAGE_LOCAL_ROOT", "static"),
+			PublicURL:       getEnv("STORAGE_PUBLIC_URL", ""),
+			SignedURLSecret: getEnv("SIGNED_URL_SECRET", getEnv("JWT_SECRET", "your-super-secret-key-change-in-production")),
+			PresignTTL:      getDurationEnv("STORAGE_PRESIGN_TTL", 0),
+			S3Endpoint:      getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Region:        getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:        getEnv("S3_BUCKET", ""),
+			S3AccessKey:     getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:     getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle:     getBoolEnv("S3_PATH_STYLE", true),
+		},
+		Marketplace: MarketplaceConfig{
+			ListingTTL:      getDurationEnv("LISTING_TTL", 60*24*time.Hour),
+			ListingReminder: getDurationEnv("LISTING_REMINDER", 3*24*time.Hour),
+			BumpInterval:    getDurationEnv("LISTING_BUMP_INTERVAL", 24*time.Hour),
+			OfferTTL:        getDurationEnv("OFFER_TTL", 7*24*time.Hour),
+			OfferMaxTTL:     getDurationEnv("OFFER_MAX_TTL", 30*24*time.Hour),
+			OfferReminder:   getDurationEnv("OFFER_REMINDER", 24*time.Hour),
+			PriceWindow:     getDurationEnv("PRICE_SUGGESTION_WINDOW", 180*24*time.Hour),
+			PriceMinSales:   getIntEnv("PRICE_SUGGESTION_MIN_SALES", 3),
+		},
 	}
 }
 
@@ -99,6 +228,15 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
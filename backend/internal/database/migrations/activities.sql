@@ -0,0 +1,13 @@
+--file: backend/db/migrations/activities.sql
+
+CREATE TABLE IF NOT EXISTS activities (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type TEXT NOT NULL, -- "track_published", "resource_published", etc.
+  object_type TEXT NOT NULL, -- "track", "shared_resource", etc.
+  object_id INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activities_user_id ON activities(user_id);
+CREATE INDEX IF NOT EXISTS idx_activities_created_at ON activities(created_at);
@@ -0,0 +1,19 @@
+--file: backend/db/migrations/blobs.sql
+
+-- Fichiers stockés une seule fois par contenu (SHA-256)
+CREATE TABLE IF NOT EXISTS blobs (
+  hash CHAR(64) PRIMARY KEY,
+  size BIGINT NOT NULL CHECK (size >= 0),
+  ref_count INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Clés logiques (audio/..., shared/...) pointant vers un blob
+CREATE TABLE IF NOT EXISTS blob_refs (
+  key TEXT PRIMARY KEY,
+  hash CHAR(64) NOT NULL REFERENCES blobs(hash),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_blob_refs_hash ON blob_refs(hash);
+CREATE INDEX IF NOT EXISTS idx_blobs_unreferenced ON blobs(hash) WHERE ref_count <= 0;
@@ -0,0 +1,37 @@
+--file: backend/db/migrations/collections.sql
+
+CREATE TABLE IF NOT EXISTS collections (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  cover_image TEXT,
+  is_public BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_collections_user ON collections(user_id);
+CREATE INDEX IF NOT EXISTS idx_collections_public ON collections(is_public, created_at DESC);
+
+-- Éléments d'une collection : pistes ou ressources partagées, ordonnés par position
+CREATE TABLE IF NOT EXISTS collection_items (
+  id SERIAL PRIMARY KEY,
+  collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+  item_type TEXT NOT NULL CHECK (item_type IN ('track', 'shared_resource')),
+  item_id INTEGER NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (collection_id, item_type, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_items_collection ON collection_items(collection_id, position);
+
+CREATE TABLE IF NOT EXISTS collection_followers (
+  collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (collection_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_followers_user ON collection_followers(user_id);
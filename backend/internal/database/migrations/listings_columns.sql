@@ -0,0 +1,9 @@
+--file: backend/db/migrations/listings_columns.sql
+
+-- Date de dernière modification d'une annonce
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_listings_user_id ON listings(user_id);
+CREATE INDEX IF NOT EXISTS idx_listings_product_id ON listings(product_id);
+CREATE INDEX IF NOT EXISTS idx_listings_status_created_at ON listings(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_offers_listing_id ON offers(listing_id);
@@ -0,0 +1,19 @@
+--file: backend/db/migrations/listings_images.sql
+
+-- Photos d'une annonce, envoyées sur le serveur avec leurs miniatures
+CREATE TABLE IF NOT EXISTS listing_images (
+  id SERIAL PRIMARY KEY,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL UNIQUE,
+  thumbnails TEXT[] NOT NULL DEFAULT '{}', -- une miniature par taille, vide si non générées
+  content_type TEXT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0, -- original et miniatures, pour les quotas de stockage
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_images_listing_id ON listing_images(listing_id, position);
+CREATE INDEX IF NOT EXISTS idx_listing_images_user_id ON listing_images(user_id);
@@ -0,0 +1,15 @@
+--file: backend/db/migrations/listings_lifecycle.sql
+
+-- Fin de vie des annonces ouvertes, rappel avant expiration et dernière remontée
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS bumped_at TIMESTAMP;
+
+-- Tri par date de publication ou de dernière remontée
+CREATE INDEX IF NOT EXISTS idx_listings_recency ON listings((COALESCE(bumped_at, created_at)) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_listings_expires_at ON listings(expires_at) WHERE status = 'open';
+
+-- Les annonces ouvertes existantes reçoivent une durée de vie complète, celle
+-- de LISTING_TTL par défaut
+UPDATE listings SET expires_at = NOW() + INTERVAL '60 days'
+WHERE status = 'open' AND expires_at IS NULL;
@@ -0,0 +1,15 @@
+--file: backend/db/migrations/notifications.sql
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type TEXT NOT NULL, -- "offer_expired", "offer_expiring", etc.
+  object_type TEXT NOT NULL, -- "offer", "listing", etc.
+  object_id INTEGER NOT NULL,
+  message TEXT NOT NULL DEFAULT '',
+  read_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
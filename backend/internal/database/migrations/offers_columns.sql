@@ -0,0 +1,16 @@
+--file: backend/db/migrations/offers_columns.sql
+
+-- Une offre sans produit proposé est une offre d'achat au prix de l'annonce
+ALTER TABLE offers ALTER COLUMN proposed_product_id DROP NOT NULL;
+
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS counter_offer TEXT;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS viewed_at TIMESTAMP;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_offers_from_user_id ON offers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_offers_proposed_product_id ON offers(proposed_product_id);
+
+-- Un utilisateur n'a qu'une offre en cours par annonce
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offers_active_per_user
+    ON offers(listing_id, from_user_id) WHERE status IN ('pending', 'countered');
@@ -0,0 +1,11 @@
+--file: backend/db/migrations/offers_expiry.sql
+
+-- Date du rappel envoyé avant l'expiration d'une offre
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+
+-- Les offres en cours antérieures à l'expiration reçoivent la validité par défaut
+UPDATE offers SET expires_at = created_at + INTERVAL '7 days'
+WHERE expires_at IS NULL AND status IN ('pending', 'countered');
+
+CREATE INDEX IF NOT EXISTS idx_offers_active_expires_at
+    ON offers(expires_at) WHERE status IN ('pending', 'countered');
@@ -0,0 +1,35 @@
+--file: backend/db/migrations/offers_rounds.sql
+
+-- Montant proposé selon les termes en cours de l'offre
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS amount INTEGER CHECK (amount >= 0);
+
+-- Tours de négociation d'une offre : chaque proposition et son auteur
+CREATE TABLE IF NOT EXISTS offer_rounds (
+  id SERIAL PRIMARY KEY,
+  offer_id INTEGER NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  amount INTEGER CHECK (amount >= 0),
+  product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+  message TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_offer_rounds_offer_id ON offer_rounds(offer_id, id);
+
+-- Les offres d'achat existantes portent sur le prix de l'annonce
+UPDATE offers o SET amount = l.price
+FROM listings l
+WHERE l.id = o.listing_id AND o.proposed_product_id IS NULL AND o.amount IS NULL;
+
+-- Premier tour des offres existantes, puis contre-proposition éventuelle du vendeur
+INSERT INTO offer_rounds (offer_id, user_id, amount, product_id, message, created_at)
+SELECT o.id, o.from_user_id, o.amount, o.proposed_product_id, o.message, o.created_at
+FROM offers o
+WHERE NOT EXISTS (SELECT 1 FROM offer_rounds r WHERE r.offer_id = o.id);
+
+INSERT INTO offer_rounds (offer_id, user_id, amount, product_id, message, created_at)
+SELECT o.id, l.user_id, o.amount, o.proposed_product_id, o.counter_offer, COALESCE(o.updated_at, o.created_at)
+FROM offers o
+JOIN listings l ON l.id = o.listing_id
+WHERE o.counter_offer IS NOT NULL
+  AND (SELECT COUNT(*) FROM offer_rounds r WHERE r.offer_id = o.id) = 1;
@@ -0,0 +1,53 @@
+--file: backend/db/migrations/offers_trade.sql
+
+-- Suivi de l'échange après acceptation : remise par le vendeur, confirmation de chaque partie
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS shipped_at TIMESTAMP;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS owner_confirmed_at TIMESTAMP;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS offerer_confirmed_at TIMESTAMP;
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_offers_trade_status ON offers(status) WHERE status IN ('accepted', 'shipped', 'disputed');
+
+-- Litiges ouverts par une partie sur un échange, tranchés par un administrateur
+CREATE TABLE IF NOT EXISTS trade_disputes (
+  id SERIAL PRIMARY KEY,
+  offer_id INTEGER NOT NULL UNIQUE REFERENCES offers(id) ON DELETE CASCADE,
+  opened_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+  resolution TEXT CHECK (resolution IN ('complete', 'cancel')),
+  resolution_note TEXT NOT NULL DEFAULT '',
+  resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_disputes_status ON trade_disputes(status, created_at);
+
+-- Photos jointes à un litige par les parties
+CREATE TABLE IF NOT EXISTS trade_dispute_evidence (
+  id SERIAL PRIMARY KEY,
+  dispute_id INTEGER NOT NULL REFERENCES trade_disputes(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL UNIQUE,
+  content_type TEXT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_dispute_evidence_dispute_id ON trade_dispute_evidence(dispute_id, id);
+CREATE INDEX IF NOT EXISTS idx_trade_dispute_evidence_user_id ON trade_dispute_evidence(user_id);
+
+-- Les échanges acceptés jusqu'ici ont déjà transféré les produits : ils sont terminés
+UPDATE offers SET status = 'completed', completed_at = COALESCE(updated_at, created_at)
+WHERE status = 'accepted';
+
+-- La colonne vient de users_reputation.sql, appliqué après ce fichier
+ALTER TABLE users ADD COLUMN IF NOT EXISTS completed_trades INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users u SET completed_trades = (
+  SELECT COUNT(*) FROM offers o JOIN listings l ON l.id = o.listing_id
+  WHERE o.status = 'completed' AND (o.from_user_id = u.id OR l.user_id = u.id)
+);
@@ -0,0 +1,30 @@
+--file: backend/db/migrations/price_history.sql
+
+-- Prix de vente constatés à la fin des échanges, par produit du catalogue et par état
+CREATE TABLE IF NOT EXISTS price_history (
+  id SERIAL PRIMARY KEY,
+  offer_id INTEGER UNIQUE REFERENCES offers(id) ON DELETE SET NULL,
+  listing_id INTEGER REFERENCES listings(id) ON DELETE SET NULL,
+  product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+  -- Désignation du produit au moment de la vente
+  name TEXT NOT NULL,
+  brand TEXT NOT NULL DEFAULT '',
+  model TEXT NOT NULL DEFAULT '',
+  category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+  state TEXT NOT NULL,
+  price INTEGER NOT NULL CHECK (price >= 0),
+  sold_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_model ON price_history(LOWER(brand), LOWER(model), state, sold_at);
+CREATE INDEX IF NOT EXISTS idx_price_history_name ON price_history(LOWER(name), state, sold_at) WHERE model = '';
+
+-- Ventes des achats déjà terminés
+INSERT INTO price_history (offer_id, listing_id, product_id, name, brand, model, category_id, state, price, sold_at)
+SELECT o.id, l.id, p.id, p.name, COALESCE(p.brand, ''), COALESCE(p.model, ''), p.category_id, l.state, o.amount,
+       COALESCE(o.completed_at, o.updated_at, o.created_at)
+FROM offers o
+JOIN listings l ON l.id = o.listing_id
+JOIN products p ON p.id = l.product_id
+WHERE o.status = 'completed' AND o.proposed_product_id IS NULL AND o.amount IS NOT NULL
+ON CONFLICT (offer_id) DO NOTHING;
@@ -0,0 +1,30 @@
+--file: backend/db/migrations/saved_searches.sql
+
+-- Recherches d'annonces enregistrées, avec alerte sur les nouvelles annonces
+CREATE TABLE IF NOT EXISTS saved_searches (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  brand TEXT NOT NULL DEFAULT '',
+  model TEXT NOT NULL DEFAULT '',
+  category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+  max_price INTEGER,
+  state TEXT NOT NULL DEFAULT '',
+  frequency TEXT NOT NULL DEFAULT 'immediate' CHECK (frequency IN ('immediate', 'daily')),
+  last_digest_at TIMESTAMP, -- dernier récapitulatif quotidien envoyé
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id);
+
+-- Annonces correspondant à une recherche, notifiées immédiatement ou en attente du récapitulatif
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+  search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  notified_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (search_id, listing_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_search_matches_pending ON saved_search_matches(search_id) WHERE notified_at IS NULL;
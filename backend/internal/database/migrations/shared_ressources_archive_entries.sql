@@ -0,0 +1,13 @@
+--file: backend/db/migrations/shared_ressources_archive_entries.sql
+
+CREATE TABLE IF NOT EXISTS shared_ressource_archive_entries (
+  id SERIAL PRIMARY KEY,
+  shared_ressource_id INTEGER NOT NULL REFERENCES shared_ressources(id) ON DELETE CASCADE,
+  path TEXT NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0,
+  compressed_size BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE(shared_ressource_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_archive_entries_resource ON shared_ressource_archive_entries(shared_ressource_id);
@@ -0,0 +1,12 @@
+--file: backend/db/migrations/shared_ressources_columns.sql
+
+-- Aligne la table sur models.SharedResource
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS download_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE shared_ressources SET updated_at = uploaded_at WHERE updated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressources_uploader_id ON shared_ressources(uploader_id);
+CREATE INDEX IF NOT EXISTS idx_shared_ressources_type ON shared_ressources(type);
+CREATE INDEX IF NOT EXISTS idx_shared_ressources_filename ON shared_ressources(filename);
@@ -0,0 +1,16 @@
+--file: backend/db/migrations/shared_ressources_midi.sql
+
+CREATE TABLE IF NOT EXISTS shared_ressource_midi (
+  shared_ressource_id INTEGER PRIMARY KEY REFERENCES shared_ressources(id) ON DELETE CASCADE,
+  tempo_bpm REAL NOT NULL,
+  time_signature TEXT NOT NULL,
+  key_signature TEXT,
+  length_bars REAL NOT NULL DEFAULT 0,
+  track_count INTEGER NOT NULL DEFAULT 0,
+  channel_count INTEGER NOT NULL DEFAULT 0,
+  lowest_note INTEGER,
+  highest_note INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_midi_tempo ON shared_ressource_midi(tempo_bpm);
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_midi_key ON shared_ressource_midi(key_signature);
@@ -0,0 +1,5 @@
+--file: backend/db/migrations/shared_ressources_publish_at.sql
+
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressources_publish_at ON shared_ressources(publish_at) WHERE publish_at IS NOT NULL;
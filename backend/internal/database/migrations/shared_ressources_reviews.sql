@@ -0,0 +1,20 @@
+--file: backend/db/migrations/shared_ressources_reviews.sql
+
+CREATE TABLE IF NOT EXISTS shared_ressource_reviews (
+  id SERIAL PRIMARY KEY,
+  shared_ressource_id INTEGER NOT NULL REFERENCES shared_ressources(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  comment TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (shared_ressource_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_reviews_resource ON shared_ressource_reviews(shared_ressource_id);
+
+-- Agrégats dénormalisés pour l'affichage et le tri des listes
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS rating_average REAL NOT NULL DEFAULT 0;
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressources_rating ON shared_ressources(rating_average DESC, rating_count DESC);
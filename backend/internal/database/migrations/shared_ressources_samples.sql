@@ -0,0 +1,18 @@
+--file: backend/db/migrations/shared_ressources_samples.sql
+
+CREATE TABLE IF NOT EXISTS shared_ressource_samples (
+  shared_ressource_id INTEGER PRIMARY KEY REFERENCES shared_ressources(id) ON DELETE CASCADE,
+  format TEXT,
+  sample_rate INTEGER,
+  bit_depth INTEGER,
+  channels INTEGER,
+  duration_seconds REAL,
+  loop_start BIGINT,
+  loop_end BIGINT,
+  bpm REAL,
+  musical_key TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_samples_bpm ON shared_ressource_samples(bpm);
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_samples_key ON shared_ressource_samples(musical_key);
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_samples_sample_rate ON shared_ressource_samples(sample_rate);
@@ -0,0 +1,22 @@
+--file: backend/db/migrations/shared_ressources_versions.sql
+
+ALTER TABLE shared_ressources ADD COLUMN IF NOT EXISTS current_version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS shared_ressource_versions (
+  id SERIAL PRIMARY KEY,
+  shared_ressource_id INTEGER NOT NULL REFERENCES shared_ressources(id) ON DELETE CASCADE,
+  version_number INTEGER NOT NULL,
+  filename TEXT NOT NULL UNIQUE,
+  size BIGINT NOT NULL DEFAULT 0,
+  release_notes TEXT NOT NULL DEFAULT '',
+  download_count INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (shared_ressource_id, version_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_ressource_versions_resource ON shared_ressource_versions(shared_ressource_id);
+
+-- Les ressources existantes deviennent leur propre version 1
+INSERT INTO shared_ressource_versions (shared_ressource_id, version_number, filename, download_count, created_at)
+SELECT id, 1, filename, download_count, uploaded_at FROM shared_ressources
+ON CONFLICT DO NOTHING;
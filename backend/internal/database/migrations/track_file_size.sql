@@ -0,0 +1,6 @@
+--file: backend/db/migrations/track_file_size.sql
+
+-- Taille du fichier audio, pour le calcul de l'espace de stockage utilisé
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS file_size BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_uploader_id ON tracks(uploader_id);
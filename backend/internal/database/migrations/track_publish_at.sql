@@ -0,0 +1,5 @@
+--file: backend/db/migrations/track_publish_at.sql
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_publish_at ON tracks(publish_at) WHERE publish_at IS NOT NULL;
@@ -0,0 +1,30 @@
+--file: backend/db/migrations/trade_bundles.sql
+
+-- Produits proposés par l'auteur d'une offre selon les termes en cours : une
+-- offre peut réunir plusieurs produits, avec ou sans montant
+CREATE TABLE IF NOT EXISTS offer_products (
+  offer_id INTEGER NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+  product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  PRIMARY KEY (offer_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_offer_products_product_id ON offer_products(product_id);
+
+-- Produits proposés à chaque tour de négociation
+CREATE TABLE IF NOT EXISTS offer_round_products (
+  round_id INTEGER NOT NULL REFERENCES offer_rounds(id) ON DELETE CASCADE,
+  product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  PRIMARY KEY (round_id, product_id)
+);
+
+-- Reprise du produit unique des offres et des tours existants
+INSERT INTO offer_products (offer_id, product_id)
+SELECT id, proposed_product_id FROM offers WHERE proposed_product_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO offer_round_products (round_id, product_id)
+SELECT id, product_id FROM offer_rounds WHERE product_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE offers DROP COLUMN IF EXISTS proposed_product_id;
+ALTER TABLE offer_rounds DROP COLUMN IF EXISTS product_id;
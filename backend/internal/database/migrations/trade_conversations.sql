@@ -0,0 +1,23 @@
+--file: backend/db/migrations/trade_conversations.sql
+
+-- Conversation entre le vendeur et un acheteur autour d'une annonce, reprise
+-- par l'offre de cet acheteur et archivée à la fin de l'échange
+CREATE TABLE IF NOT EXISTS conversations (
+  id SERIAL PRIMARY KEY,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  offer_id INTEGER REFERENCES offers(id) ON DELETE SET NULL,
+  buyer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  seller_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  archived_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (listing_id, buyer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversations_buyer_id ON conversations(buyer_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversations_seller_id ON conversations(seller_id, updated_at DESC);
+
+-- Les messages d'une conversation sont des messages privés rattachés à celle-ci
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS conversation_id INTEGER REFERENCES conversations(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id, id) WHERE conversation_id IS NOT NULL;
@@ -0,0 +1,16 @@
+--file: backend/db/migrations/trade_ratings.sql
+
+-- Évaluation d'une partie par l'autre après un échange terminé, une fois par échange
+CREATE TABLE IF NOT EXISTS trade_ratings (
+  id SERIAL PRIMARY KEY,
+  offer_id INTEGER REFERENCES offers(id) ON DELETE SET NULL, -- l'évaluation survit à l'échange
+  rater_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rated_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('seller', 'buyer')), -- rôle de l'utilisateur évalué
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  comment TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (offer_id, rater_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_ratings_rated_user ON trade_ratings(rated_user_id, created_at DESC);
@@ -0,0 +1,20 @@
+--file: backend/db/migrations/uploads.sql
+
+-- Uploads reprenables (protocole tus 1.0)
+CREATE TABLE IF NOT EXISTS uploads (
+  id TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  target TEXT NOT NULL CHECK (target IN ('track', 'shared_resource')),
+  filename TEXT NOT NULL,
+  upload_length BIGINT NOT NULL,
+  upload_offset BIGINT NOT NULL DEFAULT 0,
+  metadata JSONB NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMP NOT NULL,
+  completed_at TIMESTAMP,
+  result_id INTEGER,
+  error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user ON uploads(user_id);
+CREATE INDEX IF NOT EXISTS idx_uploads_expires_at ON uploads(expires_at);
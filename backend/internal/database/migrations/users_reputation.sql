@@ -0,0 +1,6 @@
+--file: backend/db/migrations/users_reputation.sql
+
+-- Réputation sur la marketplace, agrégats dénormalisés pour l'affichage des annonces et des offres
+ALTER TABLE users ADD COLUMN IF NOT EXISTS rating_average REAL NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS completed_trades INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+--file: backend/db/migrations/users_storage.sql
+
+-- Quota de stockage propre à l'utilisateur (NULL : quota de son rôle, 0 : illimité)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_quota BIGINT CHECK (storage_quota >= 0);
+
+-- Avatar envoyé sur le serveur (l'avatar peut aussi être une URL externe)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_file TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_size BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,22 @@
+// internal/models/activity.go
+package models
+
+import (
+	"time"
+)
+
+// Activity represents an entry in the activity feed
+type Activity struct {
+	ID         int       `db:"id" json:"id"`
+	UserID     int       `db:"user_id" json:"user_id"`
+	Type       string    `db:"type" json:"type"`               // track_published, resource_published
+	ObjectType string    `db:"object_type" json:"object_type"` // track, shared_resource
+	ObjectID   int       `db:"object_id" json:"object_id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// ActivityWithUser represents an activity with user information
+type ActivityWithUser struct {
+	Activity
+	Username string `db:"username" json:"username,omitempty"`
+}
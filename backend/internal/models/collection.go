@@ -0,0 +1,41 @@
+// internal/models/collection.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Collection represents a named, ordered kit of shared resources and tracks
+type Collection struct {
+	ID          int            `db:"id" json:"id"`
+	UserID      int            `db:"user_id" json:"user_id"`
+	Name        string         `db:"name" json:"name"`
+	Description string         `db:"description" json:"description"`
+	CoverImage  sql.NullString `db:"cover_image" json:"-"`
+	IsPublic    bool           `db:"is_public" json:"is_public"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// CollectionWithDetails represents a collection with its owner, counters and items
+type CollectionWithDetails struct {
+	Collection
+	Username      string           `db:"username" json:"username"`
+	CoverURL      string           `json:"cover_url,omitempty"`
+	ItemCount     int              `db:"item_count" json:"item_count"`
+	FollowerCount int              `db:"follower_count" json:"follower_count"`
+	IsFollowing   bool             `db:"is_following" json:"is_following"`
+	Items         []CollectionItem `json:"items,omitempty"`
+}
+
+// CollectionItem represents a track or shared resource inside a collection
+type CollectionItem struct {
+	ID       int       `db:"id" json:"id"`
+	ItemType string    `db:"item_type" json:"item_type"` // track, shared_resource
+	ItemID   int       `db:"item_id" json:"item_id"`
+	Position int       `db:"position" json:"position"`
+	Title    string    `db:"title" json:"title"`
+	Filename string    `db:"filename" json:"-"`
+	AddedAt  time.Time `db:"added_at" json:"added_at"`
+}
@@ -0,0 +1,44 @@
+// internal/models/conversation.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Conversation represents the conversation between a listing owner and a prospective
+// buyer, linked to the buyer's offer once there is one
+type Conversation struct {
+	ID         int           `db:"id" json:"id"`
+	ListingID  int           `db:"listing_id" json:"listing_id"`
+	OfferID    sql.NullInt32 `db:"offer_id" json:"offer_id,omitempty"`
+	BuyerID    int           `db:"buyer_id" json:"buyer_id"`
+	SellerID   int           `db:"seller_id" json:"seller_id"`
+	ArchivedAt sql.NullTime  `db:"archived_at" json:"archived_at,omitempty"` // Set when the trade completes; no new messages
+	CreatedAt  time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// ConversationWithDetails represents a conversation with its listing, participants
+// and last message
+type ConversationWithDetails struct {
+	Conversation
+	ListingTitle   string         `db:"listing_title" json:"listing_title"`
+	ListingStatus  string         `db:"listing_status" json:"listing_status"`
+	BuyerUsername  string         `db:"buyer_username" json:"buyer_username"`
+	SellerUsername string         `db:"seller_username" json:"seller_username"`
+	LastMessage    sql.NullString `db:"last_message" json:"last_message,omitempty"`
+	LastMessageAt  sql.NullTime   `db:"last_message_at" json:"last_message_at,omitempty"`
+}
+
+// ConversationMessage represents a message of a listing conversation, stored in the messages table
+type ConversationMessage struct {
+	ID             int            `db:"id" json:"id"`
+	ConversationID int            `db:"conversation_id" json:"conversation_id"`
+	FromUser       int            `db:"from_user" json:"from_user"`
+	FromUsername   string         `db:"from_username" json:"from_username"`
+	FromAvatar     sql.NullString `db:"from_avatar" json:"from_avatar,omitempty"`
+	ToUser         int            `db:"to_user" json:"to_user"`
+	Content        string         `db:"content" json:"content"`
+	Timestamp      time.Time      `db:"timestamp" json:"timestamp"`
+}
\ No newline at end of file
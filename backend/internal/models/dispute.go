@@ -0,0 +1,38 @@
+// internal/models/dispute.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TradeDispute represents a dispute opened by a party of an accepted trade
+type TradeDispute struct {
+	ID               int               `db:"id" json:"id"`
+	OfferID          int               `db:"offer_id" json:"offer_id"`
+	OpenedBy         int               `db:"opened_by" json:"opened_by"`
+	OpenedByUsername string            `db:"opened_by_username" json:"opened_by_username"`
+	Reason           string            `db:"reason" json:"reason"`
+	Status           string            `db:"status" json:"status"`                   // open, resolved
+	Resolution       sql.NullString    `db:"resolution" json:"resolution,omitempty"` // complete, cancel
+	ResolutionNote   string            `db:"resolution_note" json:"resolution_note,omitempty"`
+	ResolvedBy       sql.NullInt32     `db:"resolved_by" json:"resolved_by,omitempty"`
+	CreatedAt        time.Time         `db:"created_at" json:"created_at"`
+	ResolvedAt       sql.NullTime      `db:"resolved_at" json:"resolved_at,omitempty"`
+	Evidence         []DisputeEvidence `json:"evidence,omitempty"`
+}
+
+// DisputeEvidence represents a photo attached to a dispute
+type DisputeEvidence struct {
+	ID          int       `db:"id" json:"id"`
+	DisputeID   int       `db:"dispute_id" json:"dispute_id"`
+	UserID      int       `db:"user_id" json:"user_id"`
+	Username    string    `db:"username" json:"username"`
+	Filename    string    `db:"filename" json:"-"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Width       int       `db:"width" json:"width"`
+	Height      int       `db:"height" json:"height"`
+	Size        int64     `db:"size" json:"size"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	URL         string    `json:"url"`
+}
@@ -17,8 +17,10 @@ type Listing struct {
 	State       string         `db:"state" json:"state"` // new, like_new, good, fair, poor
 	Price       sql.NullInt32  `db:"price" json:"price,omitempty"`
 	ExchangeFor sql.NullString `db:"exchange_for" json:"exchange_for,omitempty"`
-	Images      pq.StringArray `db:"images" json:"images"`
-	Status      string         `db:"status" json:"status"` // open, closed, sold
+	Images      pq.StringArray `db:"images" json:"images"`                   // URLs of the uploaded images, managed by the server
+	Status      string         `db:"status" json:"status"`                   // open, reserved, closed, sold, expired
+	ExpiresAt   sql.NullTime   `db:"expires_at" json:"expires_at,omitempty"` // End of life of an open listing, pushed back on renewal
+	BumpedAt    sql.NullTime   `db:"bumped_at" json:"bumped_at,omitempty"`   // Last move to the top of recency sorting
 	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
 }
@@ -26,35 +28,77 @@ type Listing struct {
 // ListingWithDetails represents a listing with user and product information
 type ListingWithDetails struct {
 	Listing
-	Username    string         `db:"username" json:"username,omitempty"`
-	UserAvatar  sql.NullString `db:"user_avatar" json:"user_avatar,omitempty"`
-	ProductName string         `db:"product_name" json:"product_name,omitempty"`
-	Brand       sql.NullString `db:"brand" json:"brand,omitempty"`
-	Model       sql.NullString `db:"model" json:"model,omitempty"`
-	OfferCount  int            `db:"offer_count" json:"offer_count,omitempty"`
+	Username         string         `db:"username" json:"username,omitempty"`
+	UserAvatar       sql.NullString `db:"user_avatar" json:"user_avatar,omitempty"`
+	ProductName      string         `db:"product_name" json:"product_name,omitempty"`
+	Brand            sql.NullString `db:"brand" json:"brand,omitempty"`
+	Model            sql.NullString `db:"model" json:"model,omitempty"`
+	OfferCount       int            `db:"offer_count" json:"offer_count,omitempty"`
+	SellerReputation Reputation     `json:"seller_reputation"`
+}
+
+// ListingImage represents a photo uploaded for a listing
+type ListingImage struct {
+	ID          int               `db:"id" json:"id"`
+	ListingID   int               `db:"listing_id" json:"listing_id"`
+	UserID      int               `db:"user_id" json:"user_id"`
+	Filename    string            `db:"filename" json:"-"`
+	Thumbnails  pq.StringArray    `db:"thumbnails" json:"-"`
+	ContentType string            `db:"content_type" json:"content_type"`
+	Width       int               `db:"width" json:"width"`
+	Height      int               `db:"height" json:"height"`
+	Size        int64             `db:"size" json:"size"`
+	Position    int               `db:"position" json:"position"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+	URL         string            `json:"url"`
+	Sizes       map[string]string `json:"sizes"` // Thumbnail URL by size name
 }
 
 // Offer represents an offer on a listing
 type Offer struct {
-	ID                int            `db:"id" json:"id"`
-	ListingID         int            `db:"listing_id" json:"listing_id"`
-	FromUserID        int            `db:"from_user_id" json:"from_user_id"`
-	ProposedProductID int            `db:"proposed_product_id" json:"proposed_product_id"` // References user_products.id
-	Message           sql.NullString `db:"message" json:"message,omitempty"`
-	Status            string         `db:"status" json:"status"` // pending, accepted, rejected, withdrawn
-	CounterOffer      sql.NullString `db:"counter_offer" json:"counter_offer,omitempty"`
-	ExpiresAt         sql.NullTime   `db:"expires_at" json:"expires_at,omitempty"`
-	ViewedAt          sql.NullTime   `db:"viewed_at" json:"viewed_at,omitempty"`
-	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time      `db:"updated_at" json:"updated_at"`
+	ID                 int            `db:"id" json:"id"`
+	ListingID          int            `db:"listing_id" json:"listing_id"`
+	FromUserID         int            `db:"from_user_id" json:"from_user_id"`
+	ProposedProducts   []OfferProduct `json:"proposed_products"`            // Offerer's products of the current terms; empty for a purchase offer
+	Amount             sql.NullInt32  `db:"amount" json:"amount,omitempty"` // Cash amount of the current terms
+	Message            sql.NullString `db:"message" json:"message,omitempty"`
+	Status             string         `db:"status" json:"status"` // pending, accepted, rejected, withdrawn, countered, expired, shipped, completed, disputed, cancelled
+	ExpiresAt          sql.NullTime   `db:"expires_at" json:"expires_at,omitempty"`
+	ViewedAt           sql.NullTime   `db:"viewed_at" json:"viewed_at,omitempty"`
+	ShippedAt          sql.NullTime   `db:"shipped_at" json:"shipped_at,omitempty"`                     // Listing owner shipped or handed over the item
+	OwnerConfirmedAt   sql.NullTime   `db:"owner_confirmed_at" json:"owner_confirmed_at,omitempty"`     // Listing owner confirmed the trade
+	OffererConfirmedAt sql.NullTime   `db:"offerer_confirmed_at" json:"offerer_confirmed_at,omitempty"` // Offerer confirmed the trade
+	CompletedAt        sql.NullTime   `db:"completed_at" json:"completed_at,omitempty"`
+	CreatedAt          time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time      `db:"updated_at" json:"updated_at"`
 }
 
 // OfferWithDetails represents an offer with user and product information
 type OfferWithDetails struct {
 	Offer
-	FromUsername        string         `db:"from_username" json:"from_username,omitempty"`
-	FromUserAvatar      sql.NullString `db:"from_user_avatar" json:"from_user_avatar,omitempty"`
-	ProposedProductName string         `db:"proposed_product_name" json:"proposed_product_name,omitempty"`
-	ListingTitle        string         `db:"listing_title" json:"listing_title,omitempty"`
-	ListingOwnerID      int            `db:"listing_owner_id" json:"listing_owner_id,omitempty"`
+	FromUsername           string         `db:"from_username" json:"from_username,omitempty"`
+	FromUserAvatar         sql.NullString `db:"from_user_avatar" json:"from_user_avatar,omitempty"`
+	ListingTitle           string         `db:"listing_title" json:"listing_title,omitempty"`
+	ListingOwnerID         int            `db:"listing_owner_id" json:"listing_owner_id,omitempty"`
+	FromUserReputation     Reputation     `json:"from_user_reputation"`
+	ListingOwnerReputation Reputation     `json:"listing_owner_reputation"`
+	Rounds                 []OfferRound   `json:"rounds,omitempty"`
+}
+
+// OfferRound represents one proposal in the negotiation of an offer
+type OfferRound struct {
+	ID        int            `db:"id" json:"id"`
+	OfferID   int            `db:"offer_id" json:"offer_id"`
+	UserID    int            `db:"user_id" json:"user_id"` // Author of the proposal
+	Username  string         `db:"username" json:"username,omitempty"`
+	Amount    sql.NullInt32  `db:"amount" json:"amount,omitempty"`
+	Products  []OfferProduct `json:"products"` // Offerer's products to swap
+	Message   sql.NullString `db:"message" json:"message,omitempty"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+// OfferProduct represents one of the offerer's products proposed in an offer
+type OfferProduct struct {
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+// internal/models/notification.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Notification represents a message addressed to a single user
+type Notification struct {
+	ID         int          `db:"id" json:"id"`
+	UserID     int          `db:"user_id" json:"user_id"`
+	Type       string       `db:"type" json:"type"`               // offer_expired, offer_expiring, offer_countered, trade_shipped, trade_confirmed, trade_completed, trade_disputed, dispute_resolved, trade_rated, listing_match, search_digest, listing_expiring, listing_expired
+	ObjectType string       `db:"object_type" json:"object_type"` // offer, listing, saved_search
+	ObjectID   int          `db:"object_id" json:"object_id"`
+	Message    string       `db:"message" json:"message"`
+	ReadAt     sql.NullTime `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+}
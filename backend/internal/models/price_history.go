@@ -0,0 +1,51 @@
+// internal/models/price_history.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PriceSale represents the price of a completed sale of a catalogue product
+type PriceSale struct {
+	ID        int       `db:"id" json:"id"`
+	ListingID int       `db:"listing_id" json:"listing_id,omitempty"`
+	State     string    `db:"state" json:"state"` // new, like_new, good, fair, poor
+	Price     int       `db:"price" json:"price"`
+	SoldAt    time.Time `db:"sold_at" json:"sold_at"`
+}
+
+// PriceStats represents the sold prices of a catalogue product in one condition
+type PriceStats struct {
+	State      string    `db:"state" json:"state"`
+	SaleCount  int       `db:"sale_count" json:"sale_count"`
+	MinPrice   int       `db:"min_price" json:"min_price"`
+	MaxPrice   int       `db:"max_price" json:"max_price"`
+	Average    float64   `db:"average" json:"average"`
+	Median     int       `db:"median" json:"median"`
+	LastSoldAt time.Time `db:"last_sold_at" json:"last_sold_at"`
+}
+
+// PriceHistory represents the sold prices of the catalogue product of a user product,
+// with per-condition statistics and the most recent sales
+type PriceHistory struct {
+	ProductID  int            `json:"product_id"`
+	Name       string         `json:"name"`
+	Brand      sql.NullString `json:"brand,omitempty"`
+	Model      sql.NullString `json:"model,omitempty"`
+	Conditions []PriceStats   `json:"conditions"`
+	Sales      []PriceSale    `json:"sales"`
+}
+
+// PriceSuggestion represents the price range suggested for a listing from recent
+// comparable sales. Available is false when there are too few sales to suggest a range.
+type PriceSuggestion struct {
+	ProductID  int    `json:"product_id"`
+	State      string `json:"state"`
+	Available  bool   `json:"available"`
+	SampleSize int    `json:"sample_size"`
+	Low        int    `json:"low,omitempty"`    // premier quartile
+	Median     int    `json:"median,omitempty"` // médiane
+	High       int    `json:"high,omitempty"`   // troisième quartile
+	WindowDays int    `json:"window_days"`
+}
\ No newline at end of file
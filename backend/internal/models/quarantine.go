@@ -0,0 +1,24 @@
+// internal/models/quarantine.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// QuarantinedFile represents an uploaded file isolated pending review by an administrator
+type QuarantinedFile struct {
+	ID               int               `db:"id" json:"id"`
+	UserID           int               `db:"user_id" json:"user_id"`
+	Username         string            `db:"username" json:"username"`
+	Target           string            `db:"target" json:"target"` // track, shared_resource, shared_resource_version
+	OriginalFilename string            `db:"original_filename" json:"original_filename"`
+	StoredFilename   string            `db:"stored_filename" json:"-"`
+	DetectedKind     string            `db:"detected_kind" json:"detected_kind"`
+	Reason           string            `db:"reason" json:"reason"`
+	Metadata         map[string]string `db:"metadata" json:"metadata"`
+	Status           string            `db:"status" json:"status"` // pending, released, deleted
+	ReviewedBy       sql.NullInt32     `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewedAt       sql.NullTime      `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	CreatedAt        time.Time         `db:"created_at" json:"created_at"`
+}
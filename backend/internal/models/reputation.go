@@ -0,0 +1,25 @@
+// internal/models/reputation.go
+package models
+
+import "time"
+
+// Reputation represents the aggregated marketplace reputation of a user
+type Reputation struct {
+	RatingAverage   float64 `db:"rating_average" json:"rating_average"`
+	RatingCount     int     `db:"rating_count" json:"rating_count"`
+	CompletedTrades int     `db:"completed_trades" json:"completed_trades"`
+}
+
+// TradeRating represents the rating left by one party of a completed trade for the other
+type TradeRating struct {
+	ID            int       `db:"id" json:"id"`
+	OfferID       int       `db:"offer_id" json:"offer_id,omitempty"` // 0 once the trade is deleted
+	RaterID       int       `db:"rater_id" json:"rater_id"`
+	RaterUsername string    `db:"rater_username" json:"rater_username"`
+	RaterAvatar   string    `db:"rater_avatar" json:"rater_avatar,omitempty"`
+	RatedUserID   int       `db:"rated_user_id" json:"rated_user_id"`
+	Role          string    `db:"role" json:"role"` // seller, buyer: role of the rated user in the trade
+	Rating        int       `db:"rating" json:"rating"`
+	Comment       string    `db:"comment" json:"comment,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
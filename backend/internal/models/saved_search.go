@@ -0,0 +1,37 @@
+// internal/models/saved_search.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SavedSearch represents a listing filter saved by a user, with alerts on new matches
+type SavedSearch struct {
+	ID           int           `db:"id" json:"id"`
+	UserID       int           `db:"user_id" json:"user_id"`
+	Name         string        `db:"name" json:"name"`
+	Brand        string        `db:"brand" json:"brand"`
+	Model        string        `db:"model" json:"model"`
+	CategoryID   sql.NullInt32 `db:"category_id" json:"category_id,omitempty"`
+	MaxPrice     sql.NullInt32 `db:"max_price" json:"max_price,omitempty"`
+	State        string        `db:"state" json:"state"`
+	Frequency    string        `db:"frequency" json:"frequency"` // immediate, daily
+	LastDigestAt sql.NullTime  `db:"last_digest_at" json:"last_digest_at,omitempty"`
+	PendingCount int           `db:"pending_count" json:"pending_count"` // matches waiting for the daily digest
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// SavedSearchMatch represents a listing that matched a saved search
+type SavedSearchMatch struct {
+	ListingID   int           `db:"listing_id" json:"listing_id"`
+	ProductName string        `db:"product_name" json:"product_name"`
+	Brand       string        `db:"brand" json:"brand"`
+	Model       string        `db:"model" json:"model"`
+	State       string        `db:"state" json:"state"`
+	Price       sql.NullInt32 `db:"price" json:"price,omitempty"`
+	Status      string        `db:"status" json:"status"`
+	NotifiedAt  sql.NullTime  `db:"notified_at" json:"notified_at,omitempty"`
+	MatchedAt   time.Time     `db:"matched_at" json:"matched_at"`
+}
@@ -20,6 +20,10 @@ type SharedResource struct {
 	UploaderID    int            `db:"uploader_id" json:"uploader_id"`
 	IsPublic      bool           `db:"is_public" json:"is_public"`
 	DownloadCount int            `db:"download_count" json:"download_count"`
+	Version       int            `db:"current_version" json:"version"`
+	RatingAverage float64        `db:"rating_average" json:"rating_average"`
+	RatingCount   int            `db:"rating_count" json:"rating_count"`
+	PublishAt     sql.NullTime   `db:"publish_at" json:"publish_at,omitempty"`
 	UploadedAt    time.Time      `db:"uploaded_at" json:"uploaded_at"`
 	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
 }
@@ -29,6 +33,8 @@ type SharedResourceWithUploader struct {
 	SharedResource
 	UploaderUsername string         `db:"uploader_username" json:"uploader_username,omitempty"`
 	UploaderAvatar   sql.NullString `db:"uploader_avatar" json:"uploader_avatar,omitempty"`
+	MIDI             *MIDIInfo      `json:"midi,omitempty"`
+	Sample           *SampleInfo    `json:"sample,omitempty"`
 }
 
 // SharedResourceResponse represents shared resource data for API responses
@@ -44,7 +50,76 @@ type SharedResourceResponse struct {
 	UploaderUsername string        `json:"uploader_username,omitempty"`
 	IsPublic         bool          `json:"is_public"`
 	DownloadCount    int           `json:"download_count"`
+	Version          int           `json:"version"`
+	RatingAverage    float64       `json:"rating_average"`
+	RatingCount      int           `json:"rating_count"`
 	UploadedAt       time.Time     `json:"uploaded_at"`
 	UpdatedAt        time.Time     `json:"updated_at"`
 	DownloadURL      string        `json:"download_url,omitempty"`
-}
\ No newline at end of file
+	MIDI             *MIDIInfo     `json:"midi,omitempty"`
+	Sample           *SampleInfo   `json:"sample,omitempty"`
+}
+
+// MIDIInfo represents the musical analysis of a MIDI shared resource
+type MIDIInfo struct {
+	TempoBPM        float64 `db:"tempo_bpm" json:"tempo_bpm"`
+	TimeSignature   string  `db:"time_signature" json:"time_signature"`
+	KeySignature    string  `db:"key_signature" json:"key_signature,omitempty"`
+	LengthBars      float64 `db:"length_bars" json:"length_bars"`
+	TrackCount      int     `db:"track_count" json:"track_count"`
+	ChannelCount    int     `db:"channel_count" json:"channel_count"`
+	LowestNote      *int    `db:"lowest_note" json:"lowest_note,omitempty"`
+	HighestNote     *int    `db:"highest_note" json:"highest_note,omitempty"`
+	LowestNoteName  string  `json:"lowest_note_name,omitempty"`
+	HighestNoteName string  `json:"highest_note_name,omitempty"`
+}
+
+// SampleInfo represents the technical metadata of an audio sample shared resource
+type SampleInfo struct {
+	Format          string  `db:"format" json:"format,omitempty"`
+	SampleRate      int     `db:"sample_rate" json:"sample_rate,omitempty"`
+	BitDepth        int     `db:"bit_depth" json:"bit_depth,omitempty"`
+	Channels        int     `db:"channels" json:"channels,omitempty"`
+	DurationSeconds float64 `db:"duration_seconds" json:"duration_seconds,omitempty"`
+	LoopStart       *int64  `db:"loop_start" json:"loop_start,omitempty"`
+	LoopEnd         *int64  `db:"loop_end" json:"loop_end,omitempty"`
+	BPM             float64 `db:"bpm" json:"bpm,omitempty"`
+	Key             string  `db:"musical_key" json:"key,omitempty"`
+}
+
+// SharedResourceVersion represents a numbered version of a shared resource file
+type SharedResourceVersion struct {
+	ID            int       `db:"id" json:"id"`
+	ResourceID    int       `db:"shared_ressource_id" json:"resource_id"`
+	VersionNumber int       `db:"version_number" json:"version"`
+	Filename      string    `db:"filename" json:"filename"`
+	URL           string    `json:"url"`
+	Size          int64     `db:"size" json:"size"`
+	ReleaseNotes  string    `db:"release_notes" json:"release_notes"`
+	DownloadCount int       `db:"download_count" json:"download_count"`
+	IsLatest      bool      `json:"is_latest"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// SharedResourceReview represents a user rating and review of a shared resource
+type SharedResourceReview struct {
+	ID         int       `db:"id" json:"id"`
+	ResourceID int       `db:"shared_ressource_id" json:"resource_id"`
+	UserID     int       `db:"user_id" json:"user_id"`
+	Username   string    `db:"username" json:"username"`
+	Avatar     string    `db:"avatar" json:"avatar,omitempty"`
+	Rating     int       `db:"rating" json:"rating"`
+	Comment    string    `db:"comment" json:"comment,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ArchiveEntry represents a file stored inside a zip shared resource
+type ArchiveEntry struct {
+	ID             int       `db:"id" json:"id"`
+	ResourceID     int       `db:"shared_ressource_id" json:"resource_id"`
+	Path           string    `db:"path" json:"path"`
+	Size           int64     `db:"size" json:"size"`
+	CompressedSize int64     `db:"compressed_size" json:"compressed_size"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
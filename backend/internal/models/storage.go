@@ -0,0 +1,13 @@
+// internal/models/storage.go
+package models
+
+// StorageUsage represents the storage used by a user against their quota
+type StorageUsage struct {
+	UserID          int              `json:"user_id"`
+	Used            int64            `json:"used"`
+	Quota           int64            `json:"quota"` // 0 when unlimited
+	Remaining       *int64           `json:"remaining,omitempty"`
+	Unlimited       bool             `json:"unlimited"`
+	QuotaOverridden bool             `json:"quota_overridden"`
+	Categories      map[string]int64 `json:"categories"`
+}
@@ -18,6 +18,7 @@ type Track struct {
 	Tags            pq.StringArray `db:"tags" json:"tags"`
 	IsPublic        bool           `db:"is_public" json:"is_public"`
 	UploaderID      int            `db:"uploader_id" json:"uploader_id"`
+	PublishAt       sql.NullTime   `db:"publish_at" json:"publish_at,omitempty"`
 	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+// internal/models/upload.go
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Upload represents a resumable (tus) upload and the content it produced once completed
+type Upload struct {
+	ID          string            `db:"id" json:"id"`
+	UserID      int               `db:"user_id" json:"user_id"`
+	Target      string            `db:"target" json:"target"` // track, shared_resource
+	Filename    string            `db:"filename" json:"filename"`
+	Length      int64             `db:"upload_length" json:"length"`
+	Offset      int64             `db:"upload_offset" json:"offset"`
+	Metadata    map[string]string `db:"metadata" json:"metadata"`
+	ExpiresAt   time.Time         `db:"expires_at" json:"expires_at"`
+	CompletedAt sql.NullTime      `db:"completed_at" json:"completed_at,omitempty"`
+	ResultID    sql.NullInt32     `db:"result_id" json:"result_id,omitempty"`
+	Error       sql.NullString    `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+}
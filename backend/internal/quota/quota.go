@@ -0,0 +1,175 @@
+// internal/quota/quota.go
+package quota
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/models"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	ErrUserNotFound  = errors.New("user not found")
+)
+
+// Catégories de fichiers comptées dans l'espace utilisé
+const (
+	CategoryTracks          = "tracks"
+	CategorySharedResources = "shared_resources"
+	CategoryAvatar          = "avatar"
+	CategoryListingImages   = "listing_images"
+	CategoryDisputeEvidence = "dispute_evidence"
+)
+
+type source struct {
+	category string
+	query    string // %s désigne l'identifiant de l'utilisateur
+}
+
+// sources calcule l'espace utilisé à partir des fichiers encore référencés :
+// supprimer un contenu libère son espace sans comptabilité séparée. Toutes les
+// versions d'une ressource sont comptées puisqu'elles restent téléchargeables.
+var sources = []source{
+	{CategoryTracks, "SELECT COALESCE(SUM(t.file_size), 0) FROM tracks t WHERE t.uploader_id = %s"},
+	{CategorySharedResources, `SELECT COALESCE(SUM(v.size), 0) FROM shared_ressource_versions v
+		JOIN shared_ressources r ON r.id = v.shared_ressource_id WHERE r.uploader_id = %s`},
+	{CategoryAvatar, "SELECT COALESCE(SUM(a.avatar_size), 0) FROM users a WHERE a.id = %s"},
+	{CategoryListingImages, "SELECT COALESCE(SUM(i.size), 0) FROM listing_images i WHERE i.user_id = %s"},
+	{CategoryDisputeEvidence, "SELECT COALESCE(SUM(e.size), 0) FROM trade_dispute_evidence e WHERE e.user_id = %s"},
+}
+
+// UsageExpr retourne une expression SQL donnant l'espace utilisé par l'utilisateur
+// désigné par userExpr (une colonne ou un paramètre)
+func UsageExpr(userExpr string) string {
+	parts := make([]string, len(sources))
+	for i, src := range sources {
+		parts[i] = "(" + fmt.Sprintf(src.query, userExpr) + ")"
+	}
+	return "(" + strings.Join(parts, " + ") + ")"
+}
+
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Manager applique les quotas de stockage par rôle et par utilisateur
+type Manager struct {
+	db     *database.DB
+	byRole map[string]int64
+}
+
+func NewManager(db *database.DB, byRole map[string]int64) *Manager {
+	return &Manager{db: db, byRole: byRole}
+}
+
+// limit retourne le quota applicable, 0 signifiant illimité
+func (m *Manager) limit(role string, override sql.NullInt64) int64 {
+	if override.Valid {
+		return override.Int64
+	}
+	return m.byRole[role]
+}
+
+// state lit le quota et l'espace utilisé ; lock verrouille la ligne de
+// l'utilisateur pour sérialiser ses envois dans la transaction courante
+func (m *Manager) state(q querier, userID int, lock bool) (limit, used int64, err error) {
+	query := "SELECT role, storage_quota FROM users WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var role string
+	var override sql.NullInt64
+	err = q.QueryRow(query, userID).Scan(&role, &override)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get storage quota: %w", err)
+	}
+
+	if err := q.QueryRow("SELECT "+UsageExpr("$1"), userID).Scan(&used); err != nil {
+		return 0, 0, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+	return m.limit(role, override), used, nil
+}
+
+// Check vérifie qu'un fichier de la taille donnée tient dans l'espace restant,
+// avant de le recevoir. Le contrôle définitif est fait par Reserve.
+func (m *Manager) Check(userID int, size int64) error {
+	limit, used, err := m.state(m.db, userID, false)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && used+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Reserve vérifie, dans la transaction qui enregistre le fichier, que celui-ci
+// tient dans le quota. La ligne de l'utilisateur reste verrouillée jusqu'à la
+// fin de la transaction pour que deux envois simultanés ne dépassent pas le quota.
+func (m *Manager) Reserve(tx *sql.Tx, userID int, size int64) error {
+	limit, used, err := m.state(tx, userID, true)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && used+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Usage retourne l'espace utilisé par catégorie et le quota de l'utilisateur
+func (m *Manager) Usage(userID int) (*models.StorageUsage, error) {
+	var role string
+	var override sql.NullInt64
+	err := m.db.QueryRow("SELECT role, storage_quota FROM users WHERE id = $1", userID).Scan(&role, &override)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage quota: %w", err)
+	}
+
+	usage := &models.StorageUsage{
+		UserID:          userID,
+		Quota:           m.limit(role, override),
+		QuotaOverridden: override.Valid,
+		Categories:      make(map[string]int64, len(sources)),
+	}
+	for _, src := range sources {
+		var size int64
+		if err := m.db.QueryRow(fmt.Sprintf(src.query, "$1"), userID).Scan(&size); err != nil {
+			return nil, fmt.Errorf("failed to compute %s storage usage: %w", src.category, err)
+		}
+		usage.Categories[src.category] = size
+		usage.Used += size
+	}
+
+	usage.Unlimited = usage.Quota == 0
+	if !usage.Unlimited {
+		remaining := usage.Quota - usage.Used
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Remaining = &remaining
+	}
+	return usage, nil
+}
+
+// SetQuota définit le quota propre à un utilisateur ; nil rétablit celui de son rôle
+func (m *Manager) SetQuota(userID int, quota *int64) (*models.StorageUsage, error) {
+	result, err := m.db.Exec("UPDATE users SET storage_quota = $1 WHERE id = $2", quota, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update storage quota: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrUserNotFound
+	}
+	return m.Usage(userID)
+}
@@ -0,0 +1,126 @@
+// internal/security/inspector.go
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/okinrev/veza-web-app/internal/database"
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrContentMismatch = errors.New("file content does not match its extension or is not allowed for this type")
+	ErrQuarantined     = errors.New("file has been quarantined for review by an administrator")
+)
+
+// Cibles d'une soumission, c'est-à-dire la création à reprendre après examen
+const (
+	TargetTrack                 = "track"
+	TargetSharedResource        = "shared_resource"
+	TargetSharedResourceVersion = "shared_resource_version"
+)
+
+// Statuts d'un fichier en quarantaine
+const (
+	QuarantinePending  = "pending"
+	QuarantineReleased = "released"
+	QuarantineDeleted  = "deleted"
+)
+
+// Submission décrit un fichier reçu et la création à reprendre s'il est libéré de quarantaine
+type Submission struct {
+	UserID   int
+	Target   string
+	Filename string // nom d'origine
+	Metadata map[string]string
+
+	// Reviewed indique un fichier déjà examiné par un administrateur :
+	// seul le contrôle du contenu est appliqué
+	Reviewed bool
+}
+
+// Inspector contrôle la nature des fichiers reçus et isole les fichiers suspects
+type Inspector struct {
+	db            *database.DB
+	scanner       Scanner
+	quarantineDir string
+}
+
+func NewInspector(db *database.DB, scanner Scanner, quarantineDir string) *Inspector {
+	if scanner == nil {
+		scanner = NoopScanner{}
+	}
+	return &Inspector{db: db, scanner: scanner, quarantineDir: quarantineDir}
+}
+
+// QuarantinePath retourne le chemin d'un fichier en quarantaine
+func (i *Inspector) QuarantinePath(storedName string) string {
+	return filepath.Join(i.quarantineDir, filepath.Base(storedName))
+}
+
+// Inspect vérifie que le contenu du fichier fait partie des natures autorisées,
+// puis le soumet au scanner. Un fichier refusé reste en place et doit être
+// supprimé par l'appelant ; un fichier suspect est déplacé en quarantaine et
+// ErrQuarantined est retourné.
+func (i *Inspector) Inspect(path string, allowed []string, sub Submission) error {
+	kind, err := DetectFileKind(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file header: %w", err)
+	}
+	if !contains(allowed, kind) {
+		return fmt.Errorf("%w (detected %s)", ErrContentMismatch, kind)
+	}
+
+	if sub.Reviewed {
+		return nil
+	}
+
+	result, err := i.scanner.Scan(path)
+	switch {
+	case err != nil:
+		// Sans verdict le fichier n'est pas publié : un administrateur tranchera
+		utils.LogError(fmt.Sprintf("File scan failed for %s: %v", sub.Filename, err))
+		return i.quarantine(path, kind, "scan failed: "+err.Error(), sub)
+	case result.Infected:
+		return i.quarantine(path, kind, "malware detected: "+result.Signature, sub)
+	}
+	return nil
+}
+
+// quarantine déplace le fichier hors des zones publiques et enregistre la soumission
+func (i *Inspector) quarantine(path, kind, reason string, sub Submission) error {
+	metadata, err := json.Marshal(sub.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode submission metadata: %w", err)
+	}
+
+	storedName := uuid.New().String()
+	dst := i.QuarantinePath(storedName)
+	if err := utils.MoveFile(path, dst); err != nil {
+		return fmt.Errorf("failed to quarantine file: %w", err)
+	}
+
+	_, err = i.db.Exec(`
+		INSERT INTO quarantined_files (user_id, target, original_filename, stored_filename, detected_kind, reason, metadata, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, sub.UserID, sub.Target, filepath.Base(sub.Filename), storedName, kind, reason, metadata, QuarantinePending)
+	if err != nil {
+		return fmt.Errorf("failed to record quarantined file: %w", err)
+	}
+
+	utils.LogInfo(fmt.Sprintf("File %s from user %d quarantined: %s", sub.Filename, sub.UserID, reason))
+	return ErrQuarantined
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// internal/security/scanner.go
+package security
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// ScanResult est le verdict d'un antivirus sur un fichier
+type ScanResult struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner analyse un fichier reçu. Les implémentations doivent être sûres
+// pour un usage concurrent.
+type Scanner interface {
+	Scan(path string) (ScanResult, error)
+}
+
+// NoopScanner accepte tous les fichiers (aucun antivirus configuré)
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(string) (ScanResult, error) {
+	return ScanResult{}, nil
+}
+
+// ClamdScanner envoie les fichiers à un démon compatible clamd via la commande INSTREAM
+type ClamdScanner struct {
+	Network string // unix ou tcp
+	Address string
+	Timeout time.Duration
+}
+
+const clamdChunkSize = 64 << 10
+
+func (s ClamdScanner) Scan(path string) (ScanResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer f.Close()
+
+	conn, err := net.DialTimeout(s.Network, s.Address, s.Timeout)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if s.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ScanResult{}, fmt.Errorf("failed to send clamd command: %w", err)
+	}
+
+	// Chaque morceau est précédé de sa longueur sur 4 octets, un morceau vide termine le flux
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := f.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return ScanResult{}, fmt.Errorf("failed to stream file to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return ScanResult{}, fmt.Errorf("failed to stream file to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return ScanResult{}, readErr
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return ScanResult{}, fmt.Errorf("failed to stream file to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return ScanResult{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply interprète "stream: OK" ou "stream: <signature> FOUND"
+func parseClamdReply(reply string) (ScanResult, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return ScanResult{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return ScanResult{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return ScanResult{}, errors.New("clamd error: " + reply)
+	}
+}
+
+// NewScanner construit le scanner désigné par la configuration
+func NewScanner(kind, network, address string, timeout time.Duration) Scanner {
+	if kind == "clamd" {
+		return ClamdScanner{Network: network, Address: address, Timeout: timeout}
+	}
+	return NoopScanner{}
+}
@@ -0,0 +1,129 @@
+// internal/security/sniff.go
+package security
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// Natures de contenu détectées à partir des premiers octets d'un fichier
+const (
+	KindWAV        = "wav"
+	KindAIFF       = "aiff"
+	KindMP3        = "mp3"
+	KindFLAC       = "flac"
+	KindOgg        = "ogg"
+	KindMP4        = "mp4" // m4a et autres conteneurs ISO BMFF
+	KindMIDI       = "midi"
+	KindZIP        = "zip"
+	KindGzip       = "gzip"
+	KindPDF        = "pdf"
+	KindOLE        = "ole" // anciens documents Office
+	KindJPEG       = "jpeg"
+	KindPNG        = "png"
+	KindWebP       = "webp"
+	KindFXP        = "fxp"
+	KindVSTPreset  = "vstpreset"
+	KindFLP        = "flp"
+	KindPE         = "pe"     // exécutables Windows (.exe, .dll)
+	KindMachO      = "mach-o" // exécutables macOS
+	KindELF        = "elf"
+	KindScript     = "script" // texte commençant par #!
+	KindText       = "text"
+	KindUnknown    = "unknown"
+	sniffHeaderLen = 512
+)
+
+// DetectKind identifie la nature d'un contenu à partir de ses premiers octets
+func DetectKind(head []byte) string {
+	has := func(offset int, magic string) bool {
+		return len(head) >= offset+len(magic) && string(head[offset:offset+len(magic)]) == magic
+	}
+
+	switch {
+	case has(0, "RIFF") && has(8, "WAVE"):
+		return KindWAV
+	case has(0, "RIFF") && has(8, "WEBP"):
+		return KindWebP
+	case has(0, "FORM") && (has(8, "AIFF") || has(8, "AIFC")):
+		return KindAIFF
+	case has(0, "ID3"), len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return KindMP3
+	case has(0, "fLaC"):
+		return KindFLAC
+	case has(0, "OggS"):
+		return KindOgg
+	case has(4, "ftyp"):
+		return KindMP4
+	case has(0, "MThd"):
+		return KindMIDI
+	case has(0, "PK\x03\x04"), has(0, "PK\x05\x06"):
+		return KindZIP
+	case has(0, "\x1f\x8b"):
+		return KindGzip
+	case has(0, "%PDF-"):
+		return KindPDF
+	case has(0, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"):
+		return KindOLE
+	case has(0, "\xFF\xD8\xFF"):
+		return KindJPEG
+	case has(0, "\x89PNG\r\n\x1a\n"):
+		return KindPNG
+	case has(0, "CcnK"):
+		return KindFXP
+	case has(0, "VST3"):
+		return KindVSTPreset
+	case has(0, "FLhd"):
+		return KindFLP
+	case has(0, "MZ"):
+		return KindPE
+	case has(0, "\x7fELF"):
+		return KindELF
+	case len(head) >= 4 && isMachO(binary.BigEndian.Uint32(head[0:4])):
+		return KindMachO
+	case has(0, "#!"):
+		return KindScript
+	case isText(head):
+		return KindText
+	}
+	return KindUnknown
+}
+
+func isMachO(magic uint32) bool {
+	switch magic {
+	case 0xFEEDFACE, 0xFEEDFACF, 0xCEFAEDFE, 0xCFFAEDFE, 0xCAFEBABE:
+		return true
+	}
+	return false
+}
+
+// isText considère comme texte un contenu UTF-8 sans octet nul
+func isText(head []byte) bool {
+	if len(head) == 0 || bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	// Le dernier caractère peut avoir été coupé par la taille de l'en-tête
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return len(head) > 0 && utf8.Valid(head)
+}
+
+// DetectFileKind lit l'en-tête d'un fichier et en identifie la nature
+func DetectFileKind(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffHeaderLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return DetectKind(head[:n]), nil
+}
@@ -0,0 +1,29 @@
+// internal/storage/config.go
+package storage
+
+import (
+	"fmt"
+
+	"github.com/okinrev/veza-web-app/internal/config"
+)
+
+// NewBackend construit le pilote de stockage choisi par la configuration
+func NewBackend(driver string, cfg config.StorageConfig) (Backend, error) {
+	switch driver {
+	case "local":
+		return NewLocal(cfg.LocalRoot, cfg.PublicURL, []byte(cfg.SignedURLSecret)), nil
+	case "s3":
+		return NewS3(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3PathStyle)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// New construit le stockage des modules à partir de la configuration
+func New(cfg config.StorageConfig) (*Store, error) {
+	backend, err := NewBackend(cfg.Driver, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(backend, cfg.PresignTTL), nil
+}
@@ -0,0 +1,220 @@
+// internal/storage/local.go
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedFilesPath est la route qui sert les URLs présignées du stockage local
+const SignedFilesPath = "/api/v1/files/"
+
+// Local stocke les fichiers sur le disque, sous Root
+type Local struct {
+	Root string
+
+	// BaseURL préfixe les URLs présignées (vide : URL relative à l'API)
+	BaseURL string
+	Secret  []byte
+}
+
+func NewLocal(root, baseURL string, secret []byte) *Local {
+	return &Local{Root: root, BaseURL: strings.TrimSuffix(baseURL, "/"), Secret: secret}
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	// Écriture dans un fichier temporaire renommé à la fin : un fichier
+	// stocké n'est jamais visible partiellement écrit
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".put-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return nil
+}
+
+type localObject struct {
+	*os.File
+	info ObjectInfo
+}
+
+func (o *localObject) Info() ObjectInfo {
+	return o.info
+}
+
+func (l *Local) Open(key string) (Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, ErrNotFound
+	}
+	return &localObject{File: f, info: ObjectInfo{Key: key, Size: stat.Size(), ModTime: stat.ModTime()}}, nil
+}
+
+func (l *Local) Stat(key string) (ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return ObjectInfo{Key: key, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+func (l *Local) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) List(prefix string, fn func(ObjectInfo) error) error {
+	err := filepath.WalkDir(l.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if p != l.Root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !validKey(key) || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(ObjectInfo{Key: key, Size: stat.Size(), ModTime: stat.ModTime()})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	return nil
+}
+
+// sign calcule la signature d'une URL présignée locale
+func (l *Local) sign(key string, expires int64, opts ServeOptions) string {
+	h := hmac.New(sha256.New, l.Secret)
+	fmt.Fprintf(h, "%s|%d|%s|%s|%t", key, expires, opts.Filename, opts.ContentType, opts.Inline)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PresignGet retourne une URL signée servie par ServeSigned
+func (l *Local) PresignGet(key string, ttl time.Duration, opts ServeOptions) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	expires := time.Now().Add(ttl).Unix()
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	if opts.Filename != "" {
+		q.Set("name", opts.Filename)
+	}
+	if opts.ContentType != "" {
+		q.Set("type", opts.ContentType)
+	}
+	if opts.Inline {
+		q.Set("inline", "1")
+	}
+	q.Set("sig", l.sign(key, expires, opts))
+
+	u := &url.URL{Path: SignedFilesPath + key, RawQuery: q.Encode()}
+	return l.BaseURL + u.String(), nil
+}
+
+// ServeSigned sert un fichier demandé par une URL présignée locale
+func (l *Local) ServeSigned(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	opts := ServeOptions{Filename: q.Get("name"), ContentType: q.Get("type"), Inline: q.Get("inline") == "1"}
+
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(q.Get("sig")), []byte(l.sign(key, expires, opts))) {
+		http.Error(w, "Lien expiré ou signature invalide", http.StatusForbidden)
+		return
+	}
+
+	obj, err := l.Open(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer obj.Close()
+	serveObject(w, r, obj, opts)
+}
@@ -0,0 +1,205 @@
+// internal/storage/storage.go
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/okinrev/veza-web-app/internal/utils"
+)
+
+var (
+	ErrNotFound   = errors.New("stored file not found")
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Espaces de noms des fichiers stockés, préfixes des clés
+const (
+	NamespaceAudio       = "audio"
+	NamespaceShared      = "shared"
+	NamespaceAvatars     = "avatars"
+	NamespaceCollections = "collections"
+	NamespaceListings    = "listings"
+	NamespaceDisputes    = "disputes"
+
+	// NamespaceBlobs contient les fichiers adressés par leur contenu (voir le paquet blob)
+	NamespaceBlobs = "blobs"
+)
+
+// ObjectInfo décrit un fichier stocké
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Object est un fichier stocké ouvert en lecture, avec accès aléatoire pour
+// les archives zip et les requêtes partielles (Range)
+type Object interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	Info() ObjectInfo
+}
+
+// ServeOptions décrit la manière dont un fichier est envoyé au client
+type ServeOptions struct {
+	Filename    string // nom proposé au téléchargement
+	ContentType string // déduit de l'extension si vide
+	Inline      bool   // affichage dans le navigateur plutôt que téléchargement
+}
+
+// Backend est un pilote de stockage : disque local ou service compatible S3
+type Backend interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Open(key string) (Object, error)
+	Stat(key string) (ObjectInfo, error)
+	Delete(key string) error
+	// List parcourt les fichiers dont la clé commence par prefix
+	List(prefix string, fn func(ObjectInfo) error) error
+	// PresignGet retourne une URL de téléchargement valable ttl sans authentification
+	PresignGet(key string, ttl time.Duration, opts ServeOptions) (string, error)
+}
+
+// Key construit la clé d'un fichier en refusant toute traversée de répertoire
+func Key(namespace, filename string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") || strings.Contains(filename, "/") {
+		return "", ErrInvalidKey
+	}
+	return namespace + "/" + filename, nil
+}
+
+// validKey vérifie une clé complète reçue d'un client ou d'un listing
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Store est le point d'accès des modules au stockage des fichiers
+type Store struct {
+	Backend
+
+	// redirectTTL > 0 : les téléchargements sont redirigés vers une URL présignée
+	// plutôt que relayés par l'API
+	redirectTTL time.Duration
+}
+
+func NewStore(backend Backend, redirectTTL time.Duration) *Store {
+	return &Store{Backend: backend, redirectTTL: redirectTTL}
+}
+
+// PutFile copie un fichier local dans le stockage et retourne sa taille
+func (s *Store) PutFile(key, localPath string) (int64, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if err := s.Put(key, f, info.Size(), mime.TypeByExtension(path.Ext(key))); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Remove supprime un fichier stocké ; un échec est seulement journalisé
+func (s *Store) Remove(key string) {
+	if err := s.Delete(key); err != nil && !errors.Is(err, ErrNotFound) {
+		utils.LogError(fmt.Sprintf("Failed to remove stored file %s: %v", key, err))
+	}
+}
+
+// Serve envoie un fichier stocké, directement ou par redirection vers une URL présignée
+func (s *Store) Serve(w http.ResponseWriter, r *http.Request, key string, opts ServeOptions) error {
+	if s.redirectTTL > 0 {
+		url, err := s.PresignGet(key, s.redirectTTL, opts)
+		if err != nil {
+			return err
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+		return nil
+	}
+
+	obj, err := s.Open(key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	serveObject(w, r, obj, opts)
+	return nil
+}
+
+func serveObject(w http.ResponseWriter, r *http.Request, obj Object, opts ServeOptions) {
+	info := obj.Info()
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(info.Key))
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if disposition := contentDisposition(opts); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(w, r, "", info.ModTime, obj)
+}
+
+func contentDisposition(opts ServeOptions) string {
+	if opts.Filename == "" {
+		if opts.Inline {
+			return ""
+		}
+		return "attachment"
+	}
+	disposition := "attachment"
+	if opts.Inline {
+		disposition = "inline"
+	}
+	return mime.FormatMediaType(disposition, map[string]string{"filename": opts.Filename})
+}
+
+// Spool écrit le contenu reçu dans un fichier temporaire local, qui conserve
+// l'extension de name, pour l'inspecter et l'analyser avant de le stocker
+func Spool(src io.Reader, name string) (string, int64, error) {
+	f, err := os.CreateTemp("", "veza-*-"+filepath.Base(name))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	written, err := io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", 0, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	return f.Name(), written, nil
+}
+
+// Copy copie un fichier d'un stockage à l'autre
+func Copy(dst, src Backend, info ObjectInfo) error {
+	obj, err := src.Open(info.Key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	return dst.Put(info.Key, obj, obj.Info().Size, mime.TypeByExtension(path.Ext(info.Key)))
+}
@@ -10,10 +10,12 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/okinrev/veza-web-app/internal/blob"
+	"github.com/okinrev/veza-web-app/internal/storage"
 )
 
 var secretKey = []byte(strings.Trim(os.Getenv("SIGNED_URL_SECRET"), `"`))
@@ -48,29 +50,32 @@ func ValidateSignature(filename string, expiresStr string, sig string) bool {
 	return hmac.Equal([]byte(sig), []byte(validSig))
 }
 
-// Secure stream handler with signature validation
-func StreamAudioWithValidation(w http.ResponseWriter, r *http.Request) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		http.NotFound(w, r)
-		return
-	}
-	filename := parts[len(parts)-1]
-	expires := r.URL.Query().Get("expires")
-	sig := r.URL.Query().Get("sig")
+// Secure stream handler with signature validation, serving audio files from the storage backend
+func StreamAudioWithValidation(store *blob.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) < 3 {
+			http.NotFound(w, r)
+			return
+		}
+		filename := parts[len(parts)-1]
+		expires := r.URL.Query().Get("expires")
+		sig := r.URL.Query().Get("sig")
 
-	if !ValidateSignature(filename, expires, sig) {
-		http.Error(w, "Lien expiré ou signature invalide", http.StatusForbidden)
-		return
-	}
+		if !ValidateSignature(filename, expires, sig) {
+			http.Error(w, "Lien expiré ou signature invalide", http.StatusForbidden)
+			return
+		}
 
-	path := filepath.Join("audio", filename)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		http.NotFound(w, r)
-		return
+		key, err := storage.Key(storage.NamespaceAudio, filename)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := store.Serve(w, r, key, storage.ServeOptions{Inline: true}); err != nil {
+			http.NotFound(w, r)
+		}
 	}
-
-	http.ServeFile(w, r, path)
 }
 
 // Signed URL generator handler (protected route)
@@ -0,0 +1,477 @@
+// internal/utils/media/image.go
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/vp8l"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrInvalidImage  = errors.New("invalid or unsupported image")
+	ErrImageTooLarge = errors.New("image dimensions are too large")
+)
+
+// Formats d'image acceptés
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// MaxImagePixels borne la taille d'une image décodée en mémoire (40 mégapixels)
+const MaxImagePixels = 40_000_000
+
+// CleanImage est une image validée et débarrassée de ses métadonnées
+type CleanImage struct {
+	Format string
+	Width  int
+	Height int
+	Data   []byte // contenu sans EXIF (position GPS, appareil...) ni XMP
+
+	// Image décodée et orientée, source des miniatures
+	Image image.Image
+}
+
+// CleanImageData valide une image JPEG, PNG ou WebP et retire ses métadonnées.
+// Une photo JPEG pivotée par son orientation EXIF est réencodée dans le bon sens,
+// l'orientation disparaissant avec l'EXIF.
+func CleanImageData(data []byte) (*CleanImage, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return cleanJPEG(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return cleanPNG(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return cleanWebP(data)
+	}
+	return nil, ErrInvalidImage
+}
+
+func checkDimensions(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return ErrInvalidImage
+	}
+	if int64(width)*int64(height) > MaxImagePixels {
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
+// cleanJPEG retire les segments APP1 (EXIF, XMP), APP13 (IPTC), les index MPF
+// (APP2) et les commentaires ; les profils de couleur (APP2) et APP14 sont
+// conservés. La copie s'arrête au marqueur EOI de l'image principale : les
+// images secondaires MPF et vidéos ajoutées par les téléphones, qui portent
+// leurs propres EXIF, sont abandonnées.
+func cleanJPEG(data []byte) (*CleanImage, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	orientation := 1
+	pos := 2
+	for {
+		if pos+2 > len(data) || data[pos] != 0xff {
+			return nil, ErrInvalidImage
+		}
+		marker := data[pos+1]
+		if marker == 0xff {
+			pos++ // octet de remplissage
+			continue
+		}
+		if marker == 0xd9 {
+			out.Write(data[pos : pos+2])
+			break
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		}
+		if marker == 0xd8 || pos+4 > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+		if marker == 0xda {
+			// Données compressées du scan, jusqu'au prochain marqueur autre
+			// qu'un octet échappé (FF00) ou un marqueur de resynchronisation
+			scanEnd, err := entropyEnd(data, end)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[pos:scanEnd])
+			pos = scanEnd
+			continue
+		}
+
+		payload := data[pos+4 : end]
+		switch marker {
+		case 0xe1:
+			if o := exifOrientation(payload); o != 0 {
+				orientation = o
+			}
+		case 0xe2:
+			if !bytes.HasPrefix(payload, []byte("MPF\x00")) {
+				out.Write(data[pos:end])
+			}
+		case 0xed, 0xfe:
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	clean := &CleanImage{Format: FormatJPEG, Width: cfg.Width, Height: cfg.Height, Data: out.Bytes(), Image: img}
+
+	if orientation > 1 && orientation <= 8 {
+		oriented := orient(toRGBA(img), orientation)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: 92}); err != nil {
+			return nil, err
+		}
+		clean.Data = buf.Bytes()
+		clean.Image = oriented
+		clean.Width, clean.Height = oriented.Bounds().Dx(), oriented.Bounds().Dy()
+	}
+	return clean, nil
+}
+
+// entropyEnd retourne la position du marqueur qui termine les données
+// compressées commençant à pos
+func entropyEnd(data []byte, pos int) (int, error) {
+	for ; pos+1 < len(data); pos++ {
+		if data[pos] != 0xff {
+			continue
+		}
+		next := data[pos+1]
+		if next != 0x00 && next != 0xff && (next < 0xd0 || next > 0xd7) {
+			return pos, nil
+		}
+	}
+	return 0, ErrInvalidImage
+}
+
+// exifOrientation lit l'orientation (tag 0x0112) de l'IFD0 d'un segment EXIF ;
+// 0 si elle est absente ou illisible
+func exifOrientation(segment []byte) int {
+	if !bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+		return 0
+	}
+	tiff := segment[6:]
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+// pngMetadataChunks sont les chunks PNG retirés : EXIF, textes et date
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func cleanPNG(data []byte) (*CleanImage, error) {
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:8])
+	pos := 8
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, ErrInvalidImage
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return &CleanImage{Format: FormatPNG, Width: cfg.Width, Height: cfg.Height, Data: out.Bytes(), Image: img}, nil
+}
+
+// cleanWebP retire les chunks EXIF et XMP du conteneur RIFF et les indicateurs
+// correspondants de l'en-tête VP8X, puis décode l'image pour la valider. Les
+// WebP animés ne sont pas acceptés.
+func cleanWebP(data []byte) (*CleanImage, error) {
+	var chunks [][]byte
+	width, height := 0, 0
+	frameWidth, frameHeight := 0, 0
+	var lossless []byte
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		fourcc := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size&1
+		if pos+8+size > len(data) {
+			return nil, ErrInvalidImage
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		payload := data[pos+8 : pos+8+size]
+
+		switch fourcc {
+		case "EXIF", "XMP ":
+			pos = end
+			continue
+		case "VP8X":
+			if size < 10 {
+				return nil, ErrInvalidImage
+			}
+			chunk := append([]byte(nil), data[pos:end]...)
+			chunk[8] &^= 0x08 | 0x04 // indicateurs EXIF et XMP
+			chunks = append(chunks, chunk)
+			width = int(uint32(payload[4])|uint32(payload[5])<<8|uint32(payload[6])<<16) + 1
+			height = int(uint32(payload[7])|uint32(payload[8])<<8|uint32(payload[9])<<16) + 1
+			pos = end
+			continue
+		case "VP8 ":
+			if size < 10 || !bytes.Equal(payload[3:6], []byte{0x9d, 0x01, 0x2a}) {
+				return nil, ErrInvalidImage
+			}
+			frameWidth = int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff)
+			frameHeight = int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff)
+		case "VP8L":
+			if size < 5 || payload[0] != 0x2f {
+				return nil, ErrInvalidImage
+			}
+			lossless = payload
+			bits := binary.LittleEndian.Uint32(payload[1:5])
+			frameWidth = int(bits&0x3fff) + 1
+			frameHeight = int(bits>>14&0x3fff) + 1
+		}
+		chunks = append(chunks, data[pos:end])
+		pos = end
+	}
+	if width == 0 {
+		width, height = frameWidth, frameHeight
+	}
+	// Les dimensions du flux sont bornées elles aussi avant le décodage
+	if err := checkDimensions(width, height); err != nil {
+		return nil, err
+	}
+	if err := checkDimensions(frameWidth, frameHeight); err != nil {
+		return nil, err
+	}
+
+	riffSize := 4
+	for _, chunk := range chunks {
+		riffSize += len(chunk)
+	}
+	out := bytes.NewBuffer(make([]byte, 0, riffSize+8))
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(riffSize))
+	out.WriteString("WEBP")
+	for _, chunk := range chunks {
+		out.Write(chunk)
+	}
+
+	// Un flux sans perte porte son propre canal alpha, que webp.Decode refuse
+	// derrière l'indicateur alpha de VP8X : il est décodé directement
+	var img image.Image
+	var err error
+	if lossless != nil {
+		img, err = vp8l.Decode(bytes.NewReader(lossless))
+	} else {
+		img, err = webp.Decode(bytes.NewReader(out.Bytes()))
+	}
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		return nil, ErrInvalidImage
+	}
+	return &CleanImage{Format: FormatWebP, Width: width, Height: height, Data: out.Bytes(), Image: img}, nil
+}
+
+// toRGBA convertit une image en RGBA dont l'origine est (0, 0)
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// orient applique une orientation EXIF (2 à 8) à une image
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
+
+// Thumbnail réduit une image pour que son plus grand côté mesure au plus
+// maxSide pixels, en moyennant les pixels couverts par chaque pixel réduit.
+// Une image déjà assez petite est retournée telle quelle.
+func Thumbnail(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	rgba := toRGBA(src)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := ty*h/th, (ty+1)*h/th
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := tx*w/tw, (tx+1)*w/tw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[rgba.PixOffset(x0, y):rgba.PixOffset(x1, y)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			off := dst.PixOffset(tx, ty)
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// ThumbnailFormat retourne le format des miniatures d'une image : le sien pour
+// le JPEG et le PNG. Le WebP, que la bibliothèque standard ne sait pas encoder,
+// donne des miniatures PNG s'il est transparent et JPEG sinon.
+func ThumbnailFormat(clean *CleanImage) string {
+	if clean.Format != FormatWebP {
+		return clean.Format
+	}
+	if img, ok := clean.Image.(interface{ Opaque() bool }); ok && img.Opaque() {
+		return FormatJPEG
+	}
+	return FormatPNG
+}
+
+// EncodeImage encode une image au format donné (JPEG ou PNG)
+func EncodeImage(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+	return ErrInvalidImage
+}
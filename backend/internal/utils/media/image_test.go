@@ -0,0 +1,385 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 40), uint8(y * 40), 128, 255})
+		}
+	}
+	return img
+}
+
+// jpegSegment encode un segment JPEG avec sa longueur
+func jpegSegment(marker byte, payload string) []byte {
+	seg := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:4], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// exifSegment construit un segment APP1 EXIF big-endian dont l'IFD0 contient
+// l'orientation, suivi d'une coordonnée GPS factice
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 48.8566N 2.3522E")
+	return jpegSegment(0xe1, "Exif\x00\x00"+tiff.String())
+}
+
+const xmpPayload = "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>Canon EOS</x:xmpmeta>"
+
+// withJPEGSegments insère des segments juste après le marqueur SOI
+func withJPEGSegments(data []byte, segments ...[]byte) []byte {
+	out := append([]byte(nil), data[:2]...)
+	for _, seg := range segments {
+		out = append(out, seg...)
+	}
+	return append(out, data[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCleanImageDataJPEG(t *testing.T) {
+	plain := encodeJPEG(t, testImage(4, 2))
+
+	tests := []struct {
+		name       string
+		segments   [][]byte
+		trailer    []byte
+		wantWidth  int
+		wantHeight int
+		keep       []string
+	}{
+		{
+			name:      "EXIF, XMP, IPTC and comment removed",
+			segments:  [][]byte{exifSegment(1), jpegSegment(0xe1, xmpPayload), jpegSegment(0xed, "Photoshop 3.0\x00IPTC"), jpegSegment(0xfe, "shot at home")},
+			wantWidth: 4, wantHeight: 2,
+		},
+		{
+			name:      "colour profile kept",
+			segments:  [][]byte{jpegSegment(0xe2, "ICC_PROFILE\x00\x01\x01sRGB"), jpegSegment(0xe1, xmpPayload)},
+			wantWidth: 4, wantHeight: 2,
+			keep: []string{"ICC_PROFILE"},
+		},
+		{
+			name:      "MPF index removed",
+			segments:  [][]byte{jpegSegment(0xe2, "MPF\x00MM\x00\x2a\x00\x00\x00\x08")},
+			wantWidth: 4, wantHeight: 2,
+		},
+		{
+			// Image secondaire MPF ou vidéo ajoutée après l'image principale
+			name:      "data after the end of image removed",
+			trailer:   withJPEGSegments(encodeJPEG(t, testImage(2, 2)), exifSegment(1), jpegSegment(0xe1, xmpPayload)),
+			wantWidth: 4, wantHeight: 2,
+		},
+		{
+			name:      "rotated photo re-encoded upright",
+			segments:  [][]byte{exifSegment(6)},
+			wantWidth: 2, wantHeight: 4,
+		},
+		{
+			name:      "mirrored photo keeps its size",
+			segments:  [][]byte{exifSegment(2)},
+			wantWidth: 4, wantHeight: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(withJPEGSegments(plain, tt.segments...), tt.trailer...)
+			clean, err := CleanImageData(data)
+			if err != nil {
+				t.Fatalf("CleanImageData: %v", err)
+			}
+			if clean.Format != FormatJPEG || clean.Width != tt.wantWidth || clean.Height != tt.wantHeight {
+				t.Errorf("got %s %dx%d, want jpeg %dx%d", clean.Format, clean.Width, clean.Height, tt.wantWidth, tt.wantHeight)
+			}
+			for _, marker := range []string{"Exif", "GPS", "xmpmeta", "Photoshop", "shot at home", "MPF"} {
+				if bytes.Contains(clean.Data, []byte(marker)) {
+					t.Errorf("cleaned data still contains %q", marker)
+				}
+			}
+			for _, marker := range tt.keep {
+				if !bytes.Contains(clean.Data, []byte(marker)) {
+					t.Errorf("cleaned data lost %q", marker)
+				}
+			}
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(clean.Data))
+			if err != nil {
+				t.Fatalf("cleaned data is not a JPEG: %v", err)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Errorf("cleaned JPEG is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if soi := bytes.Count(clean.Data, []byte("\xff\xd8")); soi != 1 {
+				t.Errorf("cleaned data holds %d start of image markers, want 1", soi)
+			}
+			if !bytes.HasSuffix(clean.Data, []byte("\xff\xd9")) {
+				t.Errorf("cleaned data does not end with the end of image marker")
+			}
+			if b := clean.Image.Bounds(); b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("decoded image is %v, want %dx%d", b, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+// pngChunk encode un chunk PNG avec son CRC
+func pngChunk(kind, payload string) []byte {
+	out := make([]byte, 4, 12+len(payload))
+	binary.BigEndian.PutUint32(out, uint32(len(payload)))
+	out = append(out, kind...)
+	out = append(out, payload...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[4:]))
+}
+
+func TestCleanImageDataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(3, 5)); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	plain := buf.Bytes()
+
+	// Les métadonnées sont placées après IHDR (8 octets de signature et 25 d'IHDR)
+	ihdrEnd := 8 + 25
+	data := append([]byte(nil), plain[:ihdrEnd]...)
+	data = append(data, pngChunk("tEXt", "Author\x00Jane Doe")...)
+	data = append(data, pngChunk("iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>")...)
+	data = append(data, pngChunk("eXIf", "MM\x00\x2a\x00\x00\x00\x08")...)
+	data = append(data, pngChunk("tIME", "\x07\xea\x0a\x13\x0c\x00\x00")...)
+	data = append(data, pngChunk("zTXt", "Comment\x00\x00x")...)
+	data = append(data, plain[ihdrEnd:]...)
+
+	clean, err := CleanImageData(data)
+	if err != nil {
+		t.Fatalf("CleanImageData: %v", err)
+	}
+	if clean.Format != FormatPNG || clean.Width != 3 || clean.Height != 5 {
+		t.Errorf("got %s %dx%d, want png 3x5", clean.Format, clean.Width, clean.Height)
+	}
+	if !bytes.Equal(clean.Data, plain) {
+		t.Errorf("cleaned PNG differs from the image without metadata chunks")
+	}
+	if b := clean.Image.Bounds(); b.Dx() != 3 || b.Dy() != 5 {
+		t.Errorf("decoded image is %v, want 3x5", b)
+	}
+}
+
+// webpChunk encode un chunk RIFF WebP, octet de bourrage compris
+func webpChunk(fourcc, payload string) []byte {
+	out := []byte(fourcc)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func webpFile(chunks ...[]byte) []byte {
+	body := append([]byte("WEBP"), bytes.Join(chunks, nil)...)
+	out := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	return append(out, body...)
+}
+
+// vp8lChunk encode une image sans perte d'une couleur unie : chaque canal est un
+// code préfixe à un seul symbole, si bien que les pixels n'occupent aucun bit
+func vp8lChunk(width, height int, c color.NRGBA) []byte {
+	var bits []byte
+	var acc uint64
+	n := 0
+	write := func(v uint64, size int) {
+		acc |= v << n
+		for n += size; n >= 8; n -= 8 {
+			bits = append(bits, byte(acc))
+			acc >>= 8
+		}
+	}
+	write(uint64(width-1), 14)
+	write(uint64(height-1), 14)
+	write(0, 1) // indicateur alpha, informatif
+	write(0, 3) // version
+	write(0, 1) // aucune transformation
+	write(0, 1) // aucun cache de couleurs
+	write(0, 1) // aucun code préfixe par zone
+	for _, symbol := range []uint8{c.G, c.R, c.B, c.A, 0} {
+		write(1, 1) // code simple
+		write(0, 1) // un seul symbole
+		write(1, 1) // sur 8 bits
+		write(uint64(symbol), 8)
+	}
+	write(0, 8)
+	return webpChunk("VP8L", "\x2f"+string(bits))
+}
+
+func vp8xChunk(flags byte, width, height int) []byte {
+	payload := []byte{flags, 0, 0, 0,
+		byte(width - 1), byte((width - 1) >> 8), byte((width - 1) >> 16),
+		byte(height - 1), byte((height - 1) >> 8), byte((height - 1) >> 16)}
+	return webpChunk("VP8X", string(payload))
+}
+
+func TestCleanImageDataWebP(t *testing.T) {
+	blue := color.NRGBA{0, 0, 255, 255}
+	data := webpFile(
+		vp8xChunk(0x10|0x08|0x04, 640, 480), // alpha, EXIF, XMP
+		vp8lChunk(640, 480, blue),
+		webpChunk("EXIF", "MM\x00\x2a\x00\x00\x00\x08GPS"),
+		webpChunk("XMP ", "<x:xmpmeta>Canon EOS</x:xmpmeta>"),
+	)
+
+	clean, err := CleanImageData(data)
+	if err != nil {
+		t.Fatalf("CleanImageData: %v", err)
+	}
+	if clean.Format != FormatWebP || clean.Width != 640 || clean.Height != 480 {
+		t.Errorf("got %s %dx%d, want webp 640x480", clean.Format, clean.Width, clean.Height)
+	}
+	want := webpFile(vp8xChunk(0x10, 640, 480), vp8lChunk(640, 480, blue))
+	if !bytes.Equal(clean.Data, want) {
+		t.Errorf("cleaned WebP =\n  % x\nwant\n  % x", clean.Data, want)
+	}
+	if clean.Image == nil {
+		t.Fatal("WebP not decoded")
+	}
+	if got := color.NRGBAModel.Convert(clean.Image.At(320, 240)); got != blue {
+		t.Errorf("decoded pixel = %v, want %v", got, blue)
+	}
+}
+
+func TestThumbnailFormat(t *testing.T) {
+	decode := func(c color.NRGBA) *CleanImage {
+		clean, err := CleanImageData(webpFile(vp8lChunk(8, 8, c)))
+		if err != nil {
+			t.Fatalf("CleanImageData: %v", err)
+		}
+		return clean
+	}
+
+	tests := []struct {
+		name  string
+		clean *CleanImage
+		want  string
+	}{
+		{"JPEG", &CleanImage{Format: FormatJPEG}, FormatJPEG},
+		{"PNG", &CleanImage{Format: FormatPNG}, FormatPNG},
+		{"opaque WebP", decode(color.NRGBA{10, 20, 30, 255}), FormatJPEG},
+		{"transparent WebP", decode(color.NRGBA{10, 20, 30, 128}), FormatPNG},
+	}
+	for _, tt := range tests {
+		if got := ThumbnailFormat(tt.clean); got != tt.want {
+			t.Errorf("%s: ThumbnailFormat = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCleanImageDataInvalid(t *testing.T) {
+	plainJPEG := encodeJPEG(t, testImage(2, 2))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidImage},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), ErrInvalidImage},
+		{"JPEG signature only", []byte("\xff\xd8\xff\xe0"), ErrInvalidImage},
+		{"truncated JPEG", plainJPEG[:len(plainJPEG)/2], ErrInvalidImage},
+		{"PNG signature only", []byte("\x89PNG\r\n\x1a\n"), ErrInvalidImage},
+		{"WebP without image", webpFile(webpChunk("EXIF", "x")), ErrInvalidImage},
+		{"WebP chunk past the end", webpFile([]byte("VP8L\xff\x00\x00\x00\x2f")), ErrInvalidImage},
+		{"oversized WebP", webpFile(vp8lChunk(16384, 16384, color.NRGBA{})), ErrImageTooLarge},
+		{"oversized WebP frame", webpFile(vp8xChunk(0, 64, 64), vp8lChunk(16384, 16384, color.NRGBA{})), ErrImageTooLarge},
+		{"VP8X without image", webpFile(vp8xChunk(0, 64, 64)), ErrInvalidImage},
+		{"VP8X with junk image data", webpFile(vp8xChunk(0, 64, 64), webpChunk("VP8L", "\x2f\x3f\xf0\x0f\x00junk")), ErrInvalidImage},
+		{"VP8X and frame sizes differ", webpFile(vp8xChunk(0, 64, 64), vp8lChunk(32, 32, color.NRGBA{})), ErrInvalidImage},
+		{"animated WebP", webpFile(vp8xChunk(0x02, 64, 64), webpChunk("ANIM", "\x00\x00\x00\x00\x00\x00"), webpChunk("ANMF", "frame")), ErrInvalidImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if clean, err := CleanImageData(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("CleanImageData = %+v, %v, want %v", clean, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	littleEndian := []byte("Exif\x00\x00II\x2a\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x08\x00\x00\x00")
+
+	tests := []struct {
+		name    string
+		segment []byte
+		want    int
+	}{
+		{"big-endian", exifSegment(6)[4:], 6},
+		{"little-endian", littleEndian, 8},
+		{"XMP", []byte(xmpPayload), 0},
+		{"truncated IFD", littleEndian[:18], 0},
+		{"unknown byte order", []byte("Exif\x00\x00XX\x2a\x00\x08\x00\x00\x00"), 0},
+	}
+	for _, tt := range tests {
+		if got := exifOrientation(tt.segment); got != tt.want {
+			t.Errorf("%s: exifOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// Image de 2x1 : A à gauche, B à droite
+	a, b := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, a)
+	src.SetRGBA(1, 0, b)
+
+	tests := []struct {
+		orientation int
+		want        [][]color.RGBA // lignes de l'image orientée
+	}{
+		{2, [][]color.RGBA{{b, a}}},
+		{3, [][]color.RGBA{{b, a}}},
+		{4, [][]color.RGBA{{a, b}}},
+		{5, [][]color.RGBA{{a}, {b}}},
+		{6, [][]color.RGBA{{a}, {b}}},
+		{7, [][]color.RGBA{{b}, {a}}},
+		{8, [][]color.RGBA{{b}, {a}}},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		if got.Rect.Dy() != len(tt.want) || got.Rect.Dx() != len(tt.want[0]) {
+			t.Errorf("orient(%d) bounds = %v", tt.orientation, got.Rect)
+			continue
+		}
+		for y, row := range tt.want {
+			for x, c := range row {
+				if got.RGBAAt(x, y) != c {
+					t.Errorf("orient(%d) pixel (%d, %d) = %v, want %v", tt.orientation, x, y, got.RGBAAt(x, y), c)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// internal/utils/media/midi.go
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var ErrInvalidMIDI = errors.New("invalid standard MIDI file")
+
+// MIDIInfo contient les informations musicales extraites d'un fichier MIDI standard
+type MIDIInfo struct {
+	Format        int
+	TempoBPM      float64
+	TimeSignature string // ex: 4/4
+	KeySignature  string // ex: C, Am, F#m (vide si absente)
+	LengthBars    float64
+	TrackCount    int
+	ChannelCount  int
+	LowestNote    int // -1 si aucune note
+	HighestNote   int // -1 si aucune note
+}
+
+type timeSigEvent struct {
+	tick        uint64
+	numerator   int
+	denominator int
+}
+
+var majorKeys = []string{"Cb", "Gb", "Db", "Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#"}
+var minorKeys = []string{"Abm", "Ebm", "Bbm", "Fm", "Cm", "Gm", "Dm", "Am", "Em", "Bm", "F#m", "C#m", "G#m", "D#m", "A#m"}
+
+var noteNames = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// NoteName retourne le nom d'une note MIDI (60 = C4)
+func NoteName(note int) string {
+	if note < 0 || note > 127 {
+		return ""
+	}
+	return fmt.Sprintf("%s%d", noteNames[note%12], note/12-1)
+}
+
+// ParseMIDI analyse un fichier MIDI standard (formats 0, 1 et 2)
+func ParseMIDI(r io.Reader) (*MIDIInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 14 || string(data[0:4]) != "MThd" {
+		return nil, ErrInvalidMIDI
+	}
+	headerLen := int(binary.BigEndian.Uint32(data[4:8]))
+	if headerLen < 6 || 8+headerLen > len(data) {
+		return nil, ErrInvalidMIDI
+	}
+
+	info := &MIDIInfo{
+		Format:      int(binary.BigEndian.Uint16(data[8:10])),
+		TrackCount:  int(binary.BigEndian.Uint16(data[10:12])),
+		LowestNote:  -1,
+		HighestNote: -1,
+	}
+	division := binary.BigEndian.Uint16(data[12:14])
+
+	var tempo uint32 // microsecondes par noire, 0 = non défini
+	var timeSigs []timeSigEvent
+	channels := make(map[int]bool)
+	var lastTick uint64
+
+	pos := 8 + headerLen
+	for track := 0; track < info.TrackCount && pos+8 <= len(data); track++ {
+		chunkType := string(data[pos : pos+4])
+		chunkLen := int(binary.BigEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8
+		if chunkLen < 0 || pos+chunkLen > len(data) {
+			return nil, ErrInvalidMIDI
+		}
+		chunk := data[pos : pos+chunkLen]
+		pos += chunkLen
+
+		if chunkType != "MTrk" {
+			// Les chunks inconnus sont ignorés (spécification SMF)
+			track--
+			continue
+		}
+
+		endTick, err := parseTrack(chunk, info, &tempo, &timeSigs, channels)
+		if err != nil {
+			return nil, err
+		}
+		if info.Format == 2 {
+			// Format 2 : pistes séquentielles indépendantes
+			lastTick += endTick
+		} else if endTick > lastTick {
+			lastTick = endTick
+		}
+	}
+
+	if tempo == 0 {
+		tempo = 500000 // 120 BPM par défaut
+	}
+	info.TempoBPM = float64(int(60000000.0/float64(tempo)*100+0.5)) / 100
+	info.ChannelCount = len(channels)
+
+	if len(timeSigs) == 0 {
+		timeSigs = []timeSigEvent{{tick: 0, numerator: 4, denominator: 4}}
+	}
+	sort.SliceStable(timeSigs, func(i, j int) bool { return timeSigs[i].tick < timeSigs[j].tick })
+	info.TimeSignature = fmt.Sprintf("%d/%d", timeSigs[0].numerator, timeSigs[0].denominator)
+
+	// Division SMPTE (bit de poids fort à 1) : pas de notion de mesure
+	if division&0x8000 == 0 && division > 0 {
+		info.LengthBars = lengthInBars(lastTick, uint64(division), timeSigs)
+	}
+
+	return info, nil
+}
+
+// parseTrack parcourt les événements d'une piste et retourne son tick de fin
+func parseTrack(chunk []byte, info *MIDIInfo, tempo *uint32, timeSigs *[]timeSigEvent, channels map[int]bool) (uint64, error) {
+	reader := bytes.NewReader(chunk)
+	var tick uint64
+	var runningStatus byte
+
+	for reader.Len() > 0 {
+		delta, err := readVarLen(reader)
+		if err != nil {
+			return 0, err
+		}
+		tick += uint64(delta)
+
+		status, err := reader.ReadByte()
+		if err != nil {
+			return 0, ErrInvalidMIDI
+		}
+
+		switch {
+		case status == 0xFF:
+			metaType, err := reader.ReadByte()
+			if err != nil {
+				return 0, ErrInvalidMIDI
+			}
+			payload, err := readPayload(reader)
+			if err != nil {
+				return 0, err
+			}
+			switch metaType {
+			case 0x51:
+				if len(payload) == 3 && *tempo == 0 {
+					*tempo = uint32(payload[0])<<16 | uint32(payload[1])<<8 | uint32(payload[2])
+				}
+			case 0x58:
+				if len(payload) >= 2 && payload[1] < 8 {
+					*timeSigs = append(*timeSigs, timeSigEvent{
+						tick:        tick,
+						numerator:   int(payload[0]),
+						denominator: 1 << payload[1],
+					})
+				}
+			case 0x59:
+				if len(payload) == 2 && info.KeySignature == "" {
+					sf := int(int8(payload[0]))
+					if sf >= -7 && sf <= 7 {
+						if payload[1] == 1 {
+							info.KeySignature = NormalizeKey(minorKeys[sf+7])
+						} else {
+							info.KeySignature = NormalizeKey(majorKeys[sf+7])
+						}
+					}
+				}
+			case 0x2F:
+				return tick, nil
+			}
+			runningStatus = 0
+
+		case status == 0xF0 || status == 0xF7:
+			if _, err := readPayload(reader); err != nil {
+				return 0, err
+			}
+			runningStatus = 0
+
+		case status > 0xF0:
+			// Messages système communs (F1-F6) et temps réel (F8-FE) : absents d'un
+			// fichier SMF, sinon via un échappement F7. Leur longueur est inconnue ici.
+			return 0, ErrInvalidMIDI
+
+		default:
+			var data1 byte
+			if status < 0x80 {
+				// Running status : l'octet lu est la première donnée
+				if runningStatus == 0 {
+					return 0, ErrInvalidMIDI
+				}
+				data1 = status
+				status = runningStatus
+			} else {
+				runningStatus = status
+				if data1, err = reader.ReadByte(); err != nil {
+					return 0, ErrInvalidMIDI
+				}
+			}
+
+			kind := status & 0xF0
+			channel := int(status & 0x0F)
+			var data2 byte
+			if kind != 0xC0 && kind != 0xD0 {
+				if data2, err = reader.ReadByte(); err != nil {
+					return 0, ErrInvalidMIDI
+				}
+			}
+
+			channels[channel] = true
+			if kind == 0x90 && data2 > 0 {
+				note := int(data1)
+				if info.LowestNote < 0 || note < info.LowestNote {
+					info.LowestNote = note
+				}
+				if note > info.HighestNote {
+					info.HighestNote = note
+				}
+			}
+		}
+	}
+
+	return tick, nil
+}
+
+// lengthInBars convertit une durée en ticks en nombre de mesures
+func lengthInBars(totalTicks, ticksPerQuarter uint64, timeSigs []timeSigEvent) float64 {
+	var bars float64
+	for i, ts := range timeSigs {
+		if ts.tick >= totalTicks {
+			break
+		}
+		end := totalTicks
+		if i+1 < len(timeSigs) && timeSigs[i+1].tick < totalTicks {
+			end = timeSigs[i+1].tick
+		}
+		ticksPerBar := float64(ticksPerQuarter) * 4 * float64(ts.numerator) / float64(ts.denominator)
+		if ticksPerBar > 0 {
+			bars += float64(end-ts.tick) / ticksPerBar
+		}
+	}
+	return float64(int(bars*100+0.5)) / 100
+}
+
+func readVarLen(r *bytes.Reader) (uint32, error) {
+	var value uint32
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, ErrInvalidMIDI
+		}
+		value = value<<7 | uint32(b&0x7F)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, ErrInvalidMIDI
+}
+
+func readPayload(r *bytes.Reader) ([]byte, error) {
+	length, err := readVarLen(r)
+	if err != nil {
+		return nil, err
+	}
+	if int(length) > r.Len() {
+		return nil, ErrInvalidMIDI
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, ErrInvalidMIDI
+	}
+	return payload, nil
+}
@@ -0,0 +1,191 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// smf construit un fichier MIDI standard à partir de ses chunks de piste
+func smf(format, division uint16, tracks ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MThd")
+	binary.Write(&buf, binary.BigEndian, uint32(6))
+	binary.Write(&buf, binary.BigEndian, format)
+	binary.Write(&buf, binary.BigEndian, uint16(len(tracks)))
+	binary.Write(&buf, binary.BigEndian, division)
+	for _, track := range tracks {
+		buf.Write(track)
+	}
+	return buf.Bytes()
+}
+
+func chunk(kind string, events ...[]byte) []byte {
+	data := bytes.Join(events, nil)
+	var buf bytes.Buffer
+	buf.WriteString(kind)
+	binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func mtrk(events ...[]byte) []byte {
+	return chunk("MTrk", events...)
+}
+
+func varLen(v uint32) []byte {
+	out := []byte{byte(v & 0x7F)}
+	for v >>= 7; v > 0; v >>= 7 {
+		out = append([]byte{byte(v&0x7F) | 0x80}, out...)
+	}
+	return out
+}
+
+// ev encode un événement précédé de son delta-time
+func ev(delta uint32, data ...byte) []byte {
+	return append(varLen(delta), data...)
+}
+
+func meta(delta uint32, kind byte, payload ...byte) []byte {
+	return ev(delta, append([]byte{0xFF, kind, byte(len(payload))}, payload...)...)
+}
+
+var endOfTrack = meta(0, 0x2F)
+
+func TestParseMIDI(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want MIDIInfo
+	}{
+		{
+			name: "format 0 with tempo, time and key signatures",
+			data: smf(0, 96, mtrk(
+				meta(0, 0x51, 0x07, 0xA1, 0x20), // 500000 µs : 120 BPM
+				meta(0, 0x58, 3, 2, 24, 8),      // 3/4
+				meta(0, 0x59, 0xFA, 1),          // 6 bémols, mineur : Ebm
+				ev(0, 0x90, 60, 100),            // C4
+				ev(0, 0x99, 36, 100),            // grosse caisse, canal 10
+				ev(288, 0x80, 60, 0),            // une mesure de 3/4
+				ev(0, 0x90, 72, 90),             // C5
+				ev(288, 0x80, 72, 0),
+				endOfTrack,
+			)),
+			want: MIDIInfo{Format: 0, TempoBPM: 120, TimeSignature: "3/4", KeySignature: "D#m", LengthBars: 2,
+				TrackCount: 1, ChannelCount: 2, LowestNote: 36, HighestNote: 72},
+		},
+		{
+			name: "defaults and running status",
+			data: smf(0, 480, mtrk(
+				ev(0, 0x90, 64, 100),
+				ev(0, 67, 100), // running status : deuxième note
+				ev(960, 64, 0), // vélocité nulle : note off
+				ev(0, 67, 0),
+				endOfTrack,
+			)),
+			want: MIDIInfo{TempoBPM: 120, TimeSignature: "4/4", LengthBars: 0.5,
+				TrackCount: 1, ChannelCount: 1, LowestNote: 64, HighestNote: 67},
+		},
+		{
+			name: "note on with zero velocity ignored",
+			data: smf(0, 96, mtrk(ev(0, 0x90, 50, 0), ev(96, 0xC0, 5), endOfTrack)),
+			want: MIDIInfo{TempoBPM: 120, TimeSignature: "4/4", LengthBars: 0.25,
+				TrackCount: 1, ChannelCount: 1, LowestNote: -1, HighestNote: -1},
+		},
+		{
+			name: "format 1 uses the longest track",
+			data: smf(1, 96,
+				mtrk(meta(0, 0x51, 0x09, 0x27, 0xC0), meta(0, 0x59, 2, 0), endOfTrack), // 100 BPM, D
+				mtrk(ev(0, 0x91, 48, 80), ev(384, 0x81, 48, 0), endOfTrack),
+				mtrk(ev(0, 0x92, 55, 80), ev(768, 0x82, 55, 0), endOfTrack),
+			),
+			want: MIDIInfo{Format: 1, TempoBPM: 100, TimeSignature: "4/4", KeySignature: "D", LengthBars: 2,
+				TrackCount: 3, ChannelCount: 2, LowestNote: 48, HighestNote: 55},
+		},
+		{
+			name: "format 2 plays tracks one after the other",
+			data: smf(2, 96,
+				mtrk(ev(0, 0x90, 60, 80), ev(384, 0x80, 60, 0), endOfTrack),
+				mtrk(ev(0, 0x90, 62, 80), ev(384, 0x80, 62, 0), endOfTrack),
+			),
+			want: MIDIInfo{Format: 2, TempoBPM: 120, TimeSignature: "4/4", LengthBars: 2,
+				TrackCount: 2, ChannelCount: 1, LowestNote: 60, HighestNote: 62},
+		},
+		{
+			name: "sysex and unknown chunks skipped",
+			data: smf(0, 96,
+				chunk("XFIH", []byte{1, 2, 3}),
+				mtrk(ev(0, 0xF0, 3, 0x7E, 0x7F, 0xF7), ev(0, 0xF7, 1, 0xF8), ev(0, 0x90, 40, 1), ev(96, 0x80, 40, 0), endOfTrack),
+			),
+			want: MIDIInfo{TempoBPM: 120, TimeSignature: "4/4", LengthBars: 0.25,
+				TrackCount: 2, ChannelCount: 1, LowestNote: 40, HighestNote: 40},
+		},
+		{
+			name: "SMPTE division has no bars",
+			data: smf(0, 0xE728, mtrk(ev(0, 0x90, 60, 80), ev(1000, 0x80, 60, 0), endOfTrack)),
+			want: MIDIInfo{TempoBPM: 120, TimeSignature: "4/4",
+				TrackCount: 1, ChannelCount: 1, LowestNote: 60, HighestNote: 60},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMIDI(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("ParseMIDI: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseMIDI =\n  %+v\nwant\n  %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMIDIInvalid(t *testing.T) {
+	valid := smf(0, 96, mtrk(ev(0, 0x90, 60, 80), endOfTrack))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a MIDI file", []byte("RIFF\x00\x00\x00\x00WAVEfmt ")},
+		{"truncated header", valid[:12]},
+		{"truncated track", valid[:len(valid)-2]},
+		{"running status without status", smf(0, 96, mtrk(ev(0, 60, 80), endOfTrack))},
+		{"missing data byte", smf(0, 96, mtrk(ev(0, 0x90, 60)))},
+		{"meta payload past the track", smf(0, 96, mtrk(ev(0, 0xFF, 0x51, 10, 1, 2)))},
+		{"delta time over four bytes", smf(0, 96, mtrk([]byte{0x81, 0x82, 0x83, 0x84, 0x05, 0x90, 60, 80}))},
+		{"song position pointer", smf(0, 96, mtrk(ev(0, 0xF2, 0, 0), ev(0, 0x90, 60, 80), endOfTrack))},
+		{"tune request", smf(0, 96, mtrk(ev(0, 0xF6), ev(0, 0x90, 60, 80), endOfTrack))},
+		{"timing clock", smf(0, 96, mtrk(ev(0, 0xF8), ev(0, 0x90, 60, 80), endOfTrack))},
+		{"active sensing", smf(0, 96, mtrk(ev(0, 0xFE), ev(0, 0x90, 60, 80), endOfTrack))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := ParseMIDI(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidMIDI) {
+				t.Errorf("ParseMIDI = %+v, %v, want ErrInvalidMIDI", info, err)
+			}
+		})
+	}
+}
+
+func TestNoteName(t *testing.T) {
+	tests := []struct {
+		note int
+		want string
+	}{
+		{60, "C4"},
+		{69, "A4"},
+		{0, "C-1"},
+		{127, "G9"},
+		{61, "C#4"},
+		{-1, ""},
+		{128, ""},
+	}
+	for _, tt := range tests {
+		if got := NoteName(tt.note); got != tt.want {
+			t.Errorf("NoteName(%d) = %q, want %q", tt.note, got, tt.want)
+		}
+	}
+}
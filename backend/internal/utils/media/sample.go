@@ -0,0 +1,340 @@
+// internal/utils/media/sample.go
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSample = errors.New("invalid WAV/AIFF file")
+
+// SampleInfo contient les métadonnées techniques d'un sample WAV ou AIFF
+type SampleInfo struct {
+	Format          string // wav, aiff
+	SampleRate      int
+	BitDepth        int
+	Channels        int
+	DurationSeconds float64
+	LoopStart       int64 // en frames, -1 si aucune boucle
+	LoopEnd         int64 // en frames, -1 si aucune boucle
+}
+
+type chunkHeader struct {
+	id   string
+	size uint32
+}
+
+func readChunkHeader(r io.Reader, order binary.ByteOrder) (chunkHeader, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return chunkHeader{}, err
+	}
+	return chunkHeader{id: string(buf[0:4]), size: order.Uint32(buf[4:8])}, nil
+}
+
+// readChunk lit le contenu d'un chunk de taille raisonnable
+func readChunk(r io.Reader, size uint32, max uint32) ([]byte, error) {
+	if size > max {
+		return nil, ErrInvalidSample
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, ErrInvalidSample
+	}
+	return buf, nil
+}
+
+// skipChunk saute le contenu d'un chunk, octet de bourrage compris
+func skipChunk(r io.ReadSeeker, size uint32) error {
+	_, err := r.Seek(int64(size)+int64(size&1), io.SeekCurrent)
+	return err
+}
+
+// ParseSample détecte le conteneur (RIFF/WAVE ou FORM/AIFF) et en extrait les métadonnées
+func ParseSample(r io.ReadSeeker) (*SampleInfo, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, ErrInvalidSample
+	}
+
+	switch {
+	case string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return parseWAV(r)
+	case string(header[0:4]) == "FORM" && (string(header[8:12]) == "AIFF" || string(header[8:12]) == "AIFC"):
+		return parseAIFF(r)
+	default:
+		return nil, ErrInvalidSample
+	}
+}
+
+func parseWAV(r io.ReadSeeker) (*SampleInfo, error) {
+	info := &SampleInfo{Format: "wav", LoopStart: -1, LoopEnd: -1}
+	var dataSize uint32
+	var blockAlign int
+	haveFmt := false
+
+	for {
+		ch, err := readChunkHeader(r, binary.LittleEndian)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidSample
+		}
+
+		switch ch.id {
+		case "fmt ":
+			buf, err := readChunk(r, ch.size, 1024)
+			if err != nil || len(buf) < 16 {
+				return nil, ErrInvalidSample
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(buf[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(buf[4:8]))
+			blockAlign = int(binary.LittleEndian.Uint16(buf[12:14]))
+			info.BitDepth = int(binary.LittleEndian.Uint16(buf[14:16]))
+			haveFmt = true
+			if ch.size&1 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		case "data":
+			dataSize = ch.size
+			if err := skipChunk(r, ch.size); err != nil {
+				return nil, ErrInvalidSample
+			}
+		case "smpl":
+			buf, err := readChunk(r, ch.size, 1<<16)
+			if err != nil {
+				return nil, err
+			}
+			// 36 octets d'en-tête puis des boucles de 24 octets
+			if len(buf) >= 36+24 && binary.LittleEndian.Uint32(buf[28:32]) > 0 {
+				info.LoopStart = int64(binary.LittleEndian.Uint32(buf[36+8 : 36+12]))
+				info.LoopEnd = int64(binary.LittleEndian.Uint32(buf[36+12 : 36+16]))
+			}
+			if ch.size&1 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		default:
+			if err := skipChunk(r, ch.size); err != nil {
+				return nil, ErrInvalidSample
+			}
+		}
+	}
+
+	if !haveFmt || info.SampleRate <= 0 || info.Channels <= 0 {
+		return nil, ErrInvalidSample
+	}
+	if blockAlign <= 0 {
+		blockAlign = info.Channels * info.BitDepth / 8
+	}
+	if blockAlign > 0 {
+		frames := float64(dataSize) / float64(blockAlign)
+		info.DurationSeconds = roundTo(frames/float64(info.SampleRate), 3)
+	}
+	return info, nil
+}
+
+func parseAIFF(r io.ReadSeeker) (*SampleInfo, error) {
+	info := &SampleInfo{Format: "aiff", LoopStart: -1, LoopEnd: -1}
+	markers := make(map[uint16]int64)
+	var sustainBegin, sustainEnd uint16
+	var sustainMode uint16
+	var frames uint32
+	haveComm := false
+
+	for {
+		ch, err := readChunkHeader(r, binary.BigEndian)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidSample
+		}
+
+		switch ch.id {
+		case "COMM":
+			buf, err := readChunk(r, ch.size, 1024)
+			if err != nil || len(buf) < 18 {
+				return nil, ErrInvalidSample
+			}
+			info.Channels = int(binary.BigEndian.Uint16(buf[0:2]))
+			frames = binary.BigEndian.Uint32(buf[2:6])
+			info.BitDepth = int(binary.BigEndian.Uint16(buf[6:8]))
+			info.SampleRate = int(math.Round(extendedToFloat(buf[8:18])))
+			haveComm = true
+			if ch.size&1 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		case "MARK":
+			buf, err := readChunk(r, ch.size, 1<<16)
+			if err != nil || len(buf) < 2 {
+				return nil, ErrInvalidSample
+			}
+			count := int(binary.BigEndian.Uint16(buf[0:2]))
+			pos := 2
+			for i := 0; i < count && pos+7 <= len(buf); i++ {
+				id := binary.BigEndian.Uint16(buf[pos : pos+2])
+				markers[id] = int64(binary.BigEndian.Uint32(buf[pos+2 : pos+6]))
+				// Nom en pstring, complété pour une longueur totale paire
+				nameLen := int(buf[pos+6])
+				pos += 6 + 1 + nameLen
+				if (1+nameLen)%2 == 1 {
+					pos++
+				}
+			}
+			if ch.size&1 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		case "INST":
+			buf, err := readChunk(r, ch.size, 1024)
+			if err != nil || len(buf) < 20 {
+				return nil, ErrInvalidSample
+			}
+			// Boucle de sustain : playMode, beginLoop, endLoop
+			sustainMode = binary.BigEndian.Uint16(buf[8:10])
+			sustainBegin = binary.BigEndian.Uint16(buf[10:12])
+			sustainEnd = binary.BigEndian.Uint16(buf[12:14])
+			if ch.size&1 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		default:
+			if err := skipChunk(r, ch.size); err != nil {
+				return nil, ErrInvalidSample
+			}
+		}
+	}
+
+	if !haveComm || info.SampleRate <= 0 || info.Channels <= 0 {
+		return nil, ErrInvalidSample
+	}
+	info.DurationSeconds = roundTo(float64(frames)/float64(info.SampleRate), 3)
+
+	if sustainMode != 0 {
+		begin, okBegin := markers[sustainBegin]
+		end, okEnd := markers[sustainEnd]
+		if okBegin && okEnd && end > begin {
+			info.LoopStart, info.LoopEnd = begin, end
+		}
+	}
+	return info, nil
+}
+
+// extendedToFloat convertit un flottant IEEE 754 80 bits (taux d'échantillonnage AIFF)
+func extendedToFloat(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	value := float64(mantissa) * math.Pow(2, float64(exponent-16383-63))
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+var (
+	filenameSeparators = regexp.MustCompile(`[_\-\s.()\[\]]+`)
+	decimalPoint       = regexp.MustCompile(`(\d)\.(\d)`)
+	bpmToken           = regexp.MustCompile(`(?i)^(\d{2,3}(?:\.\d+)?)bpm$`)
+	keyToken           = regexp.MustCompile(`^([A-G])(#|b|s|sharp|flat)?(m|min|minor|maj|major|M)?$`)
+)
+
+// GuessTempoAndKey devine le tempo et la tonalité depuis un nom de fichier
+// conventionnel, par exemple kick_128bpm_Am.wav ou loop 90 BPM F#min.aiff
+func GuessTempoAndKey(filename string) (bpm float64, key string) {
+	name := filename
+	if i := strings.LastIndex(name, "."); i > 0 {
+		name = name[:i]
+	}
+	tokens := filenameTokens(name)
+
+	var plainNumber float64
+	for i, token := range tokens {
+		if token == "" {
+			continue
+		}
+		if m := bpmToken.FindStringSubmatch(token); m != nil && bpm == 0 {
+			bpm, _ = strconv.ParseFloat(m[1], 64)
+			continue
+		}
+		if strings.EqualFold(token, "bpm") && i > 0 && bpm == 0 {
+			if v, err := strconv.ParseFloat(tokens[i-1], 64); err == nil {
+				bpm = v
+			}
+			continue
+		}
+		if v, err := strconv.Atoi(token); err == nil && v >= 60 && v <= 200 && plainNumber == 0 {
+			plainNumber = float64(v)
+			continue
+		}
+		if m := keyToken.FindStringSubmatch(token); m != nil && key == "" {
+			key = normalizeKey(m[1], m[2], m[3])
+		}
+	}
+
+	if bpm == 0 {
+		bpm = plainNumber
+	}
+	if bpm < 20 || bpm > 400 {
+		bpm = 0
+	}
+	return bpm, key
+}
+
+// filenameTokens découpe un nom de fichier ; un point entre deux chiffres ne
+// sépare pas, pour garder les tempos décimaux (128.5bpm)
+func filenameTokens(name string) []string {
+	name = decimalPoint.ReplaceAllString(name, "${1}\x00${2}")
+	tokens := filenameSeparators.Split(name, -1)
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(token, "\x00", ".")
+	}
+	return tokens
+}
+
+// sharpNotes donne l'enharmonique des notes qui ne s'écrivent pas avec un dièse
+var sharpNotes = map[string]string{
+	"Cb": "B", "Db": "C#", "Eb": "D#", "Fb": "E", "Gb": "F#", "Ab": "G#", "Bb": "A#", "E#": "F", "B#": "C",
+}
+
+// NormalizeKey ramène une tonalité (Ebm, D#min, bb major...) à son orthographe
+// enregistrée, en dièses : D#m, A#. Retourne "" si la tonalité n'est pas reconnue.
+func NormalizeKey(key string) string {
+	key = strings.ReplaceAll(strings.TrimSpace(key), " ", "")
+	if key == "" {
+		return ""
+	}
+	m := keyToken.FindStringSubmatch(strings.ToUpper(key[:1]) + key[1:])
+	if m == nil {
+		return ""
+	}
+	return normalizeKey(m[1], m[2], m[3])
+}
+
+func normalizeKey(note, accidental, quality string) string {
+	switch strings.ToLower(accidental) {
+	case "#", "s", "sharp":
+		note += "#"
+	case "b", "flat":
+		note += "b"
+	}
+	if sharp, ok := sharpNotes[note]; ok {
+		note = sharp
+	}
+	switch quality {
+	case "m", "min", "minor":
+		note += "m"
+	}
+	return note
+}
@@ -0,0 +1,292 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// riffChunk encode un chunk WAV, octet de bourrage compris
+func riffChunk(id string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(id)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+func wav(chunks ...[]byte) []byte {
+	body := append([]byte("WAVE"), bytes.Join(chunks, nil)...)
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(body)))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func fmtChunk(channels, rate, bits int) []byte {
+	blockAlign := channels * bits / 8
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data[0:2], 1) // PCM
+	binary.LittleEndian.PutUint16(data[2:4], uint16(channels))
+	binary.LittleEndian.PutUint32(data[4:8], uint32(rate))
+	binary.LittleEndian.PutUint32(data[8:12], uint32(rate*blockAlign))
+	binary.LittleEndian.PutUint16(data[12:14], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(data[14:16], uint16(bits))
+	return riffChunk("fmt ", data)
+}
+
+// smplChunk encode un chunk smpl avec une boucle par paire début, fin
+func smplChunk(loops ...[2]uint32) []byte {
+	data := make([]byte, 36+24*len(loops))
+	binary.LittleEndian.PutUint32(data[28:32], uint32(len(loops)))
+	for i, loop := range loops {
+		base := 36 + 24*i
+		binary.LittleEndian.PutUint32(data[base+8:base+12], loop[0])
+		binary.LittleEndian.PutUint32(data[base+12:base+16], loop[1])
+	}
+	return riffChunk("smpl", data)
+}
+
+func aiffChunk(id string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(id)
+	binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+func aiff(formType string, chunks ...[]byte) []byte {
+	body := append([]byte(formType), bytes.Join(chunks, nil)...)
+	var buf bytes.Buffer
+	buf.WriteString("FORM")
+	binary.Write(&buf, binary.BigEndian, uint32(len(body)))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// commChunk encode un chunk COMM ; le taux est un flottant 80 bits
+func commChunk(channels int, frames uint32, bits int, rate uint32) []byte {
+	data := make([]byte, 18)
+	binary.BigEndian.PutUint16(data[0:2], uint16(channels))
+	binary.BigEndian.PutUint32(data[2:6], frames)
+	binary.BigEndian.PutUint16(data[6:8], uint16(bits))
+	exponent := 63
+	mantissa := uint64(rate)
+	for mantissa&(1<<63) == 0 {
+		mantissa <<= 1
+		exponent--
+	}
+	binary.BigEndian.PutUint16(data[8:10], uint16(16383+exponent))
+	binary.BigEndian.PutUint64(data[10:18], mantissa)
+	return aiffChunk("COMM", data)
+}
+
+type aiffMarker struct {
+	id   uint16
+	pos  uint32
+	name string
+}
+
+func markChunk(markers ...aiffMarker) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint16(len(markers)))
+	for _, m := range markers {
+		binary.Write(&buf, binary.BigEndian, m.id)
+		binary.Write(&buf, binary.BigEndian, m.pos)
+		buf.WriteByte(byte(len(m.name)))
+		buf.WriteString(m.name)
+		if (1+len(m.name))%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+	return aiffChunk("MARK", buf.Bytes())
+}
+
+func instChunk(sustainMode, begin, end uint16) []byte {
+	data := make([]byte, 20)
+	binary.BigEndian.PutUint16(data[8:10], sustainMode)
+	binary.BigEndian.PutUint16(data[10:12], begin)
+	binary.BigEndian.PutUint16(data[12:14], end)
+	return aiffChunk("INST", data)
+}
+
+func TestParseSample(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want SampleInfo
+	}{
+		{
+			name: "stereo WAV without loop",
+			data: wav(fmtChunk(2, 44100, 16), riffChunk("data", make([]byte, 44100*4))),
+			want: SampleInfo{Format: "wav", SampleRate: 44100, BitDepth: 16, Channels: 2, DurationSeconds: 1, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "WAV with smpl loop",
+			data: wav(fmtChunk(1, 48000, 24), riffChunk("data", make([]byte, 24000*3)), smplChunk([2]uint32{100, 2000})),
+			want: SampleInfo{Format: "wav", SampleRate: 48000, BitDepth: 24, Channels: 1, DurationSeconds: 0.5, LoopStart: 100, LoopEnd: 2000},
+		},
+		{
+			name: "first of several smpl loops",
+			data: wav(smplChunk([2]uint32{10, 20}, [2]uint32{30, 40}), fmtChunk(1, 22050, 8), riffChunk("data", make([]byte, 2205))),
+			want: SampleInfo{Format: "wav", SampleRate: 22050, BitDepth: 8, Channels: 1, DurationSeconds: 0.1, LoopStart: 10, LoopEnd: 20},
+		},
+		{
+			name: "smpl without loop",
+			data: wav(fmtChunk(1, 44100, 16), smplChunk(), riffChunk("data", make([]byte, 882))),
+			want: SampleInfo{Format: "wav", SampleRate: 44100, BitDepth: 16, Channels: 1, DurationSeconds: 0.01, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "short smpl ignored",
+			data: wav(fmtChunk(1, 44100, 16), riffChunk("smpl", make([]byte, 20))),
+			want: SampleInfo{Format: "wav", SampleRate: 44100, BitDepth: 16, Channels: 1, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "odd-sized chunks are padded",
+			data: wav(riffChunk("LIST", []byte("abc")), fmtChunk(2, 44100, 16), riffChunk("junk", []byte{1}), riffChunk("data", make([]byte, 4410*4))),
+			want: SampleInfo{Format: "wav", SampleRate: 44100, BitDepth: 16, Channels: 2, DurationSeconds: 0.1, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "AIFF with sustain loop",
+			data: aiff("AIFF",
+				commChunk(1, 22050, 24, 44100),
+				markChunk(aiffMarker{1, 100, "a"}, aiffMarker{2, 5000, "end"}),
+				instChunk(1, 1, 2),
+				aiffChunk("SSND", make([]byte, 16)),
+			),
+			want: SampleInfo{Format: "aiff", SampleRate: 44100, BitDepth: 24, Channels: 1, DurationSeconds: 0.5, LoopStart: 100, LoopEnd: 5000},
+		},
+		{
+			name: "AIFF loop off",
+			data: aiff("AIFF", commChunk(2, 48000, 16, 48000), markChunk(aiffMarker{1, 0, ""}, aiffMarker{2, 10, ""}), instChunk(0, 1, 2)),
+			want: SampleInfo{Format: "aiff", SampleRate: 48000, BitDepth: 16, Channels: 2, DurationSeconds: 1, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "AIFF loop with unknown marker",
+			data: aiff("AIFF", commChunk(1, 96000, 16, 96000), markChunk(aiffMarker{1, 10, "x"}), instChunk(1, 1, 3)),
+			want: SampleInfo{Format: "aiff", SampleRate: 96000, BitDepth: 16, Channels: 1, DurationSeconds: 1, LoopStart: -1, LoopEnd: -1},
+		},
+		{
+			name: "AIFC",
+			data: aiff("AIFC", commChunk(2, 11025, 16, 22050)),
+			want: SampleInfo{Format: "aiff", SampleRate: 22050, BitDepth: 16, Channels: 2, DurationSeconds: 0.5, LoopStart: -1, LoopEnd: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSample(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("ParseSample: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseSample =\n  %+v\nwant\n  %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSampleInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"MIDI file", []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60")},
+		{"WAV without fmt", wav(riffChunk("data", make([]byte, 100)))},
+		{"short fmt", wav(riffChunk("fmt ", make([]byte, 8)))},
+		{"zero channels", wav(fmtChunk(0, 44100, 16))},
+		{"smpl past the end", append(wav(fmtChunk(1, 44100, 16)), []byte("smpl\xff\x00\x00\x00")...)},
+		{"AIFF without COMM", aiff("AIFF", aiffChunk("SSND", make([]byte, 8)))},
+		{"short COMM", aiff("AIFF", aiffChunk("COMM", make([]byte, 10)))},
+		{"short INST", aiff("AIFF", commChunk(1, 100, 16, 44100), aiffChunk("INST", make([]byte, 6)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := ParseSample(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidSample) {
+				t.Errorf("ParseSample = %+v, %v, want ErrInvalidSample", info, err)
+			}
+		})
+	}
+}
+
+func TestExtendedToFloat(t *testing.T) {
+	tests := []struct {
+		bytes []byte
+		want  float64
+	}{
+		{[]byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}, 44100},
+		{[]byte{0x40, 0x0E, 0xBB, 0x80, 0, 0, 0, 0, 0, 0}, 48000},
+		{[]byte{0x3F, 0xFF, 0x80, 0, 0, 0, 0, 0, 0, 0}, 1},
+		{[]byte{0xBF, 0xFF, 0x80, 0, 0, 0, 0, 0, 0, 0}, -1},
+		{make([]byte, 10), 0},
+	}
+	for _, tt := range tests {
+		if got := extendedToFloat(tt.bytes); got != tt.want {
+			t.Errorf("extendedToFloat(% x) = %v, want %v", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestGuessTempoAndKey(t *testing.T) {
+	tests := []struct {
+		filename string
+		bpm      float64
+		key      string
+	}{
+		{"kick_128bpm_Am.wav", 128, "Am"},
+		{"loop 90 BPM F#min.aiff", 90, "F#m"},
+		{"pad_128.5bpm_Ebm.wav", 128.5, "D#m"},
+		{"Bass Loop 92.5 BPM Db maj.wav", 92.5, "C#"},
+		{"vox.140.Bbmin.wav", 140, "A#m"},
+		{"guitar (120) [G].wav", 120, "G"},
+		{"Csharp_minor_loop_100bpm.wav", 100, "C#"},
+		{"hat_loop.wav", 0, ""},
+		{"sub_808_C.wav", 0, "C"},
+		{"tempo_500bpm.wav", 0, ""},
+		{"snare_v2_12.wav", 0, ""},
+		{"120bpm_140bpm_E.wav", 120, "E"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			bpm, key := GuessTempoAndKey(tt.filename)
+			if bpm != tt.bpm || key != tt.key {
+				t.Errorf("GuessTempoAndKey(%q) = %v, %q, want %v, %q", tt.filename, bpm, key, tt.bpm, tt.key)
+			}
+		})
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"Am", "Am"},
+		{"am", "Am"},
+		{"A minor", "Am"},
+		{"Ebm", "D#m"},
+		{"D#m", "D#m"},
+		{"Eb", "D#"},
+		{"bb major", "A#"},
+		{"Fsharp", "F#"},
+		{"Cb", "B"},
+		{"E#", "F"},
+		{"AM", "A"},
+		{"", ""},
+		{"H", ""},
+		{"not a key", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeKey(tt.key); got != tt.want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -77,6 +77,24 @@ func (cm *ChatManager) Run() {
 	}
 }
 
+// SendToUser envoie un message à toutes les connexions d'un utilisateur
+func (cm *ChatManager) SendToUser(userID int, message []byte) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	for client := range cm.clients {
+		if client.userID != userID {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(cm.clients, client)
+		}
+	}
+}
+
 func (cm *ChatManager) HandleWebSocket(c *gin.Context) {
 	// Vérifier le token
 	token := c.Query("token")
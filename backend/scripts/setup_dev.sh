@@ -56,6 +56,11 @@ DB_SSLMODE=disable
 UPLOAD_DIR=./static
 MAX_FILE_SIZE=104857600
 
+# Stockage des fichiers : local, ou s3 (AWS, MinIO...) avec S3_ENDPOINT, S3_BUCKET,
+# S3_ACCESS_KEY et S3_SECRET_KEY
+STORAGE_DRIVER=local
+STORAGE_LOCAL_ROOT=./static
+
 # Modules Rust
 CHAT_SERVER_PORT=9001
 STREAM_SERVER_PORT=8082